@@ -0,0 +1,86 @@
+package cbor
+
+import (
+	"math/big"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// AsUint64 returns the value of an unsigned integer data item of any width,
+// or of a positive bignum that fits 64 bits
+func AsUint64(item DataItem) (uint64, error) {
+	n, err := AsBigInt(item)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, errors.NewMessageErrorf(errors.ErrCborIntegerOverflow, "Integer [%s] overflows uint64", n.String())
+	}
+	return n.Uint64(), nil
+}
+
+// AsInt64 returns the value of an integer data item of any width or sign, or
+// of a bignum that fits 64 bits
+func AsInt64(item DataItem) (int64, error) {
+	n, err := AsBigInt(item)
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsInt64() {
+		return 0, errors.NewMessageErrorf(errors.ErrCborIntegerOverflow, "Integer [%s] overflows int64", n.String())
+	}
+	return n.Int64(), nil
+}
+
+// AsBigInt returns the value of an integer data item of any width or sign,
+// including bignums (tags 2 and 3)
+func AsBigInt(item DataItem) (*big.Int, error) {
+	if n, ok := bigIntFromDataItem(item); ok {
+		return n, nil
+	}
+	return nil, accessorMismatch(item, "integer")
+}
+
+// AsBytes returns the bytes of a byte string data item
+func AsBytes(item DataItem) ([]byte, error) {
+	if b, ok := item.(*ByteString); ok && b != nil {
+		return b.ValueAsBytes(), nil
+	}
+	return nil, accessorMismatch(item, "byte string")
+}
+
+// AsText returns the value of a text string data item
+func AsText(item DataItem) (string, error) {
+	if t, ok := item.(*TextString); ok && t != nil {
+		return t.ValueAsString(), nil
+	}
+	return "", accessorMismatch(item, "text string")
+}
+
+// AsBool returns the value of a true or false data item
+func AsBool(item DataItem) (bool, error) {
+	switch item.(type) {
+	case *PrimitiveTrue:
+		return true, nil
+	case *PrimitiveFalse:
+		return false, nil
+	}
+	return false, accessorMismatch(item, "boolean")
+}
+
+// AsFloat64 returns the value of a half, single or double precision float
+// data item, which float64 represents exactly
+func AsFloat64(item DataItem) (float64, error) {
+	if f, ok := item.(interface{ Float64() float64 }); ok && !isNilDataItem(item) {
+		return f.Float64(), nil
+	}
+	return 0, accessorMismatch(item, "float")
+}
+
+// accessorMismatch returns the error for a data item of another type than expected
+func accessorMismatch(item DataItem, expected string) error {
+	if isNilDataItem(item) {
+		return errors.NewMessageErrorf(errors.ErrCborTypeMismatch, "Expected %s, found no data item", expected)
+	}
+	return errors.NewMessageErrorf(errors.ErrCborTypeMismatch, "Expected %s, found [%s]", expected, item.String())
+}
@@ -0,0 +1,120 @@
+package cbor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsIntegers(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic   string
+		expectUint64 uint64
+		uint64Code   int
+		expectInt64  int64
+		int64Code    int
+		expectBigInt string
+		bigIntCode   int
+	}{
+		{diagnostic: "7", expectUint64: 7, expectInt64: 7, expectBigInt: "7"},
+		{diagnostic: "7_0", expectUint64: 7, expectInt64: 7, expectBigInt: "7"},
+		{diagnostic: "7_3", expectUint64: 7, expectInt64: 7, expectBigInt: "7"},
+		{diagnostic: "4294967296", expectUint64: 4294967296, expectInt64: 4294967296, expectBigInt: "4294967296"},
+		{diagnostic: "18446744073709551615", expectUint64: 18446744073709551615, int64Code: errors.ErrCborIntegerOverflow, expectBigInt: "18446744073709551615"},
+		{diagnostic: "-1", uint64Code: errors.ErrCborIntegerOverflow, expectInt64: -1, expectBigInt: "-1"},
+		{diagnostic: "-300_2", uint64Code: errors.ErrCborIntegerOverflow, expectInt64: -300, expectBigInt: "-300"},
+		{diagnostic: "-18446744073709551616", uint64Code: errors.ErrCborIntegerOverflow, int64Code: errors.ErrCborIntegerOverflow, expectBigInt: "-18446744073709551616"},
+		{diagnostic: "2(h'01')", expectUint64: 1, expectInt64: 1, expectBigInt: "1"},
+		{diagnostic: "2(h'010000000000000000')", uint64Code: errors.ErrCborIntegerOverflow, int64Code: errors.ErrCborIntegerOverflow, expectBigInt: "18446744073709551616"},
+		{diagnostic: "3(h'00')", uint64Code: errors.ErrCborIntegerOverflow, expectInt64: -1, expectBigInt: "-1"},
+		{diagnostic: `"7"`, uint64Code: errors.ErrCborTypeMismatch, int64Code: errors.ErrCborTypeMismatch, bigIntCode: errors.ErrCborTypeMismatch},
+		{diagnostic: "1.0", uint64Code: errors.ErrCborTypeMismatch, int64Code: errors.ErrCborTypeMismatch, bigIntCode: errors.ErrCborTypeMismatch},
+	}
+
+	for _, testCase := range testCases {
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+
+		u, err := AsUint64(item)
+		assert.Equal(t, testCase.uint64Code, errorCode(err), testCase.diagnostic)
+		assert.Equal(t, testCase.expectUint64, u, testCase.diagnostic)
+
+		i, err := AsInt64(item)
+		assert.Equal(t, testCase.int64Code, errorCode(err), testCase.diagnostic)
+		assert.Equal(t, testCase.expectInt64, i, testCase.diagnostic)
+
+		n, err := AsBigInt(item)
+		assert.Equal(t, testCase.bigIntCode, errorCode(err), testCase.diagnostic)
+		if testCase.bigIntCode == 0 {
+			expected, _ := new(big.Int).SetString(testCase.expectBigInt, 10)
+			assert.Equal(t, expected, n, testCase.diagnostic)
+		}
+	}
+}
+
+func TestAsStringsAndBool(t *testing.T) {
+
+	b, err := AsBytes(NewByteString([]byte{1, 2}))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{1, 2}, b)
+	_, err = AsBytes(NewTextString("ab"))
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	s, err := AsText(NewTextString("ab"))
+	assert.Nil(t, err)
+	assert.Equal(t, "ab", s)
+	_, err = AsText(NewByteString([]byte("ab")))
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	v, err := AsBool(NewPrimitiveTrue())
+	assert.Nil(t, err)
+	assert.True(t, v)
+	v, err = AsBool(NewPrimitiveFalse())
+	assert.Nil(t, err)
+	assert.False(t, v)
+	_, err = AsBool(NewPrimitiveNull())
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+}
+
+func TestAsNilDataItem(t *testing.T) {
+
+	_, err := AsUint64(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsInt64(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsBigInt(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsBytes(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsText(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsBool(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+}
+
+func TestAsTypedNilDataItem(t *testing.T) {
+
+	items := []DataItem{
+		(*PositiveInteger8)(nil),
+		(*NegativeInteger8)(nil),
+		(*PositiveBignum)(nil),
+		(*NegativeBignum)(nil),
+		&PositiveBignum{},
+		&NegativeBignum{},
+	}
+
+	for _, item := range items {
+		_, err := AsUint64(item)
+		assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err), "%T", item)
+		_, err = AsBigInt(item)
+		assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err), "%T", item)
+	}
+
+	_, err := AsFloat64((*PrimitiveDoublePrecisionFloat)(nil))
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+}
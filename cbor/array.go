@@ -1,9 +1,6 @@
 package cbor
 
-import (
-	"fmt"
-	"math"
-)
+import "fmt"
 
 const (
 	indefiniteBreakCodeMajorType      uint8 = 0x07
@@ -13,7 +10,8 @@ const (
 // Array represents a CBOR array
 type Array struct {
 	baseDataItem
-	V []DataItem
+	V          []DataItem
+	indefinite bool
 }
 
 // NewArray returns instance of array data items
@@ -31,6 +29,14 @@ func NewArrayWithItems(items []DataItem) *Array {
 	}
 }
 
+// NewIndefiniteArray returns an empty array that is encoded with an
+// indefinite length
+func NewIndefiniteArray() *Array {
+	a := NewArray()
+	a.indefinite = true
+	return a
+}
+
 // Value returns the array
 func (a *Array) Value() interface{} {
 	return a.V
@@ -47,10 +53,27 @@ func (a *Array) ValuesAsString() string {
 
 // EncodeCBOR returns CBOR representation for this item
 func (a *Array) EncodeCBOR() []byte {
-	if uint64(a.Length()) > math.MaxUint64 {
-		return a.doEncodeCBOR(false)
+	return a.doEncodeCBOR(!a.indefinite)
+}
+
+// IsIndefinite returns true if the array is encoded with an indefinite length
+func (a *Array) IsIndefinite() bool {
+	return a.indefinite
+}
+
+// SetIndefinite selects an indefinite or definite length for the encoding of the array
+func (a *Array) SetIndefinite(indefinite bool) {
+	a.indefinite = indefinite
+	a.raw = nil
+}
+
+// Raw returns the bytes this array was decoded from, as long as its items were
+// not replaced or modified (see baseDataItem.Raw)
+func (a *Array) Raw() []byte {
+	if !rawItemsHold(a.raw, a.V) {
+		return nil
 	}
-	return a.doEncodeCBOR(true)
+	return a.raw
 }
 
 // String returns description of this item
@@ -61,6 +84,7 @@ func (a *Array) String() string {
 // Add an item to the list
 func (a *Array) Add(item DataItem) {
 	a.V = append(a.V, item)
+	a.raw = nil
 }
 
 // AdditionalTypeValue returns the length of the array
@@ -133,3 +133,39 @@ func TestRecursiveArray(t *testing.T) {
 	assert.Equal(t, input, c[0].(*Array).EncodeCBOR())
 
 }
+
+func TestIndefiniteArray(t *testing.T) {
+
+	a := NewIndefiniteArray()
+	assert.True(t, a.IsIndefinite())
+	assert.Equal(t, []byte{0x9f, 0xff}, a.EncodeCBOR())
+	a.Add(NewPositiveInteger8(1))
+	a.Add(NewArrayWithItems([]DataItem{NewPositiveInteger8(2)}))
+	assert.Equal(t, []byte{0x9f, 0x01, 0x81, 0x02, 0xff}, a.EncodeCBOR())
+	assert.Equal(t, "[_ 1, [2]]", Diagnostic(a))
+
+	actual, err := EncodeWithOptions(a, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x82, 0x01, 0x81, 0x02}, actual)
+
+	a.SetIndefinite(false)
+	assert.False(t, a.IsIndefinite())
+	assert.Equal(t, []byte{0x82, 0x01, 0x81, 0x02}, a.EncodeCBOR())
+
+	// decoded arrays remember their length encoding, also once modified
+	c, err := Decode([]byte{0x9f, 0x01, 0xff})
+	if !assert.Nil(t, err) {
+		return
+	}
+	decoded := c[0].(*Array)
+	assert.True(t, decoded.IsIndefinite())
+	decoded.Add(NewPositiveInteger8(2))
+	assert.Equal(t, []byte{0x9f, 0x01, 0x02, 0xff}, decoded.EncodeCBOR())
+	actual, err = EncodeWithOptions(decoded, EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x9f, 0x01, 0x02, 0xff}, actual)
+
+	decoded.SetIndefinite(false)
+	assert.Nil(t, decoded.Raw())
+	assert.Equal(t, []byte{0x82, 0x01, 0x02}, decoded.EncodeCBOR())
+}
@@ -20,6 +20,7 @@ func (r *BitstreamReader) decodeArray() (*Array, error) {
 	if err != nil {
 		return nil, err
 	}
+	array.indefinite = additionalType == additionalTypeIndefinite
 
 	log.Tracef("Starting to iterate on array with length: %d", arrayLength)
 
@@ -61,11 +62,11 @@ func (r *BitstreamReader) decodeArray() (*Array, error) {
 // decodeByteString parses the next byte string object.
 // Only called after the majorType byte string has been determined.
 func (r *BitstreamReader) decodeByteString() (*ByteString, error) {
-	result, err := r.doDecodeByteString()
+	result, err := r.doDecodeByteString(MajorTypeByteString)
 	if err != nil {
 		return nil, err
 	}
-	return NewByteString(result), nil
+	return &ByteString{baseByteString: result}, nil
 }
 
 // decodeMap parses the next map object.  Only called after the majorType map has been determined.
@@ -78,6 +79,7 @@ func (r *BitstreamReader) decodeMap() (DataItem, error) {
 	if err != nil {
 		return nil, err
 	}
+	m.indefinite = additionalType == additionalTypeIndefinite
 
 	log.Tracef("Starting to iterate on map with length: %d", mapLength)
 
@@ -425,58 +427,59 @@ func (r *BitstreamReader) decodeSemantic() (DataItem, error) {
 // decodeTextString parses the next text string object.
 // Only called after the majorType text string has been determined.
 func (r *BitstreamReader) decodeTextString() (*TextString, error) {
-	result, err := r.doDecodeByteString()
+	result, err := r.doDecodeByteString(MajorTypeTextString)
 	if err != nil {
 		return nil, err
 	}
-	return NewTextString(string(result)), nil
+	return &TextString{baseByteString: result}, nil
 }
 
 // decodeByteString handles parsing the next byte string or text string.
 // Only called after the majorType byteString/textString has been determined.
-func (r *BitstreamReader) doDecodeByteString() ([]byte, error) {
+func (r *BitstreamReader) doDecodeByteString(majorType MajorType) (baseByteString, error) {
 
 	// byteLength (second parameter) in this case indicates the length of the byte/text
 	additionalType, byteLength, err := doGetAdditionalType(r)
 	if err != nil {
-		return nil, err
+		return baseByteString{}, err
 	}
 
-	payload := []byte{}
-
 	if additionalType != additionalTypeIndefinite {
 
 		log.Tracef("Reading bytes of payload length: %d", byteLength)
-		payload, err = r.ReadBytes(byteLength)
+		payload, err := r.ReadBytes(byteLength)
 		if err != nil {
-			return nil, err
+			return baseByteString{}, err
 		}
+		return newBaseByteString(majorType, payload), nil
+	}
 
-	} else {
+	payload := []byte{}
+	chunks := []uint64{}
 
-		chunkToken, err := r.ReadBitsAsUint64(8)
-		log.Tracef("ChunkLength: 0x%02x", chunkToken)
+	chunkToken, err := r.ReadBitsAsUint64(8)
+	log.Tracef("ChunkLength: 0x%02x", chunkToken)
+	if err != nil {
+		return baseByteString{}, err
+	}
+	for chunkToken != indefiniteBreakCode {
+
+		// ignore first 3 bits, only the 5 bits matters for length
+		chunkLength := chunkToken & 0x1f
+		tmp, err := r.ReadBytes(chunkLength)
 		if err != nil {
-			return nil, err
+			return baseByteString{}, err
 		}
-		for chunkToken != indefiniteBreakCode {
-
-			// ignore first 3 bits, only the 5 bits matters for length
-			chunkLength := chunkToken & 0x1f
-			tmp, err := r.ReadBytes(chunkLength)
-			if err != nil {
-				return nil, err
-			}
-			log.Tracef("Read [%d] chunk payload", len(tmp))
-			payload = append(payload, tmp...)
+		log.Tracef("Read [%d] chunk payload", len(tmp))
+		payload = append(payload, tmp...)
+		chunks = append(chunks, chunkLength)
 
-			chunkToken, err = r.ReadBitsAsUint64(8)
-			log.Tracef("ChunkLength: 0x%02x", chunkToken)
-			if err != nil {
-				return nil, err
-			}
+		chunkToken, err = r.ReadBitsAsUint64(8)
+		log.Tracef("ChunkLength: 0x%02x", chunkToken)
+		if err != nil {
+			return baseByteString{}, err
 		}
 	}
 
-	return payload, nil
+	return newChunkedBaseByteString(majorType, payload, chunks), nil
 }
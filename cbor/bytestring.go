@@ -2,16 +2,10 @@ package cbor
 
 import (
 	"fmt"
-	"math"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	// maximum chunk length is 31 (last 5 bits = 0x1f)
-	defaultMaxChunkLength uint8 = 31
-)
-
 // ByteString represents a CBOR byte string
 type ByteString struct {
 	baseByteString
@@ -20,9 +14,13 @@ type ByteString struct {
 // baseByteString wraps base attributes for byte and text
 type baseByteString struct {
 	baseDataItem
-	V                   []byte
-	length              uint64
-	chunkEncodingLength uint64
+	V      []byte
+	length uint64
+
+	// indefinite is set for a string encoded as chunks, with the length of
+	// each chunk in chunks
+	indefinite bool
+	chunks     []uint64
 }
 
 // NewByteString returns a new byte string instance
@@ -32,6 +30,35 @@ func NewByteString(value []byte) *ByteString {
 	}
 }
 
+// NewChunkedByteString returns a new indefinite length byte string made of the
+// chunks, which is encoded as such
+func NewChunkedByteString(chunks ...[]byte) *ByteString {
+	value := []byte{}
+	for _, chunk := range chunks {
+		value = append(value, chunk...)
+	}
+	return &ByteString{
+		baseByteString: newChunkedBaseByteString(MajorTypeByteString, value, chunkLengths(chunks)),
+	}
+}
+
+// NewByteStringWithChunkSize returns a new byte string that is encoded as an
+// indefinite length string in chunks of at most chunkSize bytes if it is
+// longer than chunkSize, as the Plutus data encoding of the Cardano ledger
+// does with a chunk size of 64.  It returns nil if chunkSize is 0.
+func NewByteStringWithChunkSize(value []byte, chunkSize uint64) *ByteString {
+	if chunkSize == 0 {
+		log.Error("Chunk size should be positive number")
+		return nil
+	}
+	b := NewByteString(value)
+	if b.length > chunkSize {
+		b.indefinite = true
+		b.chunks = splitChunks(b.length, chunkSize)
+	}
+	return b
+}
+
 // ValueAsBytes returns the actual bytes
 func (b *ByteString) ValueAsBytes() []byte {
 	return b.V
@@ -48,23 +75,47 @@ func newBaseByteString(majorType MajorType, value []byte) baseByteString {
 	}
 }
 
+// newChunkedBaseByteString return a baseByteString of indefinite length with
+// chunks of the given lengths, which add up to the length of value
+func newChunkedBaseByteString(majorType MajorType, value []byte, chunks []uint64) baseByteString {
+	b := newBaseByteString(majorType, value)
+	b.indefinite = true
+	b.chunks = chunks
+	return b
+}
+
 // AdditionalTypeValue returns the length of the byte string
 func (b baseByteString) AdditionalTypeValue() uint64 {
 	return b.length
 }
 
-// EncodeCBOR returns CBOR representation for this item
+// EncodeCBOR returns CBOR representation for this item, in chunks if it is
+// of indefinite length
 func (b baseByteString) EncodeCBOR() []byte {
-
-	var result []byte
-	switch {
-	case b.length > math.MaxUint64:
-		result = b.encodeAsCBORChunks(defaultMaxChunkLength)
-		break
-	default:
-		result = append(dataItemPrefix(b.majorType, b.length), b.V...)
+	if b.indefinite {
+		return b.encodeChunks(b.chunkLengths())
 	}
+	return b.encodeDefinite()
+}
 
+// IsIndefinite returns true if the string is of indefinite length, made of chunks
+func (b baseByteString) IsIndefinite() bool {
+	return b.indefinite
+}
+
+// Chunks returns the chunks of an indefinite length string, which share
+// memory with V, or nil for a definite length string
+func (b baseByteString) Chunks() [][]byte {
+	if !b.indefinite {
+		return nil
+	}
+	chunks := b.chunkLengths()
+	result := make([][]byte, 0, len(chunks))
+	offset := uint64(0)
+	for _, length := range chunks {
+		result = append(result, b.V[offset:offset+length])
+		offset += length
+	}
 	return result
 }
 
@@ -90,30 +141,74 @@ func (b *baseByteString) String() string {
 	return fmt.Sprintf("TextString - Length: [%d]; Value: [%s];", b.length, string(b.V))
 }
 
-// encodeAsCBORChunks returns a chunk payload (given maxChunkLength) using the indefinite additional tag
-func (b baseByteString) encodeAsCBORChunks(maxChunkLength uint8) []byte {
+// encodeDefinite returns the CBOR representation of the string with a definite length
+func (b baseByteString) encodeDefinite() []byte {
+	return append(dataItemPrefix(b.majorType, b.length), b.V...)
+}
 
-	if maxChunkLength > defaultMaxChunkLength {
-		log.Error("Chunking can only support 31 max bytes")
+// encodeAsCBORChunks returns a chunk payload (given maxChunkLength) using the indefinite additional tag
+func (b baseByteString) encodeAsCBORChunks(maxChunkLength uint64) []byte {
+	if maxChunkLength == 0 {
+		log.Error("Chunk length should be positive number")
 		return nil
 	}
+	return b.encodeChunks(splitChunks(b.length, maxChunkLength))
+}
+
+// encodeChunks returns the CBOR representation of the string as an
+// indefinite length string with chunks of the given lengths
+func (b baseByteString) encodeChunks(chunks []uint64) []byte {
 
 	result := []byte{b.majorType.EncodeCBOR() | additionalTypeIndefinite}
-	counter := uint64(0)
-	for counter < b.length {
-		if counter+uint64(maxChunkLength) > b.length {
-			chunkLength := b.length - counter
-			result = append(result, b.majorType.EncodeCBOR()|byte(chunkLength))
-			result = append(result, b.V[counter:]...)
-			counter += chunkLength
-		} else {
-			result = append(result, b.majorType.EncodeCBOR()|maxChunkLength)
-			result = append(result, b.V[counter:counter+uint64(maxChunkLength)]...)
-			counter += uint64(maxChunkLength)
+	offset := uint64(0)
+	for _, length := range chunks {
+		result = append(result, dataItemPrefix(b.majorType, length)...)
+		result = append(result, b.V[offset:offset+length]...)
+		offset += length
+	}
+
+	return append(result, indefiniteBreakCode)
+}
+
+// chunkLengths returns the lengths of the chunks of an indefinite length
+// string.  If V was changed since, so that they no longer add up to its
+// length, V is split again in chunks as long as the longest one.
+func (b baseByteString) chunkLengths() []uint64 {
+	total, longest := uint64(0), uint64(0)
+	for _, length := range b.chunks {
+		total += length
+		if length > longest {
+			longest = length
 		}
 	}
+	if total == uint64(len(b.V)) {
+		return b.chunks
+	}
+	if longest == 0 {
+		longest = uint64(len(b.V))
+	}
+	return splitChunks(uint64(len(b.V)), longest)
+}
 
-	result = append(result, indefiniteBreakCode)
+// splitChunks returns the lengths of the chunks of at most maxChunkLength
+// bytes of a string of the given length
+func splitChunks(length, maxChunkLength uint64) []uint64 {
+	result := make([]uint64, 0, (length+maxChunkLength-1)/maxChunkLength)
+	for length > maxChunkLength {
+		result = append(result, maxChunkLength)
+		length -= maxChunkLength
+	}
+	if length > 0 {
+		result = append(result, length)
+	}
+	return result
+}
 
+// chunkLengths returns the lengths of the chunks
+func chunkLengths(chunks [][]byte) []uint64 {
+	result := make([]uint64, 0, len(chunks))
+	for _, chunk := range chunks {
+		result = append(result, uint64(len(chunk)))
+	}
 	return result
 }
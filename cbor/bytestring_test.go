@@ -110,3 +110,71 @@ func TestByteStringEncodeAsChunks(t *testing.T) {
 	//    0102030405 # "\x01\x02\x03\x04\x05"
 	assert.Equal(t, []byte{0x45, 0x01, 0x02, 0x03, 0x04, 0x05}, b.EncodeCBOR())
 }
+
+func TestChunkedByteString(t *testing.T) {
+
+	b := NewChunkedByteString([]byte{0x01, 0x02}, []byte{0x03})
+	assert.True(t, b.IsIndefinite())
+	assert.Equal(t, []byte{0x01, 0x02, 0x03}, b.ValueAsBytes())
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {0x03}}, b.Chunks())
+	assert.Equal(t, []byte{0x5f, 0x42, 0x01, 0x02, 0x41, 0x03, 0xff}, b.EncodeCBOR())
+	assert.Equal(t, "(_ h'0102', h'03')", Diagnostic(b))
+
+	// the deterministic encoding and the comparison ignore the chunks
+	actual, err := EncodeWithOptions(b, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x43, 0x01, 0x02, 0x03}, actual)
+	assert.True(t, Equal(NewByteString([]byte{0x01, 0x02, 0x03}), b))
+
+	assert.False(t, NewByteString([]byte{0x01}).IsIndefinite())
+	assert.Nil(t, NewByteString([]byte{0x01}).Chunks())
+	assert.Equal(t, []byte{0x5f, 0xff}, NewChunkedByteString().EncodeCBOR())
+
+	// decoded strings keep their chunks
+	for _, input := range [][]byte{
+		{0x5f, 0xff},
+		{0x5f, 0x40, 0x41, 0x01, 0xff},
+		{0x5f, 0x58, 0x18, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+			0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0xff},
+	} {
+		c, err := Decode(input)
+		if assert.Nil(t, err, "%x", input) {
+			assert.True(t, c[0].(*ByteString).IsIndefinite(), "%x", input)
+			assert.Equal(t, input, c[0].EncodeCBOR(), "%x", input)
+		}
+	}
+}
+
+func TestByteStringWithChunkSize(t *testing.T) {
+
+	value := make([]byte, 70)
+	for i := range value {
+		value[i] = byte(i)
+	}
+
+	b := NewByteStringWithChunkSize(value, 64)
+	assert.True(t, b.IsIndefinite())
+	assert.Equal(t, [][]byte{value[:64], value[64:]}, b.Chunks())
+	expect := append([]byte{0x5f, 0x58, 0x40}, value[:64]...)
+	expect = append(append(expect, 0x46), value[64:]...)
+	assert.Equal(t, append(expect, 0xff), b.EncodeCBOR())
+
+	// strings that fit a chunk keep a definite length
+	b = NewByteStringWithChunkSize(value[:64], 64)
+	assert.False(t, b.IsIndefinite())
+	assert.Equal(t, append([]byte{0x58, 0x40}, value[:64]...), b.EncodeCBOR())
+
+	assert.Nil(t, NewByteStringWithChunkSize(value, 0))
+
+	// a changed value is split again in chunks of the longest length
+	b = NewByteStringWithChunkSize(value, 64)
+	b.V = value[:3]
+	assert.Equal(t, [][]byte{value[:3]}, b.Chunks())
+	assert.Equal(t, []byte{0x5f, 0x43, 0x00, 0x01, 0x02, 0xff}, b.EncodeCBOR())
+	b.V = append(value, value...)
+	assert.Equal(t, [][]byte{b.V[:64], b.V[64:128], b.V[128:]}, b.Chunks())
+
+	c := NewChunkedByteString()
+	c.V = []byte{0x01}
+	assert.Equal(t, []byte{0x5f, 0x41, 0x01, 0xff}, c.EncodeCBOR())
+}
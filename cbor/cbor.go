@@ -14,12 +14,19 @@ type DataItem interface {
 	Value() interface{}
 	EncodeCBOR() []byte
 	String() string
+	Raw() []byte
 }
 
 // baseDataItem includes attributes for all base data item structs
 type baseDataItem struct {
 	majorType      MajorType
 	additionalType uint8
+	raw            []byte
+}
+
+// rawSetter is implemented by data items that can record their original encoding
+type rawSetter interface {
+	setRaw(raw []byte)
 }
 
 // MajorType of this data item
@@ -32,8 +39,53 @@ func (b *baseDataItem) AdditionalType() uint8 {
 	return b.additionalType
 }
 
-// Decode binary data and return list of CBOR encoded data items
+// Raw returns the bytes this data item was decoded from, including indefinite
+// lengths, integer widths and map order exactly as they were received.  It is
+// nil for data items that were constructed rather than decoded, for data items
+// modified with Add after decoding, and for arrays, maps and tags holding a
+// data item that was replaced or modified since, at any depth.
+func (b *baseDataItem) Raw() []byte {
+	return b.raw
+}
+
+// setRaw records the original encoding of this data item
+func (b *baseDataItem) setRaw(raw []byte) {
+	b.raw = raw
+}
+
+// Decode binary data and return list of CBOR encoded data items.  Decoded byte
+// strings and text strings share memory with data, which must not be modified
+// afterwards.
+//
+// Only the nesting depth is limited, so Decode is unsafe for untrusted data,
+// whose announced lengths can exhaust memory; use DecodeWithOptions with
+// DecodeOptions{Hardened: true} instead.
 func Decode(data []byte) ([]DataItem, error) {
+	return DecodeWithOptions(data, DecodeOptions{})
+}
+
+// DecodeWithOptions decodes binary data (see Decode) with the given options
+func DecodeWithOptions(data []byte, options DecodeOptions) ([]DataItem, error) {
+	if err := options.checkTotalBytes(len(data)); err != nil {
+		return nil, err
+	}
+	d := newDecoder(data, options)
+	result := []DataItem{}
+	for d.hasMore() {
+		obj, err := d.decodeNext()
+		if err != nil {
+			log.Error("Error while parsing for more data items, giving up.")
+			return result, err
+		}
+		result = append(result, obj)
+	}
+
+	return result, nil
+}
+
+// decodeBitstream decodes data with the BitstreamReader.  It is the original
+// implementation of Decode, kept as a reference for tests and benchmarks.
+func decodeBitstream(data []byte) ([]DataItem, error) {
 	r := NewBitstreamReader(data)
 	result := []DataItem{}
 	for r.HasMoreBits() {
@@ -0,0 +1,43 @@
+package cbor
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// Equal returns true if the data items have the same value.  Data items are
+// compared by their core deterministic encoding (RFC 8949 section 4.2.1), so
+// the integer width, float precision, definite or indefinite lengths and the
+// order of map entries make no difference, while an integer and a float of
+// the same number are different.
+func Equal(a, b DataItem) bool {
+	return bytes.Equal(deterministicEncoding(a), deterministicEncoding(b))
+}
+
+// Compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// The order is the bytewise lexicographic order of the core deterministic
+// encodings, which is the order of map keys in that encoding, and is defined
+// across all major types.  A nil data item is less than any other.
+func Compare(a, b DataItem) int {
+	return bytes.Compare(deterministicEncoding(a), deterministicEncoding(b))
+}
+
+// Hash returns the SHA-256 digest of the core deterministic encoding of the
+// data item.  Equal data items have the same hash, which can be used as a Go
+// map key.
+func Hash(item DataItem) [sha256.Size]byte {
+	return sha256.Sum256(deterministicEncoding(item))
+}
+
+// deterministicEncoding returns the core deterministic encoding of the data item
+func deterministicEncoding(item DataItem) []byte {
+	if item == nil {
+		return nil
+	}
+	encoded, err := EncodeWithOptions(item, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	if err != nil {
+		// maps with duplicate keys have no deterministic encoding
+		return item.EncodeCBOR()
+	}
+	return encoded
+}
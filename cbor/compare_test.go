@@ -0,0 +1,134 @@
+package cbor
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+
+	testCases := []struct {
+		a      string
+		b      string
+		expect bool
+	}{
+		{a: "1", b: "1_2", expect: true},
+		{a: "-1", b: "-1_3", expect: true},
+		{a: "1.5", b: "1.5_3", expect: true},
+		{a: "NaN", b: "NaN_2", expect: true},
+		{a: "1", b: "1.0", expect: false},
+		{a: "0.0", b: "-0.0", expect: false},
+		{a: `"ab"`, b: `(_ "a", "b")`, expect: true},
+		{a: "h'0102'", b: "(_ h'01', h'02')", expect: true},
+		{a: "h'61'", b: `"a"`, expect: false},
+		{a: "[1, [2]]", b: "[_ 1, [_ 2_0]]", expect: true},
+		{a: "[1, 2]", b: "[2, 1]", expect: false},
+		{a: "{1: 2, 3: 4}", b: "{_ 3: 4, 1: 2}", expect: true},
+		{a: "{1: 2}", b: "{1: 3}", expect: false},
+		{a: "258([1, 2])", b: "258_1([1_0, 2])", expect: true},
+		{a: "258([1])", b: "259([1])", expect: false},
+		{a: "2(h'01')", b: "1", expect: true},
+		{a: "true", b: "simple(21)", expect: true},
+		{a: "null", b: "undefined", expect: false},
+	}
+
+	for _, testCase := range testCases {
+		a, err := ParseDiagnostic(testCase.a)
+		if !assert.Nil(t, err, testCase.a) {
+			continue
+		}
+		b, err := ParseDiagnostic(testCase.b)
+		if !assert.Nil(t, err, testCase.b) {
+			continue
+		}
+		assert.Equal(t, testCase.expect, Equal(a, b), "%s == %s", testCase.a, testCase.b)
+		assert.Equal(t, testCase.expect, Equal(b, a), "%s == %s", testCase.b, testCase.a)
+		assert.Equal(t, testCase.expect, Compare(a, b) == 0, "%s <=> %s", testCase.a, testCase.b)
+		assert.Equal(t, testCase.expect, Hash(a) == Hash(b), "hash %s, %s", testCase.a, testCase.b)
+	}
+
+	// constructed data items compare by value as well
+	assert.True(t, Equal(NewPositiveInteger32(1), NewPositiveInteger8(1)))
+	assert.True(t, Equal(nil, nil))
+	assert.False(t, Equal(nil, NewPrimitiveNull()))
+}
+
+func TestCompare(t *testing.T) {
+
+	// the order of the core deterministic encoding, across major types
+	ordered := []string{
+		"0", "23", "24", "255", "256", "18446744073709551615",
+		"-1", "-24", "-25",
+		"h''", "h'ff'", "h'0000'",
+		`""`, `"a"`, `"b"`, `"aa"`,
+		"[]", "[1]", "[[]]", "[1, 1]",
+		"{}", "{1: 1}", "{1: 2}", "{2: 1}",
+		`0("2013-03-21T20:04:00Z")`, "2(h'010000000000000000')", "258([])",
+		"false", "true", "null", "1.5", "1.0e+300",
+	}
+
+	items := make([]DataItem, len(ordered))
+	for i, diagnostic := range ordered {
+		item, err := ParseDiagnostic(diagnostic)
+		if !assert.Nil(t, err, diagnostic) {
+			return
+		}
+		items[i] = item
+	}
+
+	for i := range items {
+		for j := range items {
+			expect := 0
+			if i < j {
+				expect = -1
+			} else if i > j {
+				expect = 1
+			}
+			assert.Equal(t, expect, Compare(items[i], items[j]), "%s <=> %s", ordered[i], ordered[j])
+		}
+	}
+	assert.Equal(t, -1, Compare(nil, items[0]))
+
+	// the sorter agrees with Compare
+	shuffled := make([]DataItem, len(items))
+	for i := range items {
+		shuffled[i] = items[(i*7)%len(items)]
+	}
+	sort.Sort(NewDeterministicSorter(shuffled))
+	for i := range shuffled {
+		assert.Equal(t, Diagnostic(items[i]), Diagnostic(shuffled[i]))
+	}
+}
+
+func TestDataItemSorterValueOrder(t *testing.T) {
+
+	testCases := [][]DataItem{
+		{NewNegativeInteger(-3), NewNegativeInteger(-1), NewNegativeInteger(-2)},
+		{NewTextString("b"), NewTextString("aa"), NewTextString("a")},
+		{NewByteString([]byte{0x02}), NewByteString([]byte{0x01, 0x01})},
+	}
+	expected := []string{
+		"[-3, -2, -1]",
+		`["a", "aa", "b"]`,
+		"[h'0101', h'02']",
+	}
+
+	for i, items := range testCases {
+		sort.Sort(NewDataItemSorter(items))
+		assert.Equal(t, expected[i], Diagnostic(NewArrayWithItems(items)))
+	}
+}
+
+func TestHashAsMapKey(t *testing.T) {
+
+	seen := map[[32]byte]bool{}
+	for _, diagnostic := range []string{"1", "1_3", "[1, {2: 3}]", "[_ 1, {_ 2: 3_1}]", "2"} {
+		item, err := ParseDiagnostic(diagnostic)
+		if assert.Nil(t, err, diagnostic) {
+			seen[Hash(item)] = true
+		}
+	}
+	assert.Equal(t, 3, len(seen))
+}
@@ -0,0 +1,87 @@
+package cbor
+
+import (
+	"fmt"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// DecodeError locates a decoding failure in the input.  Err holds the
+// *errors.CLIError with the code of the failure; errors.Is and errors.As from
+// the standard library see through the DecodeError to it.
+type DecodeError struct {
+	// Offset is the position in the input of the data item that failed
+	Offset int
+
+	// MajorType and AdditionalType are read from the initial byte of the
+	// data item that failed, and are zero if the input ended before it
+	MajorType      MajorType
+	AdditionalType uint8
+
+	// Path leads from the top level data item to the one that failed: [i] is
+	// the element at index i of an array, {k} the value for key k of a map (in
+	// diagnostic notation), {#i} the key of the entry at index i of a map, and
+	// (n) the content of semantic tag n.  It is empty for a top level data item.
+	Path string
+
+	Err error
+}
+
+// Error string
+func (e *DecodeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "top level"
+	}
+	return fmt.Sprintf("CBOR decoding failed at offset [%d], path [%s], major type [%s], additional type [%d]: %s",
+		e.Offset, path, e.MajorType, e.AdditionalType, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the code of the underlying error, or zero if it has none
+func (e *DecodeError) Code() int {
+	if err, ok := e.Err.(*errors.CLIError); ok {
+		return err.Code()
+	}
+	return 0
+}
+
+// positionalError returns err as a DecodeError for the data item starting at
+// offset start.  Errors of nested data items are already positional.
+func (d *decoder) positionalError(err error, start int) error {
+	if _, ok := err.(*DecodeError); ok {
+		return err
+	}
+	result := &DecodeError{
+		Offset: start,
+		Err:    err,
+	}
+	if start < len(d.data) {
+		result.MajorType = MajorType(d.data[start] >> 5)
+		result.AdditionalType = d.data[start] & 0x1f
+	}
+	return result
+}
+
+// withPath prepends a path segment to a DecodeError raised in a nested data item
+func withPath(err error, format string, v ...interface{}) error {
+	if decodeError, ok := err.(*DecodeError); ok {
+		decodeError.Path = fmt.Sprintf(format, v...) + decodeError.Path
+	}
+	return err
+}
+
+// rescanError returns the DecodeError for data the scanner rejected, by
+// decoding it, so that stream errors carry a path as well.  The scanner error
+// is returned if the decoder stops for another reason.
+func rescanError(data []byte, options DecodeOptions, scanError error) error {
+	_, err := newDecoder(data, options).decodeNext()
+	if decodeError, ok := err.(*DecodeError); ok && decodeError.Code() != errors.ErrCborIncompleteDataItem {
+		return decodeError
+	}
+	return scanError
+}
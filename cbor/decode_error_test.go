@@ -0,0 +1,87 @@
+package cbor
+
+import (
+	e "errors"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// errorCode returns the code of the CLIError wrapped by err, or zero if there is none
+func errorCode(err error) int {
+	var cliError *errors.CLIError
+	if e.As(err, &cliError) {
+		return cliError.Code()
+	}
+	return 0
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+
+	testCases := []struct {
+		input          []byte
+		options        DecodeOptions
+		expectOffset   int
+		expectPath     string
+		expectMajor    MajorType
+		expectAddition uint8
+		expectCode     int
+	}{
+		{input: []byte{0x1c}, expectOffset: 0, expectPath: "", expectMajor: MajorTypePositiveInt, expectAddition: 28, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0x82, 0x00, 0x81, 0xa2, 0x01, 0x02, 0x03, 0x1c}, expectOffset: 7, expectPath: "[1][0]{3}", expectMajor: MajorTypePositiveInt, expectAddition: 28, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0xa1, 0x61, 0x61, 0x9f, 0x01, 0x1c, 0xff}, expectOffset: 5, expectPath: `{"a"}[1]`, expectMajor: MajorTypePositiveInt, expectAddition: 28, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0xa1, 0xf8, 0x10, 0x00}, expectOffset: 1, expectPath: "{#0}", expectMajor: MajorTypePrimitive, expectAddition: 24, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0xc6, 0x82, 0x01, 0x1c}, expectOffset: 3, expectPath: "(6)[1]", expectMajor: MajorTypePositiveInt, expectAddition: 28, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0x82, 0x01}, expectOffset: 2, expectPath: "[1]", expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0xd8, 0x18, 0x43, 0x82, 0x01, 0x1c}, options: DecodeOptions{UnwrapEncodedCBOR: true}, expectOffset: 5, expectPath: "(24)[1]", expectMajor: MajorTypePositiveInt, expectAddition: 28, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+	}
+
+	for _, testCase := range testCases {
+		_, err := DecodeWithOptions(testCase.input, testCase.options)
+		decodeError, ok := err.(*DecodeError)
+		if !assert.True(t, ok, "%x: %v", testCase.input, err) {
+			continue
+		}
+		assert.Equal(t, testCase.expectOffset, decodeError.Offset, "%x", testCase.input)
+		assert.Equal(t, testCase.expectPath, decodeError.Path, "%x", testCase.input)
+		assert.Equal(t, testCase.expectMajor, decodeError.MajorType, "%x", testCase.input)
+		assert.Equal(t, testCase.expectAddition, decodeError.AdditionalType, "%x", testCase.input)
+		assert.Equal(t, testCase.expectCode, decodeError.Code(), "%x", testCase.input)
+	}
+}
+
+func TestDecodeErrorUnwrap(t *testing.T) {
+
+	_, err := Decode([]byte{0x82, 0x01, 0x1c})
+	if !assert.NotNil(t, err) {
+		return
+	}
+
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrCborAdditionalTypeUnhandled)))
+	assert.False(t, e.Is(err, errors.NewError(errors.ErrCborTypeMismatch)))
+
+	var decodeError *DecodeError
+	assert.True(t, e.As(err, &decodeError))
+	assert.Equal(t, "[1]", decodeError.Path)
+	assert.Contains(t, err.Error(), "offset [2], path [[1]]")
+
+	var cliError *errors.CLIError
+	if assert.True(t, e.As(err, &cliError)) {
+		assert.Equal(t, errors.ErrCborAdditionalTypeUnhandled, cliError.Code())
+	}
+}
+
+func TestDecodeFirstError(t *testing.T) {
+
+	_, _, err := DecodeFirst([]byte{0x82, 0x01, 0x81, 0x1c})
+	decodeError, ok := err.(*DecodeError)
+	if assert.True(t, ok, "%v", err) {
+		assert.Equal(t, 3, decodeError.Offset)
+		assert.Equal(t, "[1][0]", decodeError.Path)
+	}
+
+	// incomplete data items are reported by the scanner
+	_, _, err = DecodeFirst([]byte{0x82, 0x01})
+	assert.Equal(t, errors.ErrCborIncompleteDataItem, errorCode(err))
+}
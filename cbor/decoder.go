@@ -0,0 +1,588 @@
+package cbor
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// DuplicateMapKeyMode selects how decoding handles a map key that appears
+// more than once in the same map
+type DuplicateMapKeyMode uint8
+
+const (
+	// DuplicateMapKeyLastWins keeps the last value of a duplicate key, and
+	// records the key in Map.DuplicateKeys
+	DuplicateMapKeyLastWins DuplicateMapKeyMode = iota
+
+	// DuplicateMapKeyReject fails decoding with ErrCborDuplicateMapKey
+	DuplicateMapKeyReject
+)
+
+// Default limits applied by the decoder to the limits left at zero.  The
+// nesting depth limit always applies, and the others when
+// DecodeOptions.Hardened is set.
+const (
+	DefaultMaxNestingDepth     = 64
+	DefaultMaxArrayElements    = 131072
+	DefaultMaxMapPairs         = 131072
+	DefaultMaxByteStringLength = 16 << 20
+	DefaultMaxTotalBytes       = 64 << 20
+)
+
+// DecodeOptions configures the decoding of data items.  The limits protect
+// against hostile input announcing huge lengths or nesting deeply.  A limit
+// left at zero is unlimited unless Hardened is set, except MaxNestingDepth
+// which defaults to DefaultMaxNestingDepth regardless, and a negative limit is
+// always unlimited.
+type DecodeOptions struct {
+	DuplicateMapKeys DuplicateMapKeyMode
+
+	// UnwrapEncodedCBOR replaces encoded CBOR (tag 24) with the data item it
+	// embeds, instead of returning an EncodedCBOR
+	UnwrapEncodedCBOR bool
+
+	// Hardened gives each limit left at zero its default value, for decoding
+	// data from an untrusted peer
+	Hardened bool
+
+	// MaxNestingDepth limits how deeply arrays, maps and semantic tags nest.
+	// It defaults to DefaultMaxNestingDepth even when not hardened, as the
+	// decoder recurses for each level.
+	MaxNestingDepth int
+
+	// MaxArrayElements limits the number of elements in an array
+	MaxArrayElements int
+
+	// MaxMapPairs limits the number of key/value pairs in a map
+	MaxMapPairs int
+
+	// MaxByteStringLength limits the length of a byte string or text string,
+	// including all the chunks of an indefinite length string
+	MaxByteStringLength int
+
+	// MaxTotalBytes limits the size of the input given to Decode, and the size
+	// of a single data item read by a streaming Decoder
+	MaxTotalBytes int
+}
+
+// withDefaults returns the options with the default nesting depth if unset,
+// and the default value for each other unset limit if they are hardened
+func (o DecodeOptions) withDefaults() DecodeOptions {
+	if o.MaxNestingDepth == 0 {
+		o.MaxNestingDepth = DefaultMaxNestingDepth
+	}
+	if !o.Hardened {
+		return o
+	}
+	if o.MaxArrayElements == 0 {
+		o.MaxArrayElements = DefaultMaxArrayElements
+	}
+	if o.MaxMapPairs == 0 {
+		o.MaxMapPairs = DefaultMaxMapPairs
+	}
+	if o.MaxByteStringLength == 0 {
+		o.MaxByteStringLength = DefaultMaxByteStringLength
+	}
+	if o.MaxTotalBytes == 0 {
+		o.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+	return o
+}
+
+// exceeds returns true if n is beyond a limit, which is unlimited if not positive
+func exceeds(n uint64, limit int) bool {
+	return limit > 0 && n > uint64(limit)
+}
+
+// checkTotalBytes returns an error if n bytes exceed the MaxTotalBytes limit
+func (o DecodeOptions) checkTotalBytes(n int) error {
+	if max := o.withDefaults().MaxTotalBytes; exceeds(uint64(n), max) {
+		return errors.NewMessageErrorf(errors.ErrCborMaxTotalBytesExceeded,
+			"Data of [%d] bytes exceeds the limit of [%d] bytes", n, max)
+	}
+	return nil
+}
+
+// decoder parses CBOR data items from a byte slice.  Unlike the BitstreamReader,
+// it reads the major type and additional type from a single byte and the
+// argument with one big endian read.
+//
+// Byte strings and text strings of definite length reference the input slice
+// instead of copying it, so the input must not be modified while the decoded
+// data items are in use.
+type decoder struct {
+	data    []byte
+	pos     int
+	depth   int
+	options DecodeOptions
+}
+
+// newDecoder returns a decoder positioned at the start of data
+func newDecoder(data []byte, options DecodeOptions) *decoder {
+	return &decoder{
+		data:    data,
+		options: options.withDefaults(),
+	}
+}
+
+// hasMore returns true if there are bytes left to decode
+func (d *decoder) hasMore() bool {
+	return d.pos < len(d.data)
+}
+
+// readHeader reads the initial byte and the argument following it.
+//
+// Returns format: majorType, additionalType, additionalTypeValue, error
+func (d *decoder) readHeader() (MajorType, uint8, uint64, error) {
+
+	if d.pos >= len(d.data) {
+		return 0, 0, 0, d.incomplete()
+	}
+
+	initial := d.data[d.pos]
+	majorType := MajorType(initial >> 5)
+	additionalType := initial & 0x1f
+
+	length, ok := headerLength(additionalType)
+	if !ok {
+		return majorType, additionalType, 0, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+			"Unhandled additional type [%d] at offset [%d]", additionalType, d.pos)
+	}
+	if d.pos+length > len(d.data) {
+		return majorType, additionalType, 0, d.incomplete()
+	}
+
+	value := readArgument(d.data[d.pos:], additionalType)
+	d.pos += length
+
+	return majorType, additionalType, value, nil
+}
+
+// readBytes returns the next n bytes of the input without copying them
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.pos) < n {
+		return nil, d.incomplete()
+	}
+	start := d.pos
+	d.pos += int(n)
+	return d.data[start:d.pos:d.pos], nil
+}
+
+// atBreak returns true if the next byte is the break stop code, consuming it
+func (d *decoder) atBreak() (bool, error) {
+	if d.pos >= len(d.data) {
+		return false, d.incomplete()
+	}
+	if d.data[d.pos] == indefiniteBreakCode {
+		d.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+// incomplete returns the error for data ending in the middle of a data item
+func (d *decoder) incomplete() error {
+	return errors.NewMessageErrorf(errors.ErrCborIncompleteDataItem,
+		"Data ended at offset [%d] before the data item was complete", len(d.data))
+}
+
+// decodeNext parses the next data item, and records the bytes it was decoded from
+func (d *decoder) decodeNext() (DataItem, error) {
+
+	start := d.pos
+
+	item, err := d.decodeItem()
+	if err != nil {
+		return nil, d.positionalError(err, start)
+	}
+
+	// unwrapped encoded CBOR keeps the original bytes of the embedded data item
+	if r, ok := item.(rawSetter); ok && item.Raw() == nil {
+		r.setRaw(d.data[start:d.pos:d.pos])
+	}
+
+	return item, nil
+}
+
+// decodeItem parses the data item starting with the header at the current position
+func (d *decoder) decodeItem() (DataItem, error) {
+
+	majorType, additionalType, value, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	switch majorType {
+	case MajorTypePositiveInt:
+		return d.decodePositiveInt(additionalType, value)
+	case MajorTypeNegativeInt:
+		return d.decodeNegativeInt(additionalType, value)
+	case MajorTypeByteString:
+		b, err := d.decodeString(majorType, additionalType, value)
+		if err != nil {
+			return nil, err
+		}
+		return &ByteString{baseByteString: b}, nil
+	case MajorTypeTextString:
+		b, err := d.decodeString(majorType, additionalType, value)
+		if err != nil {
+			return nil, err
+		}
+		return &TextString{baseByteString: b}, nil
+	case MajorTypeArray:
+		return d.decodeArray(additionalType, value)
+	case MajorTypeMap:
+		return d.decodeMap(additionalType, value)
+	case MajorTypeSemantic:
+		return d.decodeSemantic(additionalType, value)
+	}
+
+	return d.decodePrimitive(additionalType, value)
+}
+
+// decodePositiveInt returns the positive integer of the width given by the additional type
+func (d *decoder) decodePositiveInt(additionalType uint8, value uint64) (DataItem, error) {
+	switch additionalType {
+	case additionalType64Bits:
+		return NewPositiveInteger64(value), nil
+	case additionalType32Bits:
+		return NewPositiveInteger32(uint32(value)), nil
+	case additionalType16Bits:
+		return NewPositiveInteger16(uint16(value)), nil
+	case additionalTypeIndefinite:
+		return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+			"Indefinite length positive integer at offset [%d]", d.pos-1)
+	}
+	return NewPositiveInteger8(uint8(value)), nil
+}
+
+// decodeNegativeInt returns the negative integer of the width given by the
+// additional type.  Encoded values beyond the int64 range are returned as a
+// NegativeBignum, which is encoded again as a negative integer.
+func (d *decoder) decodeNegativeInt(additionalType uint8, value uint64) (DataItem, error) {
+
+	if value > math.MaxInt64 {
+		n := new(big.Int).SetUint64(value)
+		bignum := NewNegativeBignumber(n.Sub(big.NewInt(-1), n))
+		bignum.integer = true
+		return bignum, nil
+	}
+
+	actualValue := int64(-1) - int64(value)
+
+	switch additionalType {
+	case additionalType64Bits:
+		return NewNegativeInteger64(actualValue), nil
+	case additionalType32Bits:
+		return NewNegativeInteger32(actualValue), nil
+	case additionalType16Bits:
+		return NewNegativeInteger16(actualValue), nil
+	case additionalTypeIndefinite:
+		return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+			"Indefinite length negative integer at offset [%d]", d.pos-1)
+	}
+	return NewNegativeInteger8(actualValue), nil
+}
+
+// decodeString returns a byte string or text string.  Chunks of an indefinite
+// length string are concatenated into a new slice, and their lengths kept for
+// encoding.  Each chunk must be a definite length string of the same major
+// type as the enclosing string, as RFC 8949 requires.
+func (d *decoder) decodeString(majorType MajorType, additionalType uint8, length uint64) (baseByteString, error) {
+
+	if additionalType != additionalTypeIndefinite {
+		if err := d.checkStringLength(length); err != nil {
+			return baseByteString{}, err
+		}
+		payload, err := d.readBytes(length)
+		if err != nil {
+			return baseByteString{}, err
+		}
+		return newBaseByteString(majorType, payload), nil
+	}
+
+	payload := []byte{}
+	chunks := []uint64{}
+	for {
+		done, err := d.atBreak()
+		if err != nil {
+			return baseByteString{}, err
+		}
+		if done {
+			return newChunkedBaseByteString(majorType, payload, chunks), nil
+		}
+
+		offset := d.pos
+		chunkMajorType, chunkAdditionalType, chunkLength, err := d.readHeader()
+		if err != nil {
+			return baseByteString{}, err
+		}
+		if chunkMajorType != majorType || chunkAdditionalType == additionalTypeIndefinite {
+			return baseByteString{}, errors.NewMessageErrorf(errors.ErrCborMajorTypeUnhandled,
+				"Invalid chunk in indefinite length string at offset [%d]", offset)
+		}
+
+		if err := d.checkStringLength(uint64(len(payload)) + chunkLength); err != nil {
+			return baseByteString{}, err
+		}
+		chunk, err := d.readBytes(chunkLength)
+		if err != nil {
+			return baseByteString{}, err
+		}
+		payload = append(payload, chunk...)
+		chunks = append(chunks, chunkLength)
+	}
+}
+
+// decodeArray parses the items of an array whose header has been read
+func (d *decoder) decodeArray(additionalType uint8, length uint64) (*Array, error) {
+
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	if additionalType == additionalTypeIndefinite {
+		array := NewIndefiniteArray()
+		for {
+			done, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return array, nil
+			}
+			if err := d.checkArrayElements(uint64(array.Length()) + 1); err != nil {
+				return nil, err
+			}
+			item, err := d.decodeNext()
+			if err != nil {
+				return nil, withPath(err, "[%d]", array.Length())
+			}
+			array.Add(item)
+		}
+	}
+
+	if err := d.checkArrayElements(length); err != nil {
+		return nil, err
+	}
+
+	// every item takes at least one byte, which bounds the allocation for bogus lengths
+	items := make([]DataItem, 0, d.capacity(length))
+	for i := uint64(0); i < length; i++ {
+		item, err := d.decodeNext()
+		if err != nil {
+			return nil, withPath(err, "[%d]", i)
+		}
+		items = append(items, item)
+	}
+
+	return NewArrayWithItems(items), nil
+}
+
+// decodeMap parses the entries of a map whose header has been read
+func (d *decoder) decodeMap(additionalType uint8, length uint64) (*Map, error) {
+
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+
+	if additionalType != additionalTypeIndefinite {
+		if err := d.checkMapPairs(length); err != nil {
+			return nil, err
+		}
+	}
+
+	m := NewMap()
+	m.indefinite = additionalType == additionalTypeIndefinite
+
+	for i := uint64(0); additionalType == additionalTypeIndefinite || i < length; i++ {
+
+		if additionalType == additionalTypeIndefinite {
+			done, err := d.atBreak()
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				break
+			}
+			if err := d.checkMapPairs(i + 1); err != nil {
+				return nil, err
+			}
+		}
+
+		offset := d.pos
+		key, err := d.decodeNext()
+		if err != nil {
+			return nil, withPath(err, "{#%d}", i)
+		}
+		value, err := d.decodeNext()
+		if err != nil {
+			return nil, withPath(err, "{%s}", Diagnostic(key))
+		}
+
+		if !m.addEntry(key, value) {
+			if d.options.DuplicateMapKeys == DuplicateMapKeyReject {
+				return nil, errors.NewMessageErrorf(errors.ErrCborDuplicateMapKey,
+					"Duplicate map key [%x] at offset [%d]", key.EncodeCBOR(), offset)
+			}
+			m.duplicateKeys = append(m.duplicateKeys, key)
+		}
+	}
+
+	return m, nil
+}
+
+// decodeSemantic parses the content of a semantic tag whose header has been read
+func (d *decoder) decodeSemantic(additionalType uint8, tag uint64) (DataItem, error) {
+
+	if additionalType == additionalTypeIndefinite {
+		return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+			"Indefinite length semantic tag at offset [%d]", d.pos-1)
+	}
+
+	if err := d.enter(); err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	content, err := d.decodeNext()
+	if err != nil {
+		return nil, withPath(err, "(%d)", tag)
+	}
+
+	if tag == semanticEncodedCBORDataItems && d.options.UnwrapEncodedCBOR {
+		bytes, ok := content.(*ByteString)
+		if !ok {
+			return nil, tagContentMismatch(tag, content)
+		}
+		// the embedded data item nests in the tag, so that a chain of encoded
+		// CBOR cannot get around MaxNestingDepth
+		embedded := newDecoder(bytes.ValueAsBytes(), d.options)
+		embedded.depth = d.depth
+		item, err := embedded.decodeEmbedded()
+		if err != nil {
+			return nil, d.embeddedError(err, content, tag)
+		}
+		return item, nil
+	}
+
+	item, err := decodeTag(tag, content)
+	if encoded, ok := item.(*EncodedCBOR); ok {
+		encoded.options = d.options
+		encoded.depth = d.depth
+	}
+	return item, err
+}
+
+// decodePrimitive returns the simple value or float for the additional type
+func (d *decoder) decodePrimitive(additionalType uint8, value uint64) (DataItem, error) {
+
+	switch additionalType {
+	case primitiveFalse:
+		return NewPrimitiveFalse(), nil
+	case primitiveTrue:
+		return NewPrimitiveTrue(), nil
+	case primitiveNull:
+		return NewPrimitiveNull(), nil
+	case primitiveUndefined:
+		return NewPrimitiveUndefined(), nil
+	case primitiveSimpleValue:
+		if value < uint64(primitiveSimpleValueMin) {
+			return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+				"Invalid simple value [%d] at offset [%d]", value, d.pos-2)
+		}
+		return NewPrimitiveSimpleValue(uint8(value)), nil
+	case primitiveHalfPrecisionFloat:
+		return NewPrimitiveHalfPrecisionFloat(uint16(value)), nil
+	case primitiveSinglePrecisionFloat:
+		return NewPrimitiveSinglePrecisionFloat(math.Float32frombits(uint32(value))), nil
+	case primitiveDoublePrecisionFloat:
+		return NewPrimitiveDoublePrecisionFloat(math.Float64frombits(value)), nil
+	case primitiveBreakStopCode:
+		// a stray break is only tolerated between top level data items, as
+		// the BitstreamReader did
+		if d.depth > 0 {
+			return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+				"Unexpected break code at offset [%d]", d.pos-1)
+		}
+		return NewPrimitiveBreakStopCode(), nil
+	}
+
+	return nil, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+		"Unassigned simple value [%d] at offset [%d]", additionalType, d.pos-1)
+}
+
+// isStringMajorType returns true for the major types allowed as string chunks
+func isStringMajorType(majorType MajorType) bool {
+	return majorType == MajorTypeByteString || majorType == MajorTypeTextString
+}
+
+// embeddedError returns the error of an unwrapped encoded CBOR data item,
+// with the offset moved from the embedded bytes to the input
+func (d *decoder) embeddedError(err error, content DataItem, tag uint64) error {
+	decodeError, ok := err.(*DecodeError)
+	if !ok {
+		return err
+	}
+	// only the payload of a definite length byte string is a slice of the input
+	if raw := content.Raw(); len(raw) > 0 && raw[0]&0x1f != additionalTypeIndefinite {
+		decodeError.Offset += d.pos - len(content.(*ByteString).ValueAsBytes())
+	} else {
+		decodeError.Offset = d.pos - len(raw)
+	}
+	return withPath(decodeError, "(%d)", tag)
+}
+
+// enter records one more level of nesting, failing beyond MaxNestingDepth
+func (d *decoder) enter() error {
+	d.depth++
+	if exceeds(uint64(d.depth), d.options.MaxNestingDepth) {
+		return errors.NewMessageErrorf(errors.ErrCborMaxNestingDepthExceeded,
+			"Nesting depth exceeds the limit of [%d] at offset [%d]", d.options.MaxNestingDepth, d.pos)
+	}
+	return nil
+}
+
+// leave records the end of a level of nesting
+func (d *decoder) leave() {
+	d.depth--
+}
+
+// checkArrayElements returns an error if an array of count elements exceeds MaxArrayElements
+func (d *decoder) checkArrayElements(count uint64) error {
+	if exceeds(count, d.options.MaxArrayElements) {
+		return errors.NewMessageErrorf(errors.ErrCborMaxArrayElementsExceeded,
+			"Array exceeds the limit of [%d] elements at offset [%d]", d.options.MaxArrayElements, d.pos)
+	}
+	return nil
+}
+
+// checkMapPairs returns an error if a map of count pairs exceeds MaxMapPairs
+func (d *decoder) checkMapPairs(count uint64) error {
+	if exceeds(count, d.options.MaxMapPairs) {
+		return errors.NewMessageErrorf(errors.ErrCborMaxMapPairsExceeded,
+			"Map exceeds the limit of [%d] pairs at offset [%d]", d.options.MaxMapPairs, d.pos)
+	}
+	return nil
+}
+
+// checkStringLength returns an error if a string of length bytes exceeds MaxByteStringLength
+func (d *decoder) checkStringLength(length uint64) error {
+	if exceeds(length, d.options.MaxByteStringLength) {
+		return errors.NewMessageErrorf(errors.ErrCborMaxByteStringLengthExceeded,
+			"String of [%d] bytes exceeds the limit of [%d] bytes at offset [%d]",
+			length, d.options.MaxByteStringLength, d.pos)
+	}
+	return nil
+}
+
+// capacity returns a safe initial capacity for a container announcing count items
+func (d *decoder) capacity(count uint64) int {
+	if remaining := uint64(len(d.data) - d.pos); count > remaining {
+		return int(remaining)
+	}
+	return int(count)
+}
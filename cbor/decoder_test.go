@@ -0,0 +1,374 @@
+package cbor
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// benchmarkPayload returns a payload resembling a block: a list of transactions
+// with inputs, outputs, hashes and metadata
+func benchmarkPayload() []byte {
+
+	hash := make([]byte, 32)
+	for i := range hash {
+		hash[i] = byte(i)
+	}
+
+	txs := NewArray()
+	for i := 0; i < 200; i++ {
+		inputs := NewArray()
+		for j := 0; j < 3; j++ {
+			inputs.Add(NewArrayWithItems([]DataItem{NewByteString(hash), NewPositiveInteger(uint64(j))}))
+		}
+		outputs := NewArray()
+		for j := 0; j < 2; j++ {
+			outputs.Add(NewArrayWithItems([]DataItem{NewByteString(hash[:29]), NewPositiveInteger(uint64(1000000 * (j + 1)))}))
+		}
+		body := NewMap()
+		body.Add(NewPositiveInteger8(0), inputs)
+		body.Add(NewPositiveInteger8(1), outputs)
+		body.Add(NewPositiveInteger8(2), NewPositiveInteger(170000))
+		body.Add(NewPositiveInteger8(3), NewPositiveInteger(uint64(4000000+i)))
+		body.Add(NewPositiveInteger8(4), NewTextString("metadata"))
+		body.Add(NewPositiveInteger8(5), NewNegativeInteger(int64(-i-1)))
+		txs.Add(body)
+	}
+
+	return txs.EncodeCBOR()
+}
+
+func TestDecodeMatchesBitstreamDecoder(t *testing.T) {
+
+	testCases := [][]byte{
+		benchmarkPayload(),
+		{0x01, 0x18, 0x18, 0x19, 0x01, 0x00, 0x1a, 0x00, 0x01, 0x00, 0x00},
+		{0x20, 0x38, 0x18, 0x39, 0x01, 0x00, 0x3a, 0x00, 0x01, 0x00, 0x00},
+		{0x9f, 0x01, 0x82, 0x02, 0x03, 0xff},
+		{0xbf, 0x61, 0x61, 0x01, 0xff},
+		{0x5f, 0x42, 0x01, 0x02, 0x41, 0x03, 0xff},
+		{0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0xf4, 0xf5, 0xf6, 0xf7, 0xf8, 0x20, 0xf9, 0x3c, 0x00, 0xfa, 0x40, 0xa0, 0x00, 0x00},
+	}
+
+	for _, testCase := range testCases {
+		expect, err := decodeBitstream(testCase)
+		assert.Nil(t, err)
+		actual, err := Decode(testCase)
+		assert.Nil(t, err)
+		// maps are keyed by data item pointers, so compare the encoding and the item types
+		assert.Equal(t, EncodeList(expect), EncodeList(actual), "%x", testCase)
+		assert.Equal(t, len(expect), len(actual), "%x", testCase)
+		for i := range expect {
+			assert.IsType(t, expect[i], actual[i], "%x", testCase)
+			assert.Equal(t, expect[i].AdditionalType(), actual[i].AdditionalType(), "%x", testCase)
+		}
+	}
+}
+
+func TestDecodeByteStringsAreNotCopied(t *testing.T) {
+
+	data := []byte{0x82, 0x42, 0x01, 0x02, 0x61, 0x61}
+
+	items, err := Decode(data)
+	assert.Nil(t, err)
+
+	array := items[0].(*Array)
+	assert.Equal(t, &data[2], &array.Get(0).(*ByteString).ValueAsBytes()[0])
+	assert.Equal(t, &data[5], &array.Get(1).(*TextString).V[0])
+}
+
+func TestDecodeNegativeIntegerBeyondInt64(t *testing.T) {
+
+	items, err := Decode([]byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	assert.Nil(t, err)
+	assert.Equal(t, "-18446744073709551616", items[0].(*NegativeBignum).V.String())
+
+	// the largest encoded value that fits an int64
+	items, err = Decode([]byte{0x3b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	assert.Nil(t, err)
+	assert.Equal(t, new(big.Int).Lsh(big.NewInt(-1), 63).Int64(), items[0].(*NegativeInteger64).ValueAsInt64())
+}
+
+func TestDecodeMalformed(t *testing.T) {
+
+	testCases := []struct {
+		input      []byte
+		expectCode int
+	}{
+		{input: []byte{0x82, 0x01}, expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0x19, 0x01}, expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0x43, 0x01}, expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0x9f, 0x01}, expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0x1c}, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0x5f, 0x01, 0xff}, expectCode: errors.ErrCborMajorTypeUnhandled},
+		{input: []byte{0x5f, 0x61, 0x61, 0xff}, expectCode: errors.ErrCborMajorTypeUnhandled},
+		{input: []byte{0x7f, 0x41, 0x61, 0xff}, expectCode: errors.ErrCborMajorTypeUnhandled},
+		{input: []byte{0xf8, 0x10}, expectCode: errors.ErrCborAdditionalTypeUnhandled},
+		{input: []byte{0xc2, 0x01}, expectCode: errors.ErrCborTypeMismatch},
+		// an array announcing far more items than the data holds must not allocate them
+		{input: []byte{0x9a, 0x00, 0x01, 0x00, 0x00}, expectCode: errors.ErrCborIncompleteDataItem},
+		{input: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, expectCode: errors.ErrCborIncompleteDataItem},
+	}
+
+	for _, testCase := range testCases {
+		_, err := Decode(testCase.input)
+		if assert.NotNil(t, err, "%x", testCase.input) {
+			assert.Equal(t, testCase.expectCode, errorCode(err), "%x", testCase.input)
+		}
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic string
+		options    DecodeOptions
+		expectCode int
+	}{
+		{diagnostic: "[[1]]", options: DecodeOptions{MaxNestingDepth: 2}},
+		{diagnostic: "[[[1]]]", options: DecodeOptions{MaxNestingDepth: 2}, expectCode: errors.ErrCborMaxNestingDepthExceeded},
+		{diagnostic: "{1: [{}]}", options: DecodeOptions{MaxNestingDepth: 2}, expectCode: errors.ErrCborMaxNestingDepthExceeded},
+		{diagnostic: "6(6(6(1)))", options: DecodeOptions{MaxNestingDepth: 2}, expectCode: errors.ErrCborMaxNestingDepthExceeded},
+		{diagnostic: "[1, 2]", options: DecodeOptions{MaxArrayElements: 2}},
+		{diagnostic: "[1, 2, 3]", options: DecodeOptions{MaxArrayElements: 2}, expectCode: errors.ErrCborMaxArrayElementsExceeded},
+		{diagnostic: "[_ 1, 2, 3]", options: DecodeOptions{MaxArrayElements: 2}, expectCode: errors.ErrCborMaxArrayElementsExceeded},
+		{diagnostic: "{1: 2}", options: DecodeOptions{MaxMapPairs: 1}},
+		{diagnostic: "{1: 2, 3: 4}", options: DecodeOptions{MaxMapPairs: 1}, expectCode: errors.ErrCborMaxMapPairsExceeded},
+		{diagnostic: "{_ 1: 2, 3: 4}", options: DecodeOptions{MaxMapPairs: 1}, expectCode: errors.ErrCborMaxMapPairsExceeded},
+		{diagnostic: "h'0102'", options: DecodeOptions{MaxByteStringLength: 2}},
+		{diagnostic: "h'010203'", options: DecodeOptions{MaxByteStringLength: 2}, expectCode: errors.ErrCborMaxByteStringLengthExceeded},
+		{diagnostic: `"abc"`, options: DecodeOptions{MaxByteStringLength: 2}, expectCode: errors.ErrCborMaxByteStringLengthExceeded},
+		{diagnostic: "(_ h'01', h'0203')", options: DecodeOptions{MaxByteStringLength: 2}, expectCode: errors.ErrCborMaxByteStringLengthExceeded},
+		{diagnostic: "[1, 2]", options: DecodeOptions{MaxTotalBytes: 3}},
+		{diagnostic: "[1, 2, 3]", options: DecodeOptions{MaxTotalBytes: 3}, expectCode: errors.ErrCborMaxTotalBytesExceeded},
+	}
+
+	for _, testCase := range testCases {
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+		_, err = DecodeWithOptions(item.Raw(), testCase.options)
+		if testCase.expectCode == 0 {
+			assert.Nil(t, err, testCase.diagnostic)
+		} else if assert.NotNil(t, err, testCase.diagnostic) {
+			assert.Equal(t, testCase.expectCode, errorCode(err), testCase.diagnostic)
+		}
+	}
+
+	// the default nesting depth applies to hardened and default decoding
+	hardened := DecodeOptions{Hardened: true}
+	nested := append(bytes.Repeat([]byte{0x81}, DefaultMaxNestingDepth-1), 0x80)
+	_, err := DecodeWithOptions(nested, hardened)
+	assert.Nil(t, err)
+	_, err = DecodeWithOptions(append([]byte{0x81}, nested...), hardened)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+	_, err = DecodeWithOptions(append([]byte{0x81}, nested...), DecodeOptions{Hardened: true, MaxNestingDepth: -1})
+	assert.Nil(t, err)
+	_, err = Decode(nested)
+	assert.Nil(t, err)
+	_, err = Decode(append([]byte{0x81}, nested...))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+	_, _, err = DecodeFirst(append([]byte{0x81}, nested...))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+	_, err = DecodeWithOptions(append([]byte{0x81}, nested...), DecodeOptions{MaxNestingDepth: -1})
+	assert.Nil(t, err)
+}
+
+func TestDecodeUnlimitedByDefault(t *testing.T) {
+
+	// a map with more pairs than DefaultMaxMapPairs
+	pairs := DefaultMaxMapPairs + 1
+	data := dataItemPrefix(MajorTypeMap, uint64(pairs))
+	for i := 0; i < pairs; i++ {
+		data = append(data, dataItemPrefix(MajorTypePositiveInt, uint64(i))...)
+		data = append(data, 0xf6)
+	}
+
+	items, err := Decode(data)
+	if assert.Nil(t, err) {
+		assert.Equal(t, pairs, items[0].(*Map).Length())
+	}
+
+	item, rest, err := DecodeFirst(data)
+	if assert.Nil(t, err) {
+		assert.Equal(t, pairs, item.(*Map).Length())
+		assert.Empty(t, rest)
+	}
+
+	_, err = DecodeWithOptions(data, DecodeOptions{Hardened: true})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxMapPairsExceeded, errorCode(err))
+	}
+	_, _, err = DecodeFirstWithOptions(data, DecodeOptions{Hardened: true})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxMapPairsExceeded, errorCode(err))
+	}
+}
+
+// encodedCBORChain returns n encoded CBOR tags, each embedding the next, around the integer 1
+func encodedCBORChain(n int) []byte {
+	data := []byte{0x01}
+	for i := 0; i < n; i++ {
+		header := append([]byte{0xd8, 0x18}, dataItemPrefix(MajorTypeByteString, uint64(len(data)))...)
+		data = append(header, data...)
+	}
+	return data
+}
+
+func TestDecodeLimitsEncodedCBORChain(t *testing.T) {
+
+	options := DecodeOptions{UnwrapEncodedCBOR: true, MaxNestingDepth: 2}
+
+	items, err := DecodeWithOptions(encodedCBORChain(2), options)
+	if assert.Nil(t, err) {
+		assert.Equal(t, uint8(1), items[0].Value())
+	}
+
+	_, err = DecodeWithOptions(encodedCBORChain(3), options)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+
+	// the embedded data items count towards the depth of the enclosing ones:
+	// 24(<<[1]>>) nests 2 levels deep, and [24(<<[1]>>)] 3 levels
+	embeddedArray := []byte{0xd8, 0x18, 0x42, 0x81, 0x01}
+	_, err = DecodeWithOptions(embeddedArray, options)
+	assert.Nil(t, err)
+	_, err = DecodeWithOptions(append([]byte{0x81}, embeddedArray...), options)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+
+	_, err = DecodeWithOptions(encodedCBORChain(10000), DecodeOptions{UnwrapEncodedCBOR: true, Hardened: true})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+}
+
+// endlessArray is a reader returning an indefinite length array that never ends
+type endlessArray struct {
+	started bool
+}
+
+func (e *endlessArray) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0x01
+	}
+	if !e.started && len(p) > 0 {
+		p[0] = 0x9f
+		e.started = true
+	}
+	return len(p), nil
+}
+
+func TestDecoderMaxTotalBytes(t *testing.T) {
+
+	d := NewDecoderWithOptions(&endlessArray{}, DecodeOptions{MaxTotalBytes: 1 << 16})
+	_, err := d.Decode()
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxTotalBytesExceeded, errorCode(err))
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := benchmarkPayload()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeBitstream(b *testing.B) {
+	data := benchmarkPayload()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeBitstream(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDecodeRaw(t *testing.T) {
+
+	// [_ 1, 24(non-minimal), {"b": 1, "a": 2}, (_ h'01', h'02')]
+	data := []byte{
+		0x9f,
+		0x01,
+		0x18, 0x18,
+		0xa2, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02,
+		0x5f, 0x41, 0x01, 0x41, 0x02, 0xff,
+		0xff,
+	}
+
+	items, err := Decode(data)
+	assert.Nil(t, err)
+
+	array := items[0].(*Array)
+	assert.Equal(t, data, array.Raw())
+	assert.Equal(t, []byte{0x01}, array.Get(0).Raw())
+	assert.Equal(t, []byte{0x18, 0x18}, array.Get(1).Raw())
+	assert.Equal(t, data[4:11], array.Get(2).Raw())
+	assert.Equal(t, data[11:17], array.Get(3).Raw())
+
+	// re-encoding reproduces the indefinite lengths and the chunks
+	assert.Equal(t, data, array.EncodeCBOR())
+
+	// constructed and modified data items have no original bytes
+	assert.Nil(t, NewPositiveInteger8(1).Raw())
+	array.Add(NewPositiveInteger8(1))
+	assert.Nil(t, array.Raw())
+}
+
+func TestDecodeRawModifiedDescendant(t *testing.T) {
+
+	decode := func(data []byte) DataItem {
+		items, err := DecodeWithOptions(data, DecodeOptions{UnwrapEncodedCBOR: true})
+		assert.Nil(t, err)
+		return items[0]
+	}
+
+	// [{1: [2]}, 1000([3])]
+	data := []byte{0x82, 0xa1, 0x01, 0x81, 0x02, 0xd9, 0x03, 0xe8, 0x81, 0x03}
+
+	// adding to a nested array resets the original bytes of its ancestors
+	root := decode(data).(*Array)
+	value, _ := root.Get(0).(*Map).GetUint(1)
+	value.(*Array).Add(NewPositiveInteger8(4))
+	assert.Nil(t, root.Raw())
+	assert.Nil(t, root.Get(0).Raw())
+	assert.Equal(t, data[5:], root.Get(1).Raw())
+	actual, err := EncodeWithOptions(root, EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x82, 0xa1, 0x01, 0x82, 0x02, 0x04, 0xd9, 0x03, 0xe8, 0x81, 0x03}, actual)
+
+	// so does replacing an item, even with another decoded one
+	root = decode(data).(*Array)
+	root.V[0] = decode([]byte{0x01})
+	assert.Nil(t, root.Raw())
+	root = decode(data).(*Array)
+	root.V = root.V[:1]
+	assert.Nil(t, root.Raw())
+	root = decode(data).(*Array)
+	root.Get(1).(*Tag).Content.(*Array).V[0] = NewPositiveInteger8(5)
+	assert.Nil(t, root.Raw())
+	assert.Nil(t, root.Get(1).Raw())
+
+	// unwrapped encoded CBOR keeps the original bytes of its parents
+	data = []byte{0x82, 0xd8, 0x18, 0x44, 0xd8, 0x18, 0x41, 0x01, 0x02}
+	assert.Equal(t, data, decode(data).Raw())
+}
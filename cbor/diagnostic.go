@@ -0,0 +1,337 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/x448/float16"
+)
+
+// Diagnostic returns the diagnostic notation (RFC 8949 section 8) of the data
+// item, such as [0, {1: h'ab'}].  Decoded data items are described as they
+// were received: indefinite lengths are marked with an underscore, and
+// integers, lengths and floats that are not in their shortest form are
+// followed by an encoding indicator, such as 1.5_3 for a double precision float.
+// ParseDiagnostic reverses the notation.
+func Diagnostic(item DataItem) string {
+
+	data, err := EncodeWithOptions(item, EncodeOptions{Mode: EncodeModePreserve})
+	if err != nil {
+		data = item.EncodeCBOR()
+	}
+
+	p := &diagnosticPrinter{data: data}
+	if err := p.writeItem(); err != nil {
+		// only reachable for data items with an invalid encoding; keep the
+		// notation valid by reporting the problem as a comment
+		p.out.WriteString(" / " + err.Error() + " /")
+	}
+
+	return p.out.String()
+}
+
+// diagnosticPrinter writes the diagnostic notation of encoded data items
+type diagnosticPrinter struct {
+	data []byte
+	pos  int
+	out  strings.Builder
+}
+
+// readHeader returns the major type, additional type and argument of the next data item
+func (p *diagnosticPrinter) readHeader() (MajorType, uint8, uint64, error) {
+
+	if p.pos >= len(p.data) {
+		return 0, 0, 0, p.malformed()
+	}
+
+	majorType := MajorType(p.data[p.pos] >> 5)
+	additionalType := p.data[p.pos] & 0x1f
+
+	length, ok := headerLength(additionalType)
+	if !ok || p.pos+length > len(p.data) {
+		return 0, 0, 0, p.malformed()
+	}
+
+	value := readArgument(p.data[p.pos:], additionalType)
+	p.pos += length
+
+	return majorType, additionalType, value, nil
+}
+
+// atBreak consumes the break code if it is the next byte
+func (p *diagnosticPrinter) atBreak() bool {
+	if p.pos < len(p.data) && p.data[p.pos] == indefiniteBreakCode {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// malformed returns the error for encoded data that cannot be described
+func (p *diagnosticPrinter) malformed() error {
+	return errors.NewMessageErrorf(errors.ErrCborInvalidDiagnostic,
+		"Malformed CBOR at offset [%d]", p.pos)
+}
+
+// writeItem writes the next data item
+func (p *diagnosticPrinter) writeItem() error {
+
+	majorType, additionalType, value, err := p.readHeader()
+	if err != nil {
+		return err
+	}
+
+	indicator := encodingIndicator(additionalType, value)
+
+	switch majorType {
+	case MajorTypePositiveInt:
+		p.out.WriteString(strconv.FormatUint(value, 10) + indicator)
+
+	case MajorTypeNegativeInt:
+		n := new(big.Int).SetUint64(value)
+		p.out.WriteString(n.Sub(big.NewInt(-1), n).String() + indicator)
+
+	case MajorTypeByteString, MajorTypeTextString:
+		if additionalType == additionalTypeIndefinite {
+			return p.writeChunks(majorType)
+		}
+		if uint64(len(p.data)-p.pos) < value {
+			return p.malformed()
+		}
+		payload := p.data[p.pos : p.pos+int(value)]
+		p.pos += int(value)
+		if majorType == MajorTypeByteString {
+			p.out.WriteString("h'" + hex.EncodeToString(payload) + "'" + indicator)
+		} else {
+			p.out.WriteString(quoteDiagnostic(payload) + indicator)
+		}
+
+	case MajorTypeArray, MajorTypeMap:
+		return p.writeContainer(majorType, additionalType, value, indicator)
+
+	case MajorTypeSemantic:
+		p.out.WriteString(strconv.FormatUint(value, 10) + indicator + "(")
+		if err := p.writeItem(); err != nil {
+			return err
+		}
+		p.out.WriteString(")")
+
+	case MajorTypePrimitive:
+		return p.writePrimitive(additionalType, value)
+	}
+
+	return nil
+}
+
+// writeChunks writes an indefinite length string
+func (p *diagnosticPrinter) writeChunks(majorType MajorType) error {
+
+	if p.atBreak() {
+		if majorType == MajorTypeByteString {
+			p.out.WriteString("''_")
+		} else {
+			p.out.WriteString(`""_`)
+		}
+		return nil
+	}
+
+	p.out.WriteString("(_ ")
+	for i := 0; !p.atBreak(); i++ {
+		if i > 0 {
+			p.out.WriteString(", ")
+		}
+		if err := p.writeItem(); err != nil {
+			return err
+		}
+	}
+	p.out.WriteString(")")
+
+	return nil
+}
+
+// writeContainer writes an array or a map
+func (p *diagnosticPrinter) writeContainer(majorType MajorType, additionalType uint8, count uint64, indicator string) error {
+
+	open, close := "[", "]"
+	if majorType == MajorTypeMap {
+		open, close = "{", "}"
+	}
+
+	indefinite := additionalType == additionalTypeIndefinite
+	p.out.WriteString(open)
+	if indefinite {
+		p.out.WriteString("_ ")
+	} else if indicator != "" {
+		p.out.WriteString(indicator + " ")
+	}
+
+	for i := uint64(0); ; i++ {
+		if indefinite {
+			if p.atBreak() {
+				break
+			}
+		} else if i == count {
+			break
+		}
+		if i > 0 {
+			p.out.WriteString(", ")
+		}
+		if err := p.writeItem(); err != nil {
+			return err
+		}
+		if majorType == MajorTypeMap {
+			p.out.WriteString(": ")
+			if err := p.writeItem(); err != nil {
+				return err
+			}
+		}
+	}
+
+	p.out.WriteString(close)
+	return nil
+}
+
+// writePrimitive writes a simple value or a float
+func (p *diagnosticPrinter) writePrimitive(additionalType uint8, value uint64) error {
+
+	switch additionalType {
+	case primitiveFalse:
+		p.out.WriteString("false")
+	case primitiveTrue:
+		p.out.WriteString("true")
+	case primitiveNull:
+		p.out.WriteString("null")
+	case primitiveUndefined:
+		p.out.WriteString("undefined")
+	case primitiveSimpleValue:
+		p.out.WriteString(fmt.Sprintf("simple(%d)", value))
+	case primitiveHalfPrecisionFloat:
+		p.writeFloat(additionalType, float64(float16.Frombits(uint16(value)).Float32()))
+	case primitiveSinglePrecisionFloat:
+		p.writeFloat(additionalType, float64(math.Float32frombits(uint32(value))))
+	case primitiveDoublePrecisionFloat:
+		p.writeFloat(additionalType, math.Float64frombits(value))
+	case primitiveBreakStopCode:
+		p.pos--
+		return p.malformed()
+	default:
+		p.out.WriteString(fmt.Sprintf("simple(%d)", additionalType))
+	}
+
+	return nil
+}
+
+// writeFloat writes a float, followed by an encoding indicator if the float
+// is not in its shortest form
+func (p *diagnosticPrinter) writeFloat(additionalType uint8, f float64) {
+
+	switch {
+	case math.IsNaN(f):
+		p.out.WriteString("NaN")
+	case math.IsInf(f, 1):
+		p.out.WriteString("Infinity")
+	case math.IsInf(f, -1):
+		p.out.WriteString("-Infinity")
+	default:
+		p.out.WriteString(formatDiagnosticFloat(f))
+	}
+
+	if additionalType != shortestFloatType(f) {
+		p.out.WriteString("_" + strconv.Itoa(int(additionalType-additionalType8Bits)))
+	}
+}
+
+// formatDiagnosticFloat formats a float with the fewest digits that identify
+// it as a double, always including a fraction such as 1.0 or 1.0e+300.  The
+// digits of half and single precision floats are exact, so they parse back to
+// the same value.
+func formatDiagnosticFloat(f float64) string {
+
+	formatted := strconv.FormatFloat(f, 'g', -1, 64)
+	mantissa, exponent := formatted, ""
+	if i := strings.IndexByte(formatted, 'e'); i >= 0 {
+		mantissa, exponent = formatted[:i], formatted[i+1:]
+	}
+
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if exponent == "" {
+		return mantissa
+	}
+
+	// strip the leading zeros Go adds to short exponents
+	sign, digits := exponent[:1], strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "e" + sign + digits
+}
+
+// encodingIndicator returns the encoding indicator for an argument that is not
+// encoded in its shortest form, such as _1 for a two byte argument
+func encodingIndicator(additionalType uint8, value uint64) string {
+	if additionalType < additionalType8Bits || additionalType > additionalType64Bits {
+		return ""
+	}
+	if additionalType == shortestArgumentType(value) {
+		return ""
+	}
+	return "_" + strconv.Itoa(int(additionalType-additionalType8Bits))
+}
+
+// shortestArgumentType returns the additional type of the shortest encoding of the argument
+func shortestArgumentType(value uint64) uint8 {
+	switch {
+	case value <= uint64(additionalTypeDirectValue23):
+		return uint8(value)
+	case value <= math.MaxUint8:
+		return additionalType8Bits
+	case value <= math.MaxUint16:
+		return additionalType16Bits
+	case value <= math.MaxUint32:
+		return additionalType32Bits
+	}
+	return additionalType64Bits
+}
+
+// quoteDiagnostic returns the text as a double quoted diagnostic string
+func quoteDiagnostic(text []byte) string {
+
+	var b strings.Builder
+	b.WriteByte('"')
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,610 @@
+package cbor
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/x448/float16"
+)
+
+// indicatorNone and indicatorIndefinite are the encoding indicators that are
+// not an argument width
+const (
+	indicatorNone       = -2
+	indicatorIndefinite = -1
+)
+
+// ParseDiagnostic returns the data item described in diagnostic notation
+// (RFC 8949 section 8), as written by Diagnostic.  Encoding indicators are
+// honoured, so the data item keeps the exact encoding described (see Raw).
+// Comments between slashes are ignored.
+func ParseDiagnostic(text string) (DataItem, error) {
+
+	p := &diagnosticParser{input: text}
+	p.skipSpace()
+	if err := p.parseItem(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("Unexpected %q after the data item", p.input[p.pos])
+	}
+
+	d := newDecoder(p.buf, DecodeOptions{})
+	return d.decodeNext()
+}
+
+// diagnosticParser encodes the data item described in diagnostic notation
+type diagnosticParser struct {
+	input string
+	pos   int
+	buf   []byte
+}
+
+// errorf returns a syntax error at the current position
+func (p *diagnosticParser) errorf(format string, v ...interface{}) error {
+	v = append(v, p.pos)
+	return errors.NewMessageErrorf(errors.ErrCborInvalidDiagnostic, format+" at offset [%d]", v...)
+}
+
+// peek returns the next character, or 0 at the end of the input
+func (p *diagnosticParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+// consume skips the prefix if the input continues with it
+func (p *diagnosticParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.input[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// expect skips the prefix, or fails if the input does not continue with it
+func (p *diagnosticParser) expect(prefix string) error {
+	p.skipSpace()
+	if !p.consume(prefix) {
+		return p.errorf("Expected %q", prefix)
+	}
+	return nil
+}
+
+// skipSpace skips whitespace and comments
+func (p *diagnosticParser) skipSpace() {
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		case '/':
+			end := strings.IndexByte(p.input[p.pos+1:], '/')
+			if end < 0 {
+				return
+			}
+			p.pos += end + 2
+		default:
+			return
+		}
+	}
+}
+
+// parseIndicator parses an optional encoding indicator: _0 to _3 for the width
+// of the argument, or _ alone for an indefinite length
+func (p *diagnosticParser) parseIndicator() (int, error) {
+	if !p.consume("_") {
+		return indicatorNone, nil
+	}
+	c := p.peek()
+	if c < '0' || c > '9' {
+		return indicatorIndefinite, nil
+	}
+	if c > '3' {
+		return 0, p.errorf("Invalid encoding indicator _%c", c)
+	}
+	p.pos++
+	return int(c - '0'), nil
+}
+
+// appendHeader appends the header of a data item, with the argument in the
+// width selected by the encoding indicator
+func (p *diagnosticParser) appendHeader(majorType MajorType, value uint64, indicator int) error {
+
+	switch indicator {
+	case indicatorNone:
+		p.buf = append(p.buf, dataItemPrefix(majorType, value)...)
+		return nil
+	case indicatorIndefinite:
+		return p.errorf("Unexpected indefinite length indicator")
+	}
+
+	width := 1 << uint(indicator)
+	if width < 8 && value >= 1<<(8*uint(width)) {
+		return p.errorf("Value [%d] does not fit encoding indicator _%d", value, indicator)
+	}
+
+	var argument [8]byte
+	binary.BigEndian.PutUint64(argument[:], value)
+	p.buf = append(p.buf, majorType.EncodeCBOR()|(additionalType8Bits+uint8(indicator)))
+	p.buf = append(p.buf, argument[8-width:]...)
+	return nil
+}
+
+// parseItem parses the next data item
+func (p *diagnosticParser) parseItem() error {
+
+	c := p.peek()
+	switch {
+	case c == '[':
+		p.pos++
+		return p.parseContainer(MajorTypeArray, "]")
+	case c == '{':
+		p.pos++
+		return p.parseContainer(MajorTypeMap, "}")
+	case c == '(':
+		p.pos++
+		return p.parseChunks()
+	case c == '"':
+		text, err := p.parseQuoted('"')
+		if err != nil {
+			return err
+		}
+		return p.appendString(MajorTypeTextString, []byte(text))
+	case c == '\'':
+		text, err := p.parseQuoted('\'')
+		if err != nil {
+			return err
+		}
+		return p.appendString(MajorTypeByteString, []byte(text))
+	case strings.HasPrefix(p.input[p.pos:], "h'"):
+		return p.parseEncodedBytes("h'", decodeDiagnosticHex)
+	case strings.HasPrefix(p.input[p.pos:], "b64'"):
+		return p.parseEncodedBytes("b64'", decodeDiagnosticBase64)
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		return p.parseKeyword()
+	case c == 0:
+		return p.errorf("Unexpected end of input")
+	}
+
+	return p.errorf("Unexpected %q", c)
+}
+
+// parseContainer parses the entries of an array or a map after the opening bracket
+func (p *diagnosticParser) parseContainer(majorType MajorType, close string) error {
+
+	indicator, err := p.parseIndicator()
+	if err != nil {
+		return err
+	}
+
+	start := len(p.buf)
+	count := uint64(0)
+	for p.skipSpace(); !p.consume(close); count++ {
+		if count > 0 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			p.skipSpace()
+		}
+		if err := p.parseItem(); err != nil {
+			return err
+		}
+		if majorType == MajorTypeMap {
+			if err := p.expect(":"); err != nil {
+				return err
+			}
+			p.skipSpace()
+			if err := p.parseItem(); err != nil {
+				return err
+			}
+		}
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return p.errorf("Expected %q", close)
+		}
+	}
+
+	// the header goes before the entries once their count is known
+	entries := append([]byte{}, p.buf[start:]...)
+	p.buf = p.buf[:start]
+
+	if indicator == indicatorIndefinite {
+		p.buf = append(p.buf, majorType.EncodeCBOR()|additionalTypeIndefinite)
+		p.buf = append(p.buf, entries...)
+		p.buf = append(p.buf, indefiniteBreakCode)
+		return nil
+	}
+
+	if err := p.appendHeader(majorType, count, indicator); err != nil {
+		return err
+	}
+	p.buf = append(p.buf, entries...)
+	return nil
+}
+
+// parseChunks parses an indefinite length string such as (_ h'01', h'02')
+// after the opening parenthesis
+func (p *diagnosticParser) parseChunks() error {
+
+	if !p.consume("_") {
+		return p.errorf("Expected \"_\"")
+	}
+
+	start := len(p.buf)
+	p.buf = append(p.buf, 0)
+	for p.skipSpace(); !p.consume(")"); {
+		if len(p.buf) > start+1 {
+			if err := p.expect(","); err != nil {
+				return err
+			}
+			p.skipSpace()
+		}
+		chunk := len(p.buf)
+		if err := p.parseItem(); err != nil {
+			return err
+		}
+		majorType := MajorType(p.buf[chunk] >> 5)
+		if !isStringMajorType(majorType) || p.buf[chunk]&0x1f == additionalTypeIndefinite {
+			return p.errorf("Indefinite length string chunks must be definite length strings")
+		}
+		if chunk == start+1 {
+			p.buf[start] = majorType.EncodeCBOR() | additionalTypeIndefinite
+		} else if MajorType(p.buf[start]>>5) != majorType {
+			return p.errorf("Indefinite length string chunks must have the same type")
+		}
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return p.errorf("Expected \")\"")
+		}
+	}
+
+	if len(p.buf) == start+1 {
+		return p.errorf("Indefinite length string without chunks; use ''_ or \"\"_")
+	}
+	p.buf = append(p.buf, indefiniteBreakCode)
+	return nil
+}
+
+// appendString appends a byte or text string followed by an optional encoding indicator
+func (p *diagnosticParser) appendString(majorType MajorType, value []byte) error {
+
+	indicator, err := p.parseIndicator()
+	if err != nil {
+		return err
+	}
+
+	if indicator == indicatorIndefinite {
+		if len(value) > 0 {
+			return p.errorf("Only empty strings can be marked indefinite")
+		}
+		p.buf = append(p.buf, majorType.EncodeCBOR()|additionalTypeIndefinite, indefiniteBreakCode)
+		return nil
+	}
+
+	if err := p.appendHeader(majorType, uint64(len(value)), indicator); err != nil {
+		return err
+	}
+	p.buf = append(p.buf, value...)
+	return nil
+}
+
+// parseQuoted parses a string delimited by quote, with JSON escapes
+func (p *diagnosticParser) parseQuoted(quote byte) (string, error) {
+
+	p.pos++
+	var b strings.Builder
+
+	for {
+		if p.pos >= len(p.input) {
+			return "", p.errorf("Unterminated string")
+		}
+		c := p.input[p.pos]
+		p.pos++
+
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+// parseEscape parses the escape sequence after a backslash
+func (p *diagnosticParser) parseEscape(b *strings.Builder) error {
+
+	c := p.peek()
+	p.pos++
+
+	switch c {
+	case '"', '\'', '\\', '/':
+		b.WriteByte(c)
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'u':
+		r, err := p.parseHexRune()
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			if !p.consume(`\u`) {
+				return p.errorf("Expected a low surrogate")
+			}
+			low, err := p.parseHexRune()
+			if err != nil {
+				return err
+			}
+			r = utf16.DecodeRune(r, low)
+		}
+		if r == utf8.RuneError {
+			return p.errorf("Invalid unicode escape")
+		}
+		b.WriteRune(r)
+	default:
+		p.pos--
+		return p.errorf("Invalid escape \\%c", c)
+	}
+
+	return nil
+}
+
+// parseHexRune parses the four hex digits of a unicode escape
+func (p *diagnosticParser) parseHexRune() (rune, error) {
+	if p.pos+4 > len(p.input) {
+		return 0, p.errorf("Invalid unicode escape")
+	}
+	value, err := strconv.ParseUint(p.input[p.pos:p.pos+4], 16, 16)
+	if err != nil {
+		return 0, p.errorf("Invalid unicode escape")
+	}
+	p.pos += 4
+	return rune(value), nil
+}
+
+// parseEncodedBytes parses a byte string such as h'0102' or b64'AQI'
+func (p *diagnosticParser) parseEncodedBytes(prefix string, decode func(string) ([]byte, error)) error {
+
+	p.pos += len(prefix)
+	end := strings.IndexByte(p.input[p.pos:], '\'')
+	if end < 0 {
+		return p.errorf("Unterminated byte string")
+	}
+
+	// whitespace and comments are allowed between the digits
+	var digits strings.Builder
+	content := p.input[p.pos : p.pos+end]
+	for len(content) > 0 {
+		switch content[0] {
+		case ' ', '\t', '\n', '\r':
+			content = content[1:]
+		case '/':
+			close := strings.IndexByte(content[1:], '/')
+			if close < 0 {
+				return p.errorf("Unterminated comment")
+			}
+			content = content[close+2:]
+		default:
+			digits.WriteByte(content[0])
+			content = content[1:]
+		}
+	}
+
+	value, err := decode(digits.String())
+	if err != nil {
+		return p.errorf("Invalid byte string %s%s'", prefix, digits.String())
+	}
+
+	p.pos += end + 1
+	return p.appendString(MajorTypeByteString, value)
+}
+
+// decodeDiagnosticHex decodes the digits of a hex byte string such as h'0102'
+func decodeDiagnosticHex(digits string) ([]byte, error) {
+	return hex.DecodeString(digits)
+}
+
+// decodeDiagnosticBase64 decodes the digits of a base64 byte string such as
+// b64'AQI', in either base64 alphabet
+func decodeDiagnosticBase64(digits string) ([]byte, error) {
+	digits = strings.TrimRight(digits, "=")
+	if strings.ContainsAny(digits, "-_") {
+		return base64.RawURLEncoding.DecodeString(digits)
+	}
+	return base64.RawStdEncoding.DecodeString(digits)
+}
+
+// parseNumber parses an integer, a float or a tag
+func (p *diagnosticParser) parseNumber() error {
+
+	start := p.pos
+	negative := p.consume("-")
+	if p.consume("Infinity") {
+		return p.appendFloat(math.Inf(-1))
+	}
+
+	isFloat := false
+	base := 10
+	if p.consume("0x") || p.consume("0o") || p.consume("0b") {
+		base = map[byte]int{'x': 16, 'o': 8, 'b': 2}[p.input[p.pos-1]]
+		for c := p.peek(); c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'; c = p.peek() {
+			p.pos++
+		}
+	} else {
+		for c := p.peek(); c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E'; c = p.peek() {
+			isFloat = isFloat || c == '.' || c == 'e' || c == 'E'
+			p.pos++
+			if (c == 'e' || c == 'E') && (p.peek() == '+' || p.peek() == '-') {
+				p.pos++
+			}
+		}
+	}
+	literal := p.input[start:p.pos]
+
+	if isFloat {
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return p.errorf("Invalid number %s", literal)
+		}
+		return p.appendFloat(f)
+	}
+
+	// the digits are parsed in the base of the prefix, so that a decimal
+	// number with a leading zero is not read as octal
+	digits := strings.TrimPrefix(literal, "-")
+	if base != 10 {
+		digits = digits[2:]
+	}
+	magnitude, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return p.errorf("Invalid number %s", literal)
+	}
+
+	indicator, err := p.parseIndicator()
+	if err != nil {
+		return err
+	}
+
+	if p.peek() == '(' {
+		if negative || !magnitude.IsUint64() {
+			return p.errorf("Invalid tag number %s", literal)
+		}
+		p.pos++
+		if err := p.appendHeader(MajorTypeSemantic, magnitude.Uint64(), indicator); err != nil {
+			return err
+		}
+		p.skipSpace()
+		if err := p.parseItem(); err != nil {
+			return err
+		}
+		return p.expect(")")
+	}
+
+	majorType, value := MajorTypePositiveInt, magnitude
+	if negative && magnitude.Sign() > 0 {
+		majorType, value = MajorTypeNegativeInt, magnitude.Sub(magnitude, big.NewInt(1))
+	}
+
+	if value.IsUint64() {
+		return p.appendHeader(majorType, value.Uint64(), indicator)
+	}
+
+	// integers beyond 64 bits are bignums
+	if indicator != indicatorNone {
+		return p.errorf("Encoding indicator on bignum %s", literal)
+	}
+	tag := semanticPositiveBignum
+	if majorType == MajorTypeNegativeInt {
+		tag = semanticNegativeBignum
+	}
+	p.buf = append(p.buf, dataItemPrefix(MajorTypeSemantic, tag)...)
+	p.buf = append(p.buf, NewByteString(value.Bytes()).EncodeCBOR()...)
+	return nil
+}
+
+// appendFloat appends a float followed by an optional encoding indicator: _1,
+// _2 and _3 select the half, single and double precision
+func (p *diagnosticParser) appendFloat(f float64) error {
+
+	indicator, err := p.parseIndicator()
+	if err != nil {
+		return err
+	}
+
+	switch indicator {
+	case indicatorNone:
+		p.buf = appendShortestFloat(p.buf, f)
+	case 1:
+		if math.IsNaN(f) {
+			p.buf = appendShortestFloat(p.buf, f)
+		} else {
+			p.buf = append(p.buf, NewPrimitiveHalfPrecisionFloat(float16.Fromfloat32(float32(f)).Bits()).EncodeCBOR()...)
+		}
+	case 2:
+		p.buf = append(p.buf, NewPrimitiveSinglePrecisionFloat(float32(f)).EncodeCBOR()...)
+	case 3:
+		p.buf = append(p.buf, NewPrimitiveDoublePrecisionFloat(f).EncodeCBOR()...)
+	default:
+		return p.errorf("Invalid encoding indicator for a float")
+	}
+
+	return nil
+}
+
+// parseKeyword parses the named simple values, floats and simple(n)
+func (p *diagnosticParser) parseKeyword() error {
+
+	start := p.pos
+	for c := p.peek(); c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	keyword := p.input[start:p.pos]
+
+	switch keyword {
+	case "false":
+		p.buf = append(p.buf, MajorTypePrimitive.EncodeCBOR()|primitiveFalse)
+	case "true":
+		p.buf = append(p.buf, MajorTypePrimitive.EncodeCBOR()|primitiveTrue)
+	case "null":
+		p.buf = append(p.buf, MajorTypePrimitive.EncodeCBOR()|primitiveNull)
+	case "undefined":
+		p.buf = append(p.buf, MajorTypePrimitive.EncodeCBOR()|primitiveUndefined)
+	case "NaN":
+		return p.appendFloat(math.NaN())
+	case "Infinity":
+		return p.appendFloat(math.Inf(1))
+	case "simple":
+		return p.parseSimple()
+	default:
+		p.pos = start
+		return p.errorf("Unknown keyword %s", keyword)
+	}
+
+	return nil
+}
+
+// parseSimple parses the value of simple(n)
+func (p *diagnosticParser) parseSimple() error {
+
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	p.skipSpace()
+	start := p.pos
+	for c := p.peek(); c >= '0' && c <= '9'; c = p.peek() {
+		p.pos++
+	}
+	value, err := strconv.ParseUint(p.input[start:p.pos], 10, 8)
+	if err != nil {
+		return p.errorf("Invalid simple value")
+	}
+	if value >= uint64(additionalType8Bits) && value < uint64(primitiveSimpleValueMin) {
+		return p.errorf("Invalid simple value [%d]", value)
+	}
+
+	p.buf = append(p.buf, dataItemPrefix(MajorTypePrimitive, value)...)
+	return p.expect(")")
+}
@@ -0,0 +1,204 @@
+package cbor
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostic(t *testing.T) {
+
+	// examples from RFC 8949 appendix A, with the encoding indicators of section 8.1
+	testCases := []struct {
+		encoded    string
+		diagnostic string
+	}{
+		{encoded: "00", diagnostic: "0"},
+		{encoded: "17", diagnostic: "23"},
+		{encoded: "1818", diagnostic: "24"},
+		{encoded: "1903e8", diagnostic: "1000"},
+		{encoded: "1bffffffffffffffff", diagnostic: "18446744073709551615"},
+		{encoded: "c249010000000000000000", diagnostic: "2(h'010000000000000000')"},
+		{encoded: "3bffffffffffffffff", diagnostic: "-18446744073709551616"},
+		{encoded: "20", diagnostic: "-1"},
+		{encoded: "3903e7", diagnostic: "-1000"},
+		{encoded: "f90000", diagnostic: "0.0"},
+		{encoded: "f98000", diagnostic: "-0.0"},
+		{encoded: "f93e00", diagnostic: "1.5"},
+		{encoded: "f97bff", diagnostic: "65504.0"},
+		{encoded: "fa47c35000", diagnostic: "100000.0"},
+		{encoded: "fa7f7fffff", diagnostic: "3.4028234663852886e+38"},
+		{encoded: "fb7e37e43c8800759c", diagnostic: "1.0e+300"},
+		{encoded: "f90001", diagnostic: "5.960464477539063e-8"},
+		{encoded: "fbc010666666666666", diagnostic: "-4.1"},
+		{encoded: "f97c00", diagnostic: "Infinity"},
+		{encoded: "f97e00", diagnostic: "NaN"},
+		{encoded: "f9fc00", diagnostic: "-Infinity"},
+		{encoded: "f4", diagnostic: "false"},
+		{encoded: "f5", diagnostic: "true"},
+		{encoded: "f6", diagnostic: "null"},
+		{encoded: "f7", diagnostic: "undefined"},
+		{encoded: "f8ff", diagnostic: "simple(255)"},
+		{encoded: "c074323031332d30332d32315432303a30343a30305a", diagnostic: `0("2013-03-21T20:04:00Z")`},
+		{encoded: "c11a514b67b0", diagnostic: "1(1363896240)"},
+		{encoded: "d74401020304", diagnostic: "23(h'01020304')"},
+		{encoded: "d818456449455446", diagnostic: "24(h'6449455446')"},
+		{encoded: "40", diagnostic: "h''"},
+		{encoded: "60", diagnostic: `""`},
+		{encoded: "62225c", diagnostic: `"\"\\"`},
+		{encoded: "62c3bc", diagnostic: `"ü"`},
+		{encoded: "6401020a7f", diagnostic: `"\u0001\u0002\n\u007f"`},
+		{encoded: "80", diagnostic: "[]"},
+		{encoded: "8301820203820405", diagnostic: "[1, [2, 3], [4, 5]]"},
+		{encoded: "a201020304", diagnostic: "{1: 2, 3: 4}"},
+		{encoded: "a26161016162820203", diagnostic: `{"a": 1, "b": [2, 3]}`},
+		{encoded: "5f42010243030405ff", diagnostic: "(_ h'0102', h'030405')"},
+		{encoded: "7f657374726561646d696e67ff", diagnostic: `(_ "strea", "ming")`},
+		{encoded: "5fff", diagnostic: "''_"},
+		{encoded: "7fff", diagnostic: `""_`},
+		{encoded: "9fff", diagnostic: "[_ ]"},
+		{encoded: "9f018202039f0405ffff", diagnostic: "[_ 1, [2, 3], [_ 4, 5]]"},
+		{encoded: "bf61610161629f0203ffff", diagnostic: `{_ "a": 1, "b": [_ 2, 3]}`},
+		// encoding indicators for arguments that are not in their shortest form
+		{encoded: "1801", diagnostic: "1_0"},
+		{encoded: "3a000003e7", diagnostic: "-1000_2"},
+		{encoded: "5801ff", diagnostic: "h'ff'_0"},
+		{encoded: "98020102", diagnostic: "[_0 1, 2]"},
+		{encoded: "d9001841ff", diagnostic: "24_1(h'ff')"},
+		{encoded: "fa3fc00000", diagnostic: "1.5_2"},
+		{encoded: "fb3ff8000000000000", diagnostic: "1.5_3"},
+		{encoded: "fa7fc00000", diagnostic: "NaN_2"},
+	}
+
+	for _, testCase := range testCases {
+		encoded, _ := hex.DecodeString(testCase.encoded)
+
+		items, err := Decode(encoded)
+		if assert.Nil(t, err, testCase.encoded) {
+			assert.Equal(t, testCase.diagnostic, Diagnostic(items[0]), testCase.encoded)
+		}
+
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if assert.Nil(t, err, testCase.diagnostic) {
+			actual, _ := EncodeWithOptions(item, EncodeOptions{})
+			assert.Equal(t, testCase.encoded, hex.EncodeToString(actual), testCase.diagnostic)
+		}
+	}
+}
+
+func TestDiagnosticBuiltItems(t *testing.T) {
+
+	m := NewMap()
+	m.Add(NewPositiveInteger8(1), NewByteString([]byte{0xab, 0xcd}))
+	array := NewArrayWithItems([]DataItem{NewPositiveInteger8(0), m, NewPrimitiveDoublePrecisionFloat(1.5)})
+
+	// built items are described by their EncodeCBOR encoding
+	assert.Equal(t, "[0, {1: h'abcd'}, 1.5_3]", Diagnostic(array))
+}
+
+func TestParseDiagnosticLiterals(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic string
+		encoded    string
+	}{
+		{diagnostic: " [ 1 ,2 ] ", encoded: "820102"},
+		{diagnostic: "[1, / comment / 2]", encoded: "820102"},
+		{diagnostic: "0x1f", encoded: "181f"},
+		{diagnostic: "-0b11", encoded: "22"},
+		{diagnostic: "0o17", encoded: "0f"},
+		{diagnostic: "010", encoded: "0a"},
+		{diagnostic: "18446744073709551616", encoded: "c249010000000000000000"},
+		{diagnostic: "-18446744073709551617", encoded: "c349010000000000000000"},
+		{diagnostic: "'ab'", encoded: "426162"},
+		{diagnostic: "h'01 02 / two / 03'", encoded: "43010203"},
+		{diagnostic: "b64'AQID'", encoded: "43010203"},
+		{diagnostic: "b64'-_8'", encoded: "42fbff"},
+		{diagnostic: `"😀"`, encoded: "64f09f9880"},
+		{diagnostic: "0.1", encoded: "fb3fb999999999999a"},
+		{diagnostic: "1e3", encoded: "f963d0"},
+		{diagnostic: "0.1_2", encoded: "fa3dcccccd"},
+		{diagnostic: "-Infinity_3", encoded: "fbfff0000000000000"},
+		{diagnostic: "simple(16)", encoded: "f0"},
+	}
+
+	for _, testCase := range testCases {
+		p := &diagnosticParser{input: testCase.diagnostic}
+		p.skipSpace()
+		err := p.parseItem()
+		if assert.Nil(t, err, testCase.diagnostic) {
+			assert.Equal(t, testCase.encoded, hex.EncodeToString(p.buf), testCase.diagnostic)
+		}
+	}
+}
+
+func TestParseDiagnosticNegativeBoundary(t *testing.T) {
+
+	// negative integers down to -2^64 fit major type 1, and keep it when
+	// encoded again, while an explicit tag 3 is kept as well
+	testCases := []struct {
+		diagnostic string
+		encoded    string
+	}{
+		{diagnostic: "-9223372036854775808", encoded: "3b7fffffffffffffff"},
+		{diagnostic: "-9223372036854775809", encoded: "3b8000000000000000"},
+		{diagnostic: "-18446744073709551616", encoded: "3bffffffffffffffff"},
+		{diagnostic: "-18446744073709551617", encoded: "c349010000000000000000"},
+		{diagnostic: "3(h'ffffffffffffffff')", encoded: "c348ffffffffffffffff"},
+	}
+
+	for _, testCase := range testCases {
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if assert.Nil(t, err, testCase.diagnostic) {
+			assert.Equal(t, testCase.encoded, hex.EncodeToString(item.EncodeCBOR()), testCase.diagnostic)
+
+			parsed, err := ParseDiagnostic(Diagnostic(item))
+			if assert.Nil(t, err, testCase.diagnostic) {
+				assert.Equal(t, testCase.encoded, hex.EncodeToString(parsed.EncodeCBOR()), testCase.diagnostic)
+			}
+		}
+	}
+}
+
+func TestParseDiagnosticInvalid(t *testing.T) {
+
+	testCases := []string{
+		"",
+		"[1, 2",
+		"[1 2]",
+		"{1}",
+		"{1: 2,}",
+		"1 2",
+		"h'0'",
+		`"abc`,
+		`"\x"`,
+		"(_ )",
+		"(_ h'01', \"a\")",
+		"(_ 1)",
+		"h'01'_",
+		"256_0",
+		"1_4",
+		"0b12",
+		"0o8",
+		"0x",
+		"-1(2)",
+		"simple(24)",
+		"simple(256)",
+		"nothing",
+	}
+
+	for _, testCase := range testCases {
+		_, err := ParseDiagnostic(testCase)
+		if assert.NotNil(t, err, testCase) {
+			assert.Equal(t, errors.ErrCborInvalidDiagnostic, errorCode(err), testCase)
+		}
+	}
+
+	// the described data item must also decode
+	_, err := ParseDiagnostic("2(1)")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	}
+}
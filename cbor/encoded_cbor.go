@@ -0,0 +1,114 @@
+package cbor
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// EncodedCBOR wraps an encoded CBOR data item embedded in a byte string
+// (semantic additional type value: 24).  The embedded data item is decoded on
+// the first call to Item, and the original bytes are kept for hashing.
+type EncodedCBOR struct {
+	baseSemantic
+	V    []byte
+	once sync.Once
+	item DataItem
+	err  error
+
+	// options and depth of the decoder that read the tag, which also apply
+	// to the embedded data item
+	options DecodeOptions
+	depth   int
+}
+
+func init() {
+	RegisterTag(semanticEncodedCBORDataItems, decodeEncodedCBOR, encodeEncodedCBOR)
+}
+
+// NewEncodedCBOR returns an encoded CBOR instance holding the encoded data item
+func NewEncodedCBOR(data []byte) *EncodedCBOR {
+	return &EncodedCBOR{
+		baseSemantic: baseSemantic{
+			baseDataItem: baseDataItem{
+				majorType: MajorTypeSemantic,
+			},
+			additionalTypeValue: semanticEncodedCBORDataItems,
+		},
+		V: data,
+	}
+}
+
+// NewEncodedCBORFromItem returns an encoded CBOR instance embedding the data item
+func NewEncodedCBORFromItem(item DataItem) *EncodedCBOR {
+	e := NewEncodedCBOR(item.EncodeCBOR())
+	e.once.Do(func() {
+		e.item = item
+	})
+	return e
+}
+
+// Item returns the embedded data item, decoding it on the first call with the
+// options of the decoder that read the tag, and the nesting depth left to it
+func (e *EncodedCBOR) Item() (DataItem, error) {
+	e.once.Do(func() {
+		d := newDecoder(e.V, e.options)
+		d.depth = e.depth
+		e.item, e.err = d.decodeEmbedded()
+	})
+	return e.item, e.err
+}
+
+// ValueAsBytes returns the encoded data item
+func (e *EncodedCBOR) ValueAsBytes() []byte {
+	return e.V
+}
+
+// Value returns the encoded data item
+func (e *EncodedCBOR) Value() interface{} {
+	return e.V
+}
+
+// EncodeCBOR returns CBOR representation for this item
+func (e *EncodedCBOR) EncodeCBOR() []byte {
+	return append(
+		dataItemPrefix(MajorTypeSemantic, semanticEncodedCBORDataItems),
+		NewByteString(e.V).EncodeCBOR()...)
+}
+
+// String returns description of this item
+func (e *EncodedCBOR) String() string {
+	return fmt.Sprintf("EncodedCBOR - Length: [%d]; Value: [%x]", len(e.V), e.V)
+}
+
+// decodeEmbedded decodes the single data item held by an encoded CBOR byte string
+func (d *decoder) decodeEmbedded() (DataItem, error) {
+	item, err := d.decodeNext()
+	if err != nil {
+		return nil, err
+	}
+	if d.hasMore() {
+		return nil, errors.NewMessageErrorf(errors.ErrCborExtraneousData,
+			"Encoded CBOR has [%d] bytes after the data item", len(d.data)-d.pos)
+	}
+	return item, nil
+}
+
+// decodeEncodedCBOR decodes the content of tag 24
+func decodeEncodedCBOR(content DataItem) (DataItem, error) {
+	bytes, ok := content.(*ByteString)
+	if !ok {
+		return nil, tagContentMismatch(semanticEncodedCBORDataItems, content)
+	}
+	return NewEncodedCBOR(bytes.ValueAsBytes()), nil
+}
+
+// encodeEncodedCBOR returns the content of tag 24
+func encodeEncodedCBOR(item DataItem) (DataItem, error) {
+	e, ok := item.(*EncodedCBOR)
+	if !ok {
+		return nil, tagContentMismatch(semanticEncodedCBORDataItems, item)
+	}
+	return NewByteString(e.V), nil
+}
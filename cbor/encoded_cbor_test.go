@@ -0,0 +1,122 @@
+package cbor
+
+import (
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodedCBOR(t *testing.T) {
+
+	// [24(h'820102'), 1]
+	input := []byte{0x82, 0xd8, 0x18, 0x43, 0x82, 0x01, 0x02, 0x01}
+
+	c, err := Decode(input)
+	assert.Nil(t, err)
+	encoded, ok := c[0].(*Array).V[0].(*EncodedCBOR)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, MajorTypeSemantic, encoded.MajorType())
+	assert.Equal(t, semanticEncodedCBORDataItems, encoded.AdditionalTypeValue())
+	assert.Equal(t, []byte{0x82, 0x01, 0x02}, encoded.ValueAsBytes())
+
+	item, err := encoded.Item()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, item.(*Array).Length())
+
+	// the embedded data item is decoded only once
+	again, _ := encoded.Item()
+	assert.True(t, item == again)
+
+	assert.Equal(t, input, c[0].EncodeCBOR())
+	actual, err := EncodeWithOptions(c[0], EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, input, actual)
+}
+
+func TestEncodedCBORFromItem(t *testing.T) {
+
+	item := NewPositiveInteger8(10)
+	encoded := NewEncodedCBORFromItem(item)
+	assert.Equal(t, []byte{0xd8, 0x18, 0x41, 0x0a}, encoded.EncodeCBOR())
+
+	actual, err := encoded.Item()
+	assert.Nil(t, err)
+	assert.True(t, item == actual)
+}
+
+func TestEncodedCBORItemOptions(t *testing.T) {
+
+	item := func(data []byte, options DecodeOptions) error {
+		items, err := DecodeWithOptions(data, options)
+		if !assert.Nil(t, err) {
+			return err
+		}
+		_, err = items[0].(*Array).Get(0).(*EncodedCBOR).Item()
+		return err
+	}
+
+	// [24(<<[[1]]>>)] nests 4 levels deep
+	data := []byte{0x81, 0xd8, 0x18, 0x43, 0x81, 0x81, 0x01}
+	assert.Nil(t, item(data, DecodeOptions{MaxNestingDepth: 4}))
+	err := item(data, DecodeOptions{MaxNestingDepth: 3})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborMaxNestingDepthExceeded, errorCode(err))
+	}
+
+	// [24(<<{1: 1, 1: 2}>>)]
+	data = []byte{0x81, 0xd8, 0x18, 0x45, 0xa2, 0x01, 0x01, 0x01, 0x02}
+	assert.Nil(t, item(data, DecodeOptions{}))
+	err = item(data, DecodeOptions{DuplicateMapKeys: DuplicateMapKeyReject})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborDuplicateMapKey, errorCode(err))
+	}
+}
+
+func TestEncodedCBORInvalid(t *testing.T) {
+
+	testCases := []struct {
+		data []byte
+		code int
+	}{
+		// 24(h'') has no data item
+		{data: []byte{0xd8, 0x18, 0x40}, code: errors.ErrCborIncompleteDataItem},
+		// 24(h'0101') has extraneous data
+		{data: []byte{0xd8, 0x18, 0x42, 0x01, 0x01}, code: errors.ErrCborExtraneousData},
+	}
+
+	for _, testCase := range testCases {
+		c, err := Decode(testCase.data)
+		assert.Nil(t, err, "%x", testCase.data)
+		_, err = c[0].(*EncodedCBOR).Item()
+		if assert.NotNil(t, err, "%x", testCase.data) {
+			assert.Equal(t, testCase.code, errorCode(err), "%x", testCase.data)
+		}
+
+		_, err = DecodeWithOptions(testCase.data, DecodeOptions{UnwrapEncodedCBOR: true})
+		if assert.NotNil(t, err, "%x", testCase.data) {
+			assert.Equal(t, testCase.code, errorCode(err), "%x", testCase.data)
+		}
+	}
+
+	// the content must be a byte string
+	_, err := Decode([]byte{0xd8, 0x18, 0x01})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	}
+}
+
+func TestDecodeUnwrapEncodedCBOR(t *testing.T) {
+
+	// [24(h'd8184101'), 2], with encoded CBOR nested in encoded CBOR
+	input := []byte{0x82, 0xd8, 0x18, 0x44, 0xd8, 0x18, 0x41, 0x01, 0x02}
+
+	c, err := DecodeWithOptions(input, DecodeOptions{UnwrapEncodedCBOR: true})
+	assert.Nil(t, err)
+	array := c[0].(*Array)
+	assert.Equal(t, uint8(1), array.V[0].Value())
+	assert.Equal(t, []byte{0x01}, array.V[0].Raw())
+	assert.Equal(t, input, array.Raw())
+}
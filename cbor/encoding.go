@@ -0,0 +1,326 @@
+package cbor
+
+import (
+	"bytes"
+	"math"
+	"sort"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/x448/float16"
+)
+
+// EncodeMode selects how data items are serialized
+type EncodeMode uint8
+
+const (
+	// EncodeModePreserve reproduces the original bytes (see Raw) of decoded
+	// data items, so they can be hashed or verified as received.  Data items
+	// without original bytes are encoded as EncodeCBOR does, while keeping the
+	// original bytes of any decoded data items nested in them.
+	EncodeModePreserve EncodeMode = iota
+
+	// EncodeModeCoreDeterministic follows the core deterministic encoding
+	// requirements of RFC 8949 section 4.2.1: shortest integer and float
+	// forms, definite lengths, and map keys sorted bytewise by their encoding.
+	EncodeModeCoreDeterministic
+
+	// EncodeModeLengthFirstCanonical follows the canonical CBOR of RFC 7049
+	// section 3.9, which differs from the core deterministic encoding by
+	// sorting shorter map keys before longer ones.
+	EncodeModeLengthFirstCanonical
+)
+
+// EncodeOptions configures the serialization of data items
+type EncodeOptions struct {
+	Mode EncodeMode
+}
+
+// EncodeWithOptions returns the CBOR representation of the data item in the
+// given mode.  The deterministic modes fail when a map has duplicate keys.
+func EncodeWithOptions(item DataItem, options EncodeOptions) ([]byte, error) {
+	return appendEncoded(nil, item, options)
+}
+
+// EncodeListWithOptions returns the CBOR representation of each item in the
+// list in the given mode
+func EncodeListWithOptions(list []DataItem, options EncodeOptions) ([]byte, error) {
+	result := []byte{}
+	for _, item := range list {
+		var err error
+		result, err = appendEncoded(result, item, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// appendEncoded appends the encoding of the data item to buf
+func appendEncoded(buf []byte, item DataItem, options EncodeOptions) ([]byte, error) {
+
+	if options.Mode == EncodeModePreserve {
+		return appendPreserved(buf, item)
+	}
+
+	switch v := item.(type) {
+	case *Array:
+		buf = append(buf, dataItemPrefix(MajorTypeArray, uint64(v.Length()))...)
+		for _, element := range v.V {
+			var err error
+			buf, err = appendEncoded(buf, element, options)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+
+	case *Map:
+		return appendDeterministicMap(buf, v, options)
+
+	case *ByteString:
+		return append(buf, v.encodeDefinite()...), nil
+
+	case *TextString:
+		return append(buf, v.encodeDefinite()...), nil
+
+	case *PrimitiveHalfPrecisionFloat:
+		return appendShortestFloat(buf, float64(v.V.Float32())), nil
+
+	case *PrimitiveSinglePrecisionFloat:
+		return appendShortestFloat(buf, float64(v.V)), nil
+
+	case *PrimitiveDoublePrecisionFloat:
+		return appendShortestFloat(buf, v.V), nil
+
+	case *PositiveBignum:
+		// preferred serialization uses a plain integer when the value fits
+		if v.V.IsUint64() {
+			return append(buf, dataItemPrefix(MajorTypePositiveInt, v.V.Uint64())...), nil
+		}
+
+	case *NegativeBignum:
+		if encodedValue := v.encodedValue(); encodedValue.IsUint64() {
+			return append(buf, dataItemPrefix(MajorTypeNegativeInt, encodedValue.Uint64())...), nil
+		}
+	}
+
+	if item.MajorType() == MajorTypeSemantic {
+		content, ok, err := tagContent(item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			buf = append(buf, dataItemPrefix(MajorTypeSemantic, item.AdditionalTypeValue())...)
+			return appendEncoded(buf, content, options)
+		}
+	}
+
+	return append(buf, item.EncodeCBOR()...), nil
+}
+
+// appendPreserved appends the original bytes of the data item when available
+func appendPreserved(buf []byte, item DataItem) ([]byte, error) {
+
+	if raw := item.Raw(); raw != nil {
+		return append(buf, raw...), nil
+	}
+
+	switch v := item.(type) {
+	case *Array:
+		buf = appendContainerHeader(buf, MajorTypeArray, uint64(v.Length()), v.indefinite)
+		for _, element := range v.V {
+			var err error
+			buf, err = appendPreserved(buf, element)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return appendContainerEnd(buf, v.indefinite), nil
+
+	case *Map:
+		buf = appendContainerHeader(buf, MajorTypeMap, uint64(v.Length()), v.indefinite)
+		for i, key := range v.keys {
+			var err error
+			buf, err = appendPreserved(buf, key)
+			if err != nil {
+				return nil, err
+			}
+			buf, err = appendPreserved(buf, v.values[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return appendContainerEnd(buf, v.indefinite), nil
+
+	case *Tag:
+		buf = append(buf, dataItemPrefix(MajorTypeSemantic, v.Number)...)
+		return appendPreserved(buf, v.Content)
+	}
+
+	return append(buf, item.EncodeCBOR()...), nil
+}
+
+// rawItemsHold returns true if raw, the original bytes of a container, is still
+// made of its header, the original bytes of each item in order, and the break
+// code of an indefinite length.  It is false once an item was replaced, or
+// modified so that it lost its own original bytes.
+func rawItemsHold(raw []byte, items []DataItem) bool {
+	if len(raw) == 0 {
+		return false
+	}
+	offset, ok := headerLength(raw[0] & 0x1f)
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if offset = rawItemEnd(raw, offset, item); offset < 0 {
+			return false
+		}
+	}
+	if raw[0]&0x1f == additionalTypeIndefinite {
+		offset++
+	}
+	return offset == len(raw)
+}
+
+// rawItemEnd returns the offset in raw after the original bytes of item, which
+// start at offset, or -1 if they do not.  Encoded CBOR unwrapped by the decoder
+// keeps the bytes inside the tag 24 and byte string headers, which are skipped
+// for each level of encoded CBOR.
+func rawItemEnd(raw []byte, offset int, item DataItem) int {
+
+	itemRaw := item.Raw()
+	if len(itemRaw) == 0 {
+		return -1
+	}
+
+	end := -1
+	for offset < len(raw) && &raw[offset] != &itemRaw[0] {
+		tagLength, ok := headerLength(raw[offset] & 0x1f)
+		if !ok || offset+tagLength >= len(raw) || MajorType(raw[offset]>>5) != MajorTypeSemantic ||
+			readArgument(raw[offset:], raw[offset]&0x1f) != semanticEncodedCBORDataItems {
+			return -1
+		}
+		offset += tagLength
+		bytesLength, ok := headerLength(raw[offset] & 0x1f)
+		if !ok || offset+bytesLength > len(raw) || MajorType(raw[offset]>>5) != MajorTypeByteString ||
+			raw[offset]&0x1f == additionalTypeIndefinite {
+			return -1
+		}
+		length := readArgument(raw[offset:], raw[offset]&0x1f)
+		offset += bytesLength
+		// each level embeds exactly one data item, so they all end together
+		if uint64(len(raw)-offset) < length || end >= 0 && offset+int(length) != end {
+			return -1
+		}
+		end = offset + int(length)
+	}
+
+	if offset+len(itemRaw) > len(raw) || &raw[offset] != &itemRaw[0] ||
+		end >= 0 && offset+len(itemRaw) != end {
+		return -1
+	}
+	return offset + len(itemRaw)
+}
+
+// appendContainerHeader appends the header of an array or map of the given
+// length, or of indefinite length
+func appendContainerHeader(buf []byte, majorType MajorType, length uint64, indefinite bool) []byte {
+	if indefinite {
+		return append(buf, majorType.EncodeCBOR()|additionalTypeIndefinite)
+	}
+	return append(buf, dataItemPrefix(majorType, length)...)
+}
+
+// appendContainerEnd appends the break code ending an array or map of indefinite length
+func appendContainerEnd(buf []byte, indefinite bool) []byte {
+	if indefinite {
+		return append(buf, indefiniteBreakCode)
+	}
+	return buf
+}
+
+// appendDeterministicMap appends the map with its entries sorted by the
+// encoding of their keys
+func appendDeterministicMap(buf []byte, m *Map, options EncodeOptions) ([]byte, error) {
+
+	type entry struct {
+		key   []byte
+		value []byte
+	}
+
+	entries := make([]entry, 0, m.Length())
+	for i, key := range m.keys {
+		encodedKey, err := appendEncoded(nil, key, options)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := appendEncoded(nil, m.values[i], options)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key: encodedKey, value: encodedValue})
+	}
+
+	less := compareBytewise
+	if options.Mode == EncodeModeLengthFirstCanonical {
+		less = compareLengthFirst
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return less(entries[i].key, entries[j].key) < 0
+	})
+
+	buf = append(buf, dataItemPrefix(MajorTypeMap, uint64(len(entries)))...)
+	for i, e := range entries {
+		if i > 0 && bytes.Equal(entries[i-1].key, e.key) {
+			return nil, errors.NewMessageErrorf(errors.ErrCborDuplicateMapKey,
+				"Duplicate map key [%x]", e.key)
+		}
+		buf = append(buf, e.key...)
+		buf = append(buf, e.value...)
+	}
+
+	return buf, nil
+}
+
+// compareBytewise orders encoded keys as required by RFC 8949 section 4.2.1
+func compareBytewise(a, b []byte) int {
+	return bytes.Compare(a, b)
+}
+
+// compareLengthFirst orders encoded keys as required by RFC 7049 section 3.9
+func compareLengthFirst(a, b []byte) int {
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return bytes.Compare(a, b)
+}
+
+// appendShortestFloat appends the shortest float encoding that represents f
+// exactly (see NewFloat)
+func appendShortestFloat(buf []byte, f float64) []byte {
+	return append(buf, NewFloat(f).EncodeCBOR()...)
+}
+
+// shortestFloatType returns the additional type of the shortest float
+// encoding that represents f exactly
+func shortestFloatType(f float64) uint8 {
+
+	if math.IsNaN(f) {
+		return primitiveHalfPrecisionFloat
+	}
+
+	f32 := float32(f)
+	if float64(f32) != f {
+		return primitiveDoublePrecisionFloat
+	}
+
+	if float16.Fromfloat32(f32).Float32() == f32 {
+		return primitiveHalfPrecisionFloat
+	}
+
+	return primitiveSinglePrecisionFloat
+}
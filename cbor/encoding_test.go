@@ -0,0 +1,153 @@
+package cbor
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeShortestFloat(t *testing.T) {
+
+	testCases := []struct {
+		input  DataItem
+		expect []byte
+	}{
+		{input: NewPrimitiveDoublePrecisionFloat(0), expect: []byte{0xf9, 0x00, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(math.Copysign(0, -1)), expect: []byte{0xf9, 0x80, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(1.5), expect: []byte{0xf9, 0x3e, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(65504), expect: []byte{0xf9, 0x7b, 0xff}},
+		{input: NewPrimitiveDoublePrecisionFloat(100000), expect: []byte{0xfa, 0x47, 0xc3, 0x50, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(1.1), expect: []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{input: NewPrimitiveDoublePrecisionFloat(math.Inf(1)), expect: []byte{0xf9, 0x7c, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(math.Inf(-1)), expect: []byte{0xf9, 0xfc, 0x00}},
+		{input: NewPrimitiveDoublePrecisionFloat(math.NaN()), expect: []byte{0xf9, 0x7e, 0x00}},
+		{input: NewPrimitiveSinglePrecisionFloat(5.960464477539063e-8), expect: []byte{0xf9, 0x00, 0x01}},
+		{input: NewPrimitiveSinglePrecisionFloat(3.4028234663852886e+38), expect: []byte{0xfa, 0x7f, 0x7f, 0xff, 0xff}},
+	}
+
+	for _, testCase := range testCases {
+		for _, mode := range []EncodeMode{EncodeModeCoreDeterministic, EncodeModeLengthFirstCanonical} {
+			actual, err := EncodeWithOptions(testCase.input, EncodeOptions{Mode: mode})
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.expect, actual, testCase.input.String())
+		}
+	}
+
+	// preserve mode keeps the width of the float
+	actual, err := EncodeWithOptions(NewPrimitiveDoublePrecisionFloat(0), EncodeOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xfb, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, actual)
+}
+
+func TestEncodeMapKeyOrder(t *testing.T) {
+
+	// keys of mixed major types: 10, 100, -1, "z", "aa", [100], false
+	m := NewMap()
+	m.Add(NewTextString("aa"), NewPositiveInteger8(0))
+	m.Add(NewArrayWithItems([]DataItem{NewPositiveInteger8(100)}), NewPositiveInteger8(0))
+	m.Add(NewPositiveInteger8(100), NewPositiveInteger8(0))
+	m.Add(NewPrimitiveFalse(), NewPositiveInteger8(0))
+	m.Add(NewTextString("z"), NewPositiveInteger8(0))
+	m.Add(NewNegativeInteger8(-1), NewPositiveInteger8(0))
+	m.Add(NewPositiveInteger8(10), NewPositiveInteger8(0))
+
+	deterministic, err := EncodeWithOptions(m, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xa7,
+		0x0a, 0x00, // 10
+		0x18, 0x64, 0x00, // 100
+		0x20, 0x00, // -1
+		0x61, 0x7a, 0x00, // "z"
+		0x62, 0x61, 0x61, 0x00, // "aa"
+		0x81, 0x18, 0x64, 0x00, // [100]
+		0xf4, 0x00, // false
+	}, deterministic)
+
+	// EncodeCBOR keeps the insertion order
+	assert.Equal(t, []byte{0xa7,
+		0x62, 0x61, 0x61, 0x00, // "aa"
+		0x81, 0x18, 0x64, 0x00, // [100]
+		0x18, 0x64, 0x00, // 100
+		0xf4, 0x00, // false
+		0x61, 0x7a, 0x00, // "z"
+		0x20, 0x00, // -1
+		0x0a, 0x00, // 10
+	}, m.EncodeCBOR())
+
+	canonical, err := EncodeWithOptions(m, EncodeOptions{Mode: EncodeModeLengthFirstCanonical})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xa7,
+		0x0a, 0x00, // 10
+		0x20, 0x00, // -1
+		0xf4, 0x00, // false
+		0x18, 0x64, 0x00, // 100
+		0x61, 0x7a, 0x00, // "z"
+		0x62, 0x61, 0x61, 0x00, // "aa"
+		0x81, 0x18, 0x64, 0x00, // [100]
+	}, canonical)
+}
+
+func TestEncodeNestedAndBignums(t *testing.T) {
+
+	inner := NewMap()
+	inner.Add(NewPositiveInteger8(2), NewPrimitiveDoublePrecisionFloat(1))
+	inner.Add(NewPositiveInteger8(1), NewPositiveBignumber(big.NewInt(1)))
+
+	outer := NewArrayWithItems([]DataItem{
+		inner,
+		NewNegativeBignumber(big.NewInt(-2)),
+		NewPositiveBignumber(new(big.Int).Lsh(big.NewInt(1), 64)),
+	})
+
+	actual, err := EncodeListWithOptions([]DataItem{outer}, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x83,
+		0xa2, 0x01, 0x01, 0x02, 0xf9, 0x3c, 0x00,
+		0x21,
+		0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, actual)
+}
+
+func TestEncodeDuplicateMapKeys(t *testing.T) {
+
+	// keys modified after they were added can end up equal
+	key := NewArray()
+	m := NewMap()
+	m.Add(NewArrayWithItems([]DataItem{NewPositiveInteger8(1)}), NewPositiveInteger8(1))
+	m.Add(key, NewPositiveInteger8(2))
+	key.Add(NewPositiveInteger8(1))
+
+	_, err := EncodeWithOptions(m, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborDuplicateMapKey, errorCode(err))
+	}
+}
+
+func TestEncodePreserve(t *testing.T) {
+
+	// [_ {"b": 1, "a": 2}, 24(non-minimal)]
+	data := []byte{0x9f, 0xa2, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02, 0x18, 0x18, 0xff}
+
+	items, err := Decode(data)
+	assert.Nil(t, err)
+
+	actual, err := EncodeWithOptions(items[0], EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, data, actual)
+
+	// a modified array keeps its indefinite length and the original bytes of
+	// the items it contains
+	array := items[0].(*Array)
+	array.Add(NewPrimitiveNull())
+	actual, err = EncodeWithOptions(array, EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x9f, 0xa2, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02, 0x18, 0x18, 0xf6, 0xff}, actual)
+
+	// the deterministic modes ignore the original bytes
+	actual, err = EncodeWithOptions(array, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x83, 0xa2, 0x61, 0x61, 0x02, 0x61, 0x62, 0x01, 0x18, 0x18, 0xf6}, actual)
+}
@@ -0,0 +1,146 @@
+package cbor
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// Struct fields are mapped using the "cbor" struct tag:
+//
+//   Field int `cbor:"name"`             // map entry with text key "name"
+//   Field int `cbor:"1,keyasint"`       // map entry with integer key 1
+//   Field int `cbor:"name,omitempty"`   // skipped when the field is empty
+//   Field int `cbor:"-"`                // never encoded or decoded
+//   _ struct{} `cbor:",toarray"`        // encode the struct as an array in field order
+//
+// Fields without a tag use the Go field name as a text key.
+
+const (
+	structTagName     = "cbor"
+	structTagToArray  = "toarray"
+	structTagKeyAsInt = "keyasint"
+	structTagOmit     = "omitempty"
+	structTagSkip     = "-"
+)
+
+// structField describes how a single struct field is encoded
+type structField struct {
+	index     int
+	name      string
+	key       DataItem
+	omitEmpty bool
+}
+
+// structInfo describes how a struct type is encoded
+type structInfo struct {
+	toArray bool
+	fields  []structField
+
+	// keyIndex maps the encoded key of a field to its position in fields
+	keyIndex map[string]int
+}
+
+var structInfoCache sync.Map
+
+// getStructInfo returns the (cached) encoding information for the struct type.
+// A keyasint field whose name is not an integer, or two fields with the same
+// key, are an error.
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+
+	if cached, ok := structInfoCache.Load(t); ok {
+		return cached.(*structInfo), nil
+	}
+
+	info := &structInfo{
+		keyIndex: map[string]int{},
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		tag := f.Tag.Get(structTagName)
+		name, options := parseStructTag(tag)
+
+		if f.Name == "_" {
+			if options[structTagToArray] {
+				info.toArray = true
+			}
+			continue
+		}
+
+		if f.PkgPath != "" || name == structTagSkip {
+			// unexported or explicitly skipped
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		field := structField{
+			index:     i,
+			name:      name,
+			omitEmpty: options[structTagOmit],
+		}
+
+		if options[structTagKeyAsInt] {
+			n, err := strconv.ParseInt(name, 10, 64)
+			if err != nil {
+				return nil, errors.NewMessageErrorf(errors.ErrCborInvalidStructTag,
+					"Field [%s] of type [%s] has keyasint with the non integer name [%s]", f.Name, t, name)
+			}
+			if n >= 0 {
+				field.key = NewPositiveInteger(uint64(n))
+			} else {
+				field.key = NewNegativeInteger(n)
+			}
+		} else {
+			field.key = NewTextString(name)
+		}
+
+		key := string(field.key.EncodeCBOR())
+		if other, found := info.keyIndex[key]; found {
+			return nil, errors.NewMessageErrorf(errors.ErrCborInvalidStructTag,
+				"Fields [%s] and [%s] of type [%s] have the same key [%s]",
+				t.Field(info.fields[other].index).Name, f.Name, t, field.key.String())
+		}
+		info.keyIndex[key] = len(info.fields)
+		info.fields = append(info.fields, field)
+	}
+
+	cached, _ := structInfoCache.LoadOrStore(t, info)
+	return cached.(*structInfo), nil
+}
+
+// parseStructTag returns the name and the set of options of a cbor struct tag
+func parseStructTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	options := map[string]bool{}
+	for _, option := range parts[1:] {
+		options[strings.TrimSpace(option)] = true
+	}
+	return strings.TrimSpace(parts[0]), options
+}
+
+// isEmptyValue returns true if the value is considered empty for omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package cbor
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxFractionExponent bounds the exponents converted to an exact big.Rat, as
+// the size of the result grows with the exponent
+const maxFractionExponent = 1 << 16
+
+// DecimalFraction wraps a decimal fraction, Mantissa * 10^Exponent - section
+// 3.4.4 of RFC8949 (semantic additional type value: 4)
+type DecimalFraction struct {
+	baseSemantic
+	Exponent int64
+	Mantissa *big.Int
+}
+
+// BigFloat wraps a binary floating point number, Mantissa * 2^Exponent -
+// section 3.4.4 of RFC8949 (semantic additional type value: 5)
+type BigFloat struct {
+	baseSemantic
+	Exponent int64
+	Mantissa *big.Int
+}
+
+// Rational wraps a fraction, Numerator / Denominator, which is not reduced so
+// that it is encoded as received (semantic additional type value: 30)
+type Rational struct {
+	baseSemantic
+	Numerator   *big.Int
+	Denominator *big.Int
+}
+
+func init() {
+	RegisterTag(semanticDecimalFraction, decodeExponentMantissa(semanticDecimalFraction), encodeExponentMantissa)
+	RegisterTag(semanticBigFloat, decodeExponentMantissa(semanticBigFloat), encodeExponentMantissa)
+	RegisterTag(semanticRational, decodeRational, encodeRational)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewDecimalFraction returns a new decimal fraction (additional type: 4)
+func NewDecimalFraction(mantissa *big.Int, exponent int64) *DecimalFraction {
+	return &DecimalFraction{
+		baseSemantic: newBaseSemantic(semanticDecimalFraction),
+		Exponent:     exponent,
+		Mantissa:     mantissa,
+	}
+}
+
+// Rat returns the exact value of the decimal fraction
+func (d *DecimalFraction) Rat() (*big.Rat, error) {
+	return ratFromExponent(d.Mantissa, big.NewInt(10), d.Exponent)
+}
+
+// Float returns the value of the decimal fraction, rounded as by
+// big.Float.SetRat.  It is exact when the exponent is not negative.
+func (d *DecimalFraction) Float() (*big.Float, error) {
+	r, err := d.Rat()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(r), nil
+}
+
+// Value returns the exact value as *big.Rat, or nil if the exponent is too large
+func (d *DecimalFraction) Value() interface{} {
+	r, _ := d.Rat()
+	return r
+}
+
+// EncodeCBOR returns CBOR representation for this item
+func (d *DecimalFraction) EncodeCBOR() []byte {
+	content := exponentMantissaContent(d.Exponent, d.Mantissa)
+	return append(dataItemPrefix(MajorTypeSemantic, semanticDecimalFraction), content.EncodeCBOR()...)
+}
+
+// String returns description of this item
+func (d *DecimalFraction) String() string {
+	return fmt.Sprintf("DecimalFraction - Mantissa: [%s]; Exponent: [%d]", d.Mantissa.String(), d.Exponent)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewBigFloat returns a new bigfloat (additional type: 5)
+func NewBigFloat(mantissa *big.Int, exponent int64) *BigFloat {
+	return &BigFloat{
+		baseSemantic: newBaseSemantic(semanticBigFloat),
+		Exponent:     exponent,
+		Mantissa:     mantissa,
+	}
+}
+
+// Rat returns the exact value of the bigfloat
+func (b *BigFloat) Rat() (*big.Rat, error) {
+	return ratFromExponent(b.Mantissa, big.NewInt(2), b.Exponent)
+}
+
+// Float returns the exact value of the bigfloat, with the precision of the mantissa
+func (b *BigFloat) Float() (*big.Float, error) {
+
+	prec := uint(b.Mantissa.BitLen())
+	if prec == 0 {
+		prec = 1
+	}
+	mantissa := new(big.Float).SetPrec(prec).SetInt(b.Mantissa)
+	if b.Mantissa.Sign() == 0 {
+		return mantissa, nil
+	}
+
+	if b.Exponent > big.MaxExp || b.Exponent < big.MinExp {
+		return nil, exponentOverflow(b.Exponent)
+	}
+	f := new(big.Float).SetMantExp(mantissa, int(b.Exponent))
+	if f.IsInf() || f.Sign() == 0 {
+		return nil, exponentOverflow(b.Exponent)
+	}
+	return f, nil
+}
+
+// Value returns the exact value as *big.Float, or nil if the exponent is too large
+func (b *BigFloat) Value() interface{} {
+	f, _ := b.Float()
+	return f
+}
+
+// EncodeCBOR returns CBOR representation for this item
+func (b *BigFloat) EncodeCBOR() []byte {
+	content := exponentMantissaContent(b.Exponent, b.Mantissa)
+	return append(dataItemPrefix(MajorTypeSemantic, semanticBigFloat), content.EncodeCBOR()...)
+}
+
+// String returns description of this item
+func (b *BigFloat) String() string {
+	return fmt.Sprintf("BigFloat - Mantissa: [%s]; Exponent: [%d]", b.Mantissa.String(), b.Exponent)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewRational returns a new rational number (additional type: 30)
+func NewRational(numerator, denominator *big.Int) *Rational {
+	if denominator.Sign() <= 0 {
+		log.Error("Rational denominator should be positive number")
+		return nil
+	}
+	return &Rational{
+		baseSemantic: newBaseSemantic(semanticRational),
+		Numerator:    numerator,
+		Denominator:  denominator,
+	}
+}
+
+// content returns the content of tag 30
+func (r *Rational) content() *Array {
+	return NewArrayWithItems([]DataItem{newIntegerFromBig(r.Numerator), newIntegerFromBig(r.Denominator)})
+}
+
+// Rat returns the value of the rational number
+func (r *Rational) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(r.Numerator, r.Denominator)
+}
+
+// Value returns the value as *big.Rat
+func (r *Rational) Value() interface{} {
+	return r.Rat()
+}
+
+// EncodeCBOR returns CBOR representation for this item
+func (r *Rational) EncodeCBOR() []byte {
+	return append(dataItemPrefix(MajorTypeSemantic, semanticRational), r.content().EncodeCBOR()...)
+}
+
+// String returns description of this item
+func (r *Rational) String() string {
+	return fmt.Sprintf("Rational - Value: [%s/%s]", r.Numerator.String(), r.Denominator.String())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// newBaseSemantic returns the base of a semantic data item for the tag
+func newBaseSemantic(tag uint64) baseSemantic {
+	return baseSemantic{
+		baseDataItem: baseDataItem{
+			majorType: MajorTypeSemantic,
+		},
+		additionalTypeValue: tag,
+	}
+}
+
+// ratFromExponent returns mantissa * base^exponent
+func ratFromExponent(mantissa, base *big.Int, exponent int64) (*big.Rat, error) {
+
+	if exponent > maxFractionExponent || exponent < -maxFractionExponent {
+		return nil, exponentOverflow(exponent)
+	}
+
+	abs := exponent
+	if abs < 0 {
+		abs = -abs
+	}
+	power := new(big.Int).Exp(base, big.NewInt(abs), nil)
+
+	if exponent < 0 {
+		return new(big.Rat).SetFrac(mantissa, power), nil
+	}
+	return new(big.Rat).SetInt(power.Mul(power, mantissa)), nil
+}
+
+// exponentOverflow returns the error for an exponent too large to convert
+func exponentOverflow(exponent int64) error {
+	return errors.NewMessageErrorf(errors.ErrCborIntegerOverflow,
+		"Exponent [%d] is out of the supported range", exponent)
+}
+
+// exponentMantissaContent returns the content of tag 4 or 5
+func exponentMantissaContent(exponent int64, mantissa *big.Int) *Array {
+	return NewArrayWithItems([]DataItem{newInteger(exponent), newIntegerFromBig(mantissa)})
+}
+
+// decodeExponentMantissa returns the decoder for the content of tag 4 or 5
+func decodeExponentMantissa(tag uint64) TagDecoder {
+	return func(content DataItem) (DataItem, error) {
+
+		array, ok := content.(*Array)
+		if !ok || array.Length() != 2 {
+			return nil, tagContentMismatch(tag, content)
+		}
+
+		// the exponent must be a plain integer, the mantissa may be a bignum
+		switch array.Get(0).MajorType() {
+		case MajorTypePositiveInt, MajorTypeNegativeInt:
+		default:
+			return nil, tagContentMismatch(tag, array.Get(0))
+		}
+		exponent, err := AsInt64(array.Get(0))
+		if err != nil {
+			return nil, err
+		}
+		mantissa, err := AsBigInt(array.Get(1))
+		if err != nil {
+			return nil, tagContentMismatch(tag, array.Get(1))
+		}
+
+		if tag == semanticDecimalFraction {
+			return NewDecimalFraction(mantissa, exponent), nil
+		}
+		return NewBigFloat(mantissa, exponent), nil
+	}
+}
+
+// encodeExponentMantissa returns the content of tag 4 or 5
+func encodeExponentMantissa(item DataItem) (DataItem, error) {
+	switch v := item.(type) {
+	case *DecimalFraction:
+		return exponentMantissaContent(v.Exponent, v.Mantissa), nil
+	case *BigFloat:
+		return exponentMantissaContent(v.Exponent, v.Mantissa), nil
+	}
+	return nil, tagContentMismatch(item.AdditionalTypeValue(), item)
+}
+
+// decodeRational decodes the content of tag 30
+func decodeRational(content DataItem) (DataItem, error) {
+
+	array, ok := content.(*Array)
+	if !ok || array.Length() != 2 {
+		return nil, tagContentMismatch(semanticRational, content)
+	}
+
+	numerator, err := AsBigInt(array.Get(0))
+	if err != nil {
+		return nil, tagContentMismatch(semanticRational, array.Get(0))
+	}
+	denominator, err := AsBigInt(array.Get(1))
+	if err != nil || denominator.Sign() <= 0 {
+		return nil, errors.NewMessageErrorf(errors.ErrCborTypeMismatch,
+			"Rational denominator must be a positive integer, found [%s]", array.Get(1).String())
+	}
+
+	return NewRational(numerator, denominator), nil
+}
+
+// encodeRational returns the content of tag 30
+func encodeRational(item DataItem) (DataItem, error) {
+	r, ok := item.(*Rational)
+	if !ok {
+		return nil, tagContentMismatch(semanticRational, item)
+	}
+	return r.content(), nil
+}
@@ -0,0 +1,165 @@
+package cbor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeFractions(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic string
+		expectRat  string
+	}{
+		{diagnostic: "4([-2, 27315])", expectRat: "5463/20"},
+		{diagnostic: "4([3, -7])", expectRat: "-7000/1"},
+		{diagnostic: "4([0, 2(h'010000000000000000')])", expectRat: "18446744073709551616/1"},
+		{diagnostic: "5([-1, 3])", expectRat: "3/2"},
+		{diagnostic: "5([4_1, 3(h'00')])", expectRat: "-16/1"},
+		{diagnostic: "30([1, 3])", expectRat: "1/3"},
+		{diagnostic: "30([-2, 4])", expectRat: "-1/2"},
+	}
+
+	for _, testCase := range testCases {
+		parsed, err := ParseDiagnostic(testCase.diagnostic)
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+		items, err := Decode(parsed.Raw())
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+		item := items[0]
+
+		var r *big.Rat
+		switch v := item.(type) {
+		case *DecimalFraction:
+			r, err = v.Rat()
+		case *BigFloat:
+			r, err = v.Rat()
+		case *Rational:
+			r = v.Rat()
+		default:
+			t.Errorf("Unexpected %T for %s", item, testCase.diagnostic)
+			continue
+		}
+		assert.Nil(t, err, testCase.diagnostic)
+		assert.Equal(t, testCase.expectRat, r.String(), testCase.diagnostic)
+		if f, ok := item.Value().(*big.Float); ok {
+			value, accuracy := f.Rat(nil)
+			assert.Equal(t, big.Exact, accuracy, testCase.diagnostic)
+			assert.Equal(t, 0, r.Cmp(value), testCase.diagnostic)
+		} else {
+			assert.Equal(t, r, item.Value(), testCase.diagnostic)
+		}
+
+		// the data items encode as they were received
+		assert.Equal(t, testCase.diagnostic, Diagnostic(item), testCase.diagnostic)
+		if testCase.diagnostic != "5([4_1, 3(h'00')])" {
+			assert.Equal(t, parsed.Raw(), item.EncodeCBOR(), testCase.diagnostic)
+		}
+	}
+}
+
+func TestFractionFloat(t *testing.T) {
+
+	f, err := NewBigFloat(big.NewInt(3), -1).Float()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.5", f.Text('g', -1))
+
+	f, err = NewBigFloat(big.NewInt(-5), 100).Float()
+	assert.Nil(t, err)
+	expected := new(big.Int).Lsh(big.NewInt(-5), 100)
+	actual, accuracy := f.Int(nil)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, big.Exact, accuracy)
+
+	f, err = NewBigFloat(big.NewInt(0), 1<<40).Float()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, f.Sign())
+
+	f, err = NewDecimalFraction(big.NewInt(27315), -2).Float()
+	assert.Nil(t, err)
+	value, _ := f.Float64()
+	assert.Equal(t, 273.15, value)
+
+	f, err = NewDecimalFraction(big.NewInt(12), 30).Float()
+	assert.Nil(t, err)
+	assert.Equal(t, "1.2e+31", f.Text('g', -1))
+
+	// exponents that cannot be converted
+	_, err = NewBigFloat(big.NewInt(1), 1<<40).Float()
+	assert.Equal(t, errors.ErrCborIntegerOverflow, errorCode(err))
+	_, err = NewBigFloat(big.NewInt(1), 1<<20).Rat()
+	assert.Equal(t, errors.ErrCborIntegerOverflow, errorCode(err))
+	_, err = NewDecimalFraction(big.NewInt(1), -1<<20).Rat()
+	assert.Equal(t, errors.ErrCborIntegerOverflow, errorCode(err))
+	assert.Nil(t, NewDecimalFraction(big.NewInt(1), -1<<20).Value())
+}
+
+func TestEncodeFractions(t *testing.T) {
+
+	testCases := []struct {
+		item   DataItem
+		expect string
+	}{
+		{item: NewDecimalFraction(big.NewInt(-5), -1), expect: "4([-1, -5])"},
+		{item: NewDecimalFraction(new(big.Int).Lsh(big.NewInt(1), 64), 2), expect: "4([2, 2(h'010000000000000000')])"},
+		{item: NewBigFloat(big.NewInt(3), -1), expect: "5([-1, 3])"},
+		{item: NewRational(big.NewInt(2), big.NewInt(4)), expect: "30([2, 4])"},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expect, Diagnostic(testCase.item))
+
+		items, err := Decode(testCase.item.EncodeCBOR())
+		if assert.Nil(t, err, testCase.expect) {
+			assert.True(t, Equal(testCase.item, items[0]), testCase.expect)
+		}
+
+		encoded, err := EncodeWithOptions(testCase.item, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+		assert.Nil(t, err, testCase.expect)
+		assert.Equal(t, testCase.item.EncodeCBOR(), encoded, testCase.expect)
+	}
+
+	assert.Nil(t, NewRational(big.NewInt(1), big.NewInt(0)))
+}
+
+func TestDecodeInvalidFractions(t *testing.T) {
+
+	for _, diagnostic := range []string{
+		"4(1)",
+		"4([1])",
+		"4([1, 2, 3])",
+		"4([1.5, 1])",
+		"4([2(h'01'), 1])",
+		"4([18446744073709551615, 1])",
+		`5(["a", 1])`,
+		"5([1, 1.5])",
+		"30([1, 0])",
+		"30([1, -1])",
+		"30([h'01', 1])",
+	} {
+		// parsing decodes the encoding of the notation
+		_, err := ParseDiagnostic(diagnostic)
+		if assert.NotNil(t, err, diagnostic) {
+			code := errorCode(err)
+			assert.True(t, code == errors.ErrCborTypeMismatch || code == errors.ErrCborIntegerOverflow, "%s: %v", diagnostic, err)
+		}
+	}
+}
+
+func TestBitstreamDecodeFractions(t *testing.T) {
+
+	// the reference decoder does not handle decimal fractions and bigfloats
+	for _, data := range [][]byte{
+		{0xc4, 0x82, 0x21, 0x19, 0x6a, 0xb3},
+		{0xc5, 0x82, 0x20, 0x03},
+	} {
+		_, err := decodeBitstream(data)
+		assert.Equal(t, errors.ErrCborAdditionalTypeUnhandled, errorCode(err), "%x", data)
+	}
+}
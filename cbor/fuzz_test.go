@@ -0,0 +1,81 @@
+//go:build go1.18
+// +build go1.18
+
+package cbor
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzDecode(f *testing.F) {
+
+	for _, seed := range []string{
+		"[0, -1, 24, 1000_1, 18446744073709551615, -18446744073709551616]",
+		`{"a": h'abcd', 1: [_ 1.5, 1.5_3, NaN], h'': {_ }}`,
+		`(_ "strea", "ming")`,
+		"(_ h'0102', h'030405')",
+		"[true, false, null, undefined, simple(255)]",
+		`0("2013-03-21T20:04:00Z")`,
+		"2(h'010000000000000000')",
+		"24(h'820102')",
+		"258([1, 2])",
+	} {
+		item, err := ParseDiagnostic(seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(item.Raw())
+	}
+	f.Add(benchmarkPayload())
+
+	options := DecodeOptions{
+		MaxNestingDepth:     16,
+		MaxArrayElements:    1024,
+		MaxMapPairs:         1024,
+		MaxByteStringLength: 1 << 16,
+		MaxTotalBytes:       1 << 20,
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		items, err := DecodeWithOptions(data, options)
+		if err != nil {
+			if _, ok := err.(*DecodeError); !ok || errorCode(err) == 0 {
+				t.Fatalf("Unexpected error type %T: %v", err, err)
+			}
+			return
+		}
+
+		// decoded data items reproduce their input
+		encoded, err := EncodeListWithOptions(items, EncodeOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, encoded) {
+			t.Fatalf("Encoded %x, decoded from %x", encoded, data)
+		}
+
+		// the scanner finds the same data item boundaries
+		rest := data
+		for i := range items {
+			if _, ok := items[i].(*PrimitiveBreakStopCode); ok {
+				// stray top level breaks are tolerated by the decoder only
+				rest = rest[1:]
+				continue
+			}
+			var item DataItem
+			item, rest, err = DecodeFirst(rest)
+			if err != nil {
+				t.Fatalf("DecodeFirst failed on data item [%d]: %v", i, err)
+			}
+			if !bytes.Equal(item.Raw(), items[i].Raw()) {
+				t.Fatalf("DecodeFirst returned %x instead of %x", item.Raw(), items[i].Raw())
+			}
+		}
+
+		for _, item := range items {
+			Diagnostic(item)
+		}
+	})
+}
@@ -0,0 +1,515 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// JSONSchema selects the JSON convention used by ToJSON and FromJSON
+type JSONSchema uint8
+
+const (
+	// JSONSchemaPlain maps data items to the natural JSON values.  Byte strings
+	// are written as hex strings, bignums as decimal strings, non finite floats
+	// as "NaN", "Infinity" or "-Infinity", and map keys that are not text
+	// strings as their hex, decimal or diagnostic notation.  Semantic tags are
+	// replaced by their content.  Reading plain JSON back yields text strings
+	// for all JSON strings.
+	JSONSchemaPlain JSONSchema = iota
+
+	// JSONSchemaDetailed is the detailed schema of cardano-node for
+	// transaction metadata and Plutus data, such as {"int": 1}, {"bytes": "ab"},
+	// {"string": "a"}, {"list": [..]}, {"map": [{"k": .., "v": ..}]} and
+	// {"constructor": 0, "fields": [..]}.  It round-trips losslessly, but only
+	// holds integers, byte and text strings, arrays, maps and Plutus
+	// constructors.  Maps with duplicate keys are rejected both ways, as the
+	// map of a data item holds one value per key.
+	JSONSchemaDetailed
+)
+
+// JSONOptions configures the conversion between data items and JSON
+type JSONOptions struct {
+	Schema JSONSchema
+}
+
+// Plutus data constructors are tagged with 121 to 127 for the first seven
+// alternatives, 1280 to 1400 for the next 121, and 102 with the alternative as
+// the first array element beyond that
+const (
+	plutusConstructorTag        uint64 = 121
+	plutusConstructorTagExtra   uint64 = 1280
+	plutusConstructorTagGeneral uint64 = 102
+	plutusConstructorCompact    uint64 = 7
+	plutusConstructorExtra      uint64 = 128
+)
+
+// ToJSON returns the JSON representation of the data item in the given schema
+func ToJSON(item DataItem, options JSONOptions) ([]byte, error) {
+	if options.Schema == JSONSchemaDetailed {
+		return appendDetailedJSON(nil, item)
+	}
+	return appendPlainJSON(nil, item)
+}
+
+// FromJSON returns the data item for the JSON value in the given schema.
+// Object members keep their order as map entries.
+func FromJSON(data []byte, options JSONOptions) (DataItem, error) {
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	item, err := readJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.NewMessageErrorf(errors.ErrCborInvalidJSON,
+			"Unexpected data after the JSON value at offset [%d]", decoder.InputOffset())
+	}
+
+	if options.Schema == JSONSchemaDetailed {
+		return fromDetailedJSON(item)
+	}
+	return item, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// appendPlainJSON appends the plain JSON representation of the data item
+func appendPlainJSON(buf []byte, item DataItem) ([]byte, error) {
+
+	switch v := item.(type) {
+	case *Array:
+		buf = append(buf, '[')
+		for i, element := range v.V {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = appendPlainJSON(buf, element); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+
+	case *Map:
+		buf = append(buf, '{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, plainJSONKey(key))
+			buf = append(buf, ':')
+			var err error
+			if buf, err = appendPlainJSON(buf, v.values[i]); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	case *ByteString:
+		return appendJSONString(buf, hex.EncodeToString(v.ValueAsBytes())), nil
+
+	case *TextString:
+		return appendJSONString(buf, v.ValueAsString()), nil
+
+	case *PositiveBignum:
+		return appendJSONString(buf, v.V.String()), nil
+
+	case *NegativeBignum:
+		return appendJSONString(buf, v.V.String()), nil
+
+	case *EncodedCBOR:
+		return appendJSONString(buf, hex.EncodeToString(v.V)), nil
+
+	case *PrimitiveTrue:
+		return append(buf, "true"...), nil
+
+	case *PrimitiveFalse:
+		return append(buf, "false"...), nil
+
+	case *PrimitiveNull, *PrimitiveUndefined:
+		return append(buf, "null"...), nil
+
+	case *PrimitiveSimpleValue:
+		return strconv.AppendUint(buf, v.AdditionalTypeValue(), 10), nil
+
+	case *PrimitiveHalfPrecisionFloat, *PrimitiveSinglePrecisionFloat, *PrimitiveDoublePrecisionFloat:
+		f, _ := floatFromDataItem(item)
+		return appendJSONFloat(buf, f), nil
+	}
+
+	if n, ok := bigIntFromDataItem(item); ok {
+		return append(buf, n.String()...), nil
+	}
+
+	if item.MajorType() == MajorTypeSemantic {
+		content, ok, err := tagContent(item)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return appendPlainJSON(buf, content)
+		}
+	}
+
+	return nil, errors.NewMessageErrorf(errors.ErrCborUnsupportedType,
+		"Data item [%s] has no JSON representation", item.String())
+}
+
+// plainJSONKey returns the JSON object member name for a map key
+func plainJSONKey(key DataItem) string {
+	switch v := key.(type) {
+	case *TextString:
+		return v.ValueAsString()
+	case *ByteString:
+		return hex.EncodeToString(v.ValueAsBytes())
+	}
+	if n, ok := bigIntFromDataItem(key); ok {
+		return n.String()
+	}
+	return Diagnostic(key)
+}
+
+// appendJSONFloat appends a float as a JSON number, or as a string if it is not finite
+func appendJSONFloat(buf []byte, f float64) []byte {
+	switch {
+	case math.IsNaN(f):
+		return appendJSONString(buf, "NaN")
+	case math.IsInf(f, 1):
+		return appendJSONString(buf, "Infinity")
+	case math.IsInf(f, -1):
+		return appendJSONString(buf, "-Infinity")
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, 64)
+}
+
+// appendJSONString appends the string as a JSON string, without escaping HTML characters
+func appendJSONString(buf []byte, s string) []byte {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return append(buf, bytes.TrimSuffix(b.Bytes(), []byte{'\n'})...)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// appendDetailedJSON appends the detailed schema representation of the data item
+func appendDetailedJSON(buf []byte, item DataItem) ([]byte, error) {
+
+	switch v := item.(type) {
+	case *Array:
+		buf = append(buf, `{"list":`...)
+		var err error
+		if buf, err = appendDetailedJSONList(buf, v.V); err != nil {
+			return nil, err
+		}
+		return append(buf, '}'), nil
+
+	case *Map:
+		if duplicates := v.DuplicateKeys(); len(duplicates) > 0 {
+			return nil, errors.NewMessageErrorf(errors.ErrCborDuplicateMapKey,
+				"Duplicate map key [%s] has no detailed schema representation", Diagnostic(duplicates[0]))
+		}
+		buf = append(buf, `{"map":[`...)
+		for i, key := range v.keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, `{"k":`...)
+			var err error
+			if buf, err = appendDetailedJSON(buf, key); err != nil {
+				return nil, err
+			}
+			buf = append(buf, `,"v":`...)
+			if buf, err = appendDetailedJSON(buf, v.values[i]); err != nil {
+				return nil, err
+			}
+			buf = append(buf, '}')
+		}
+		return append(buf, "]}"...), nil
+
+	case *ByteString:
+		buf = append(buf, `{"bytes":`...)
+		buf = appendJSONString(buf, hex.EncodeToString(v.ValueAsBytes()))
+		return append(buf, '}'), nil
+
+	case *TextString:
+		buf = append(buf, `{"string":`...)
+		buf = appendJSONString(buf, v.ValueAsString())
+		return append(buf, '}'), nil
+
+	case *Tag:
+		if constructor, fields, ok := plutusConstructor(v); ok {
+			buf = append(buf, `{"constructor":`...)
+			buf = strconv.AppendUint(buf, constructor, 10)
+			buf = append(buf, `,"fields":`...)
+			var err error
+			if buf, err = appendDetailedJSONList(buf, fields.V); err != nil {
+				return nil, err
+			}
+			return append(buf, '}'), nil
+		}
+	}
+
+	if n, ok := bigIntFromDataItem(item); ok {
+		buf = append(buf, `{"int":`...)
+		buf = append(buf, n.String()...)
+		return append(buf, '}'), nil
+	}
+
+	return nil, errors.NewMessageErrorf(errors.ErrCborUnsupportedType,
+		"Data item [%s] has no detailed schema JSON representation", item.String())
+}
+
+// appendDetailedJSONList appends the detailed schema representation of each item as a JSON array
+func appendDetailedJSONList(buf []byte, items []DataItem) ([]byte, error) {
+	buf = append(buf, '[')
+	for i, item := range items {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		var err error
+		if buf, err = appendDetailedJSON(buf, item); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, ']'), nil
+}
+
+// plutusConstructor returns the alternative and fields of a Plutus data constructor tag
+func plutusConstructor(tag *Tag) (uint64, *Array, bool) {
+
+	fields, ok := tag.Content.(*Array)
+	if !ok {
+		return 0, nil, false
+	}
+
+	switch {
+	case tag.Number >= plutusConstructorTag && tag.Number < plutusConstructorTag+plutusConstructorCompact:
+		return tag.Number - plutusConstructorTag, fields, true
+	case tag.Number >= plutusConstructorTagExtra &&
+		tag.Number < plutusConstructorTagExtra+plutusConstructorExtra-plutusConstructorCompact:
+		return tag.Number - plutusConstructorTagExtra + plutusConstructorCompact, fields, true
+	case tag.Number == plutusConstructorTagGeneral && fields.Length() == 2:
+		inner, isArray := fields.V[1].(*Array)
+		if isArray && fields.V[0].MajorType() == MajorTypePositiveInt {
+			return fields.V[0].AdditionalTypeValue(), inner, true
+		}
+	}
+
+	return 0, nil, false
+}
+
+// newPlutusConstructor returns the tag for a Plutus data constructor
+func newPlutusConstructor(constructor uint64, fields *Array) *Tag {
+	switch {
+	case constructor < plutusConstructorCompact:
+		return NewTag(plutusConstructorTag+constructor, fields)
+	case constructor < plutusConstructorExtra:
+		return NewTag(plutusConstructorTagExtra+constructor-plutusConstructorCompact, fields)
+	}
+	return NewTag(plutusConstructorTagGeneral,
+		NewArrayWithItems([]DataItem{NewPositiveInteger(constructor), fields}))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// readJSONValue reads the next JSON value as a plain data item
+func readJSONValue(decoder *json.Decoder) (DataItem, error) {
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, invalidJSON(decoder, err)
+	}
+
+	switch v := token.(type) {
+	case json.Delim:
+		switch v {
+		case '[':
+			array := NewArray()
+			for decoder.More() {
+				element, err := readJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array.Add(element)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, invalidJSON(decoder, err)
+			}
+			return array, nil
+
+		case '{':
+			m := NewMap()
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, invalidJSON(decoder, err)
+				}
+				value, err := readJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				m.Add(NewTextString(key.(string)), value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, invalidJSON(decoder, err)
+			}
+			return m, nil
+		}
+
+	case string:
+		return NewTextString(v), nil
+
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			f, err := v.Float64()
+			if err != nil {
+				return nil, invalidJSON(decoder, err)
+			}
+			return NewPrimitiveDoublePrecisionFloat(f), nil
+		}
+		n, ok := new(big.Int).SetString(v.String(), 10)
+		if !ok {
+			return nil, errors.NewMessageErrorf(errors.ErrCborInvalidJSON, "Invalid JSON number [%s]", v)
+		}
+		return newIntegerFromBig(n), nil
+
+	case bool:
+		if v {
+			return NewPrimitiveTrue(), nil
+		}
+		return NewPrimitiveFalse(), nil
+
+	case nil:
+		return NewPrimitiveNull(), nil
+	}
+
+	return nil, errors.NewMessageErrorf(errors.ErrCborInvalidJSON,
+		"Unexpected JSON token [%v] at offset [%d]", token, decoder.InputOffset())
+}
+
+// invalidJSON returns the error for JSON that cannot be read
+func invalidJSON(decoder *json.Decoder, err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return errors.NewMessageErrorf(errors.ErrCborInvalidJSON,
+		"Invalid JSON at offset [%d]: %s", decoder.InputOffset(), err)
+}
+
+// fromDetailedJSON returns the data item for a detailed schema value read as plain JSON
+func fromDetailedJSON(item DataItem) (DataItem, error) {
+
+	m, ok := item.(*Map)
+	if !ok {
+		return nil, detailedJSONMismatch(item)
+	}
+
+	if m.Length() == 1 {
+		if value, ok := m.GetText("int"); ok {
+			if _, isInt := bigIntFromDataItem(value); isInt {
+				return value, nil
+			}
+		}
+		if value, ok := m.GetText("bytes"); ok {
+			if text, isText := value.(*TextString); isText {
+				decoded, err := hex.DecodeString(text.ValueAsString())
+				if err != nil {
+					return nil, errors.NewMessageErrorf(errors.ErrCborInvalidJSON,
+						"Invalid hex bytes [%s]", text.ValueAsString())
+				}
+				return NewByteString(decoded), nil
+			}
+		}
+		if value, ok := m.GetText("string"); ok {
+			if text, isText := value.(*TextString); isText {
+				return text, nil
+			}
+		}
+		if value, ok := m.GetText("list"); ok {
+			if list, isArray := value.(*Array); isArray {
+				return fromDetailedJSONList(list)
+			}
+		}
+		if value, ok := m.GetText("map"); ok {
+			if entries, isArray := value.(*Array); isArray {
+				return fromDetailedJSONMap(entries)
+			}
+		}
+	}
+
+	if m.Length() == 2 {
+		constructor, hasConstructor := m.GetText("constructor")
+		fields, hasFields := m.GetText("fields")
+		if hasConstructor && hasFields && constructor.MajorType() == MajorTypePositiveInt {
+			if list, isArray := fields.(*Array); isArray {
+				array, err := fromDetailedJSONList(list)
+				if err != nil {
+					return nil, err
+				}
+				return newPlutusConstructor(constructor.AdditionalTypeValue(), array), nil
+			}
+		}
+	}
+
+	return nil, detailedJSONMismatch(item)
+}
+
+// fromDetailedJSONList returns the array for the elements of a detailed schema list
+func fromDetailedJSONList(list *Array) (*Array, error) {
+	array := NewArray()
+	for _, element := range list.V {
+		item, err := fromDetailedJSON(element)
+		if err != nil {
+			return nil, err
+		}
+		array.Add(item)
+	}
+	return array, nil
+}
+
+// fromDetailedJSONMap returns the map for the entries of a detailed schema map
+func fromDetailedJSONMap(entries *Array) (*Map, error) {
+	m := NewMap()
+	for _, entry := range entries.V {
+		pair, ok := entry.(*Map)
+		if !ok || pair.Length() != 2 {
+			return nil, detailedJSONMismatch(entry)
+		}
+		k, hasKey := pair.GetText("k")
+		v, hasValue := pair.GetText("v")
+		if !hasKey || !hasValue {
+			return nil, detailedJSONMismatch(entry)
+		}
+		key, err := fromDetailedJSON(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := fromDetailedJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		if !m.addEntry(key, value) {
+			return nil, errors.NewMessageErrorf(errors.ErrCborDuplicateMapKey,
+				"Duplicate map key [%s] in the detailed schema", Diagnostic(key))
+		}
+	}
+	return m, nil
+}
+
+// detailedJSONMismatch returns the error for JSON that does not follow the detailed schema
+func detailedJSONMismatch(item DataItem) error {
+	return errors.NewMessageErrorf(errors.ErrCborInvalidJSON,
+		"JSON value [%s] does not follow the detailed schema", Diagnostic(item))
+}
@@ -0,0 +1,155 @@
+package cbor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToJSONPlain(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic string
+		json       string
+	}{
+		{diagnostic: "[0, -1, 18446744073709551615, -18446744073709551616]", json: `[0,-1,18446744073709551615,"-18446744073709551616"]`},
+		{diagnostic: "2(h'010000000000000000')", json: `"18446744073709551616"`},
+		{diagnostic: `{"a": h'abcd', 1: "<b>", h'01': true, [1]: null}`, json: `{"a":"abcd","1":"<b>","01":true,"[1]":null}`},
+		{diagnostic: "[1.5, NaN, -Infinity, 1.0e+300]", json: `[1.5,"NaN","-Infinity",1e+300]`},
+		{diagnostic: "[false, undefined, simple(255)]", json: `[false,null,255]`},
+		{diagnostic: `[0("2013-03-21T20:04:00Z"), 1(1363896240), 24(h'01'), 258([1])]`, json: `["2013-03-21T20:04:00Z",1363896240,"01",[1]]`},
+		{diagnostic: `"\u0001\""`, json: `"\u0001\""`},
+	}
+
+	for _, testCase := range testCases {
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+		actual, err := ToJSON(item, JSONOptions{})
+		assert.Nil(t, err, testCase.diagnostic)
+		assert.Equal(t, testCase.json, string(actual), testCase.diagnostic)
+	}
+}
+
+func TestFromJSONPlain(t *testing.T) {
+
+	testCases := []struct {
+		json       string
+		diagnostic string
+	}{
+		{json: ` {"b": [1, -2, 2.5], "a": {"c": null}} `, diagnostic: `{"b": [1, -2, 2.5_3], "a": {"c": null}}`},
+		{json: `[true, false, "x", 1e2]`, diagnostic: `[true, false, "x", 100.0_3]`},
+		{json: `18446744073709551616`, diagnostic: "2(h'010000000000000000')"},
+		{json: `-9223372036854775809`, diagnostic: "3(h'8000000000000000')"},
+	}
+
+	for _, testCase := range testCases {
+		item, err := FromJSON([]byte(testCase.json), JSONOptions{})
+		if assert.Nil(t, err, testCase.json) {
+			assert.Equal(t, testCase.diagnostic, Diagnostic(item), testCase.json)
+		}
+	}
+}
+
+func TestJSONDetailedSchema(t *testing.T) {
+
+	testCases := []struct {
+		diagnostic string
+		json       string
+	}{
+		{diagnostic: "1", json: `{"int":1}`},
+		{diagnostic: "3(h'010000000000000000')", json: `{"int":-18446744073709551617}`},
+		{diagnostic: "h'abcd'", json: `{"bytes":"abcd"}`},
+		{diagnostic: `"a"`, json: `{"string":"a"}`},
+		{diagnostic: `[1, [h'']]`, json: `{"list":[{"int":1},{"list":[{"bytes":""}]}]}`},
+		{diagnostic: `{2: "b", 1: "a"}`, json: `{"map":[{"k":{"int":2},"v":{"string":"b"}},{"k":{"int":1},"v":{"string":"a"}}]}`},
+		{diagnostic: "121([])", json: `{"constructor":0,"fields":[]}`},
+		{diagnostic: "127([1])", json: `{"constructor":6,"fields":[{"int":1}]}`},
+		{diagnostic: "1280([])", json: `{"constructor":7,"fields":[]}`},
+		{diagnostic: "1400([])", json: `{"constructor":127,"fields":[]}`},
+		{diagnostic: "102([128, [h'01']])", json: `{"constructor":128,"fields":[{"bytes":"01"}]}`},
+	}
+
+	options := JSONOptions{Schema: JSONSchemaDetailed}
+	for _, testCase := range testCases {
+		item, err := ParseDiagnostic(testCase.diagnostic)
+		if !assert.Nil(t, err, testCase.diagnostic) {
+			continue
+		}
+
+		actual, err := ToJSON(item, options)
+		assert.Nil(t, err, testCase.diagnostic)
+		assert.Equal(t, testCase.json, string(actual), testCase.diagnostic)
+
+		item, err = FromJSON(actual, options)
+		if assert.Nil(t, err, testCase.json) {
+			assert.Equal(t, testCase.diagnostic, Diagnostic(item), testCase.json)
+		}
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+
+	// data items outside the detailed schema
+	for _, item := range []DataItem{NewPrimitiveTrue(), NewPrimitiveDoublePrecisionFloat(1), NewTag(258, NewArray())} {
+		_, err := ToJSON(item, JSONOptions{Schema: JSONSchemaDetailed})
+		if assert.NotNil(t, err, item.String()) {
+			assert.Equal(t, errors.ErrCborUnsupportedType, errorCode(err))
+		}
+	}
+
+	testCases := []struct {
+		json   string
+		schema JSONSchema
+	}{
+		{json: ``, schema: JSONSchemaPlain},
+		{json: `[1,`, schema: JSONSchemaPlain},
+		{json: `1 2`, schema: JSONSchemaPlain},
+		{json: `{"a" 1}`, schema: JSONSchemaPlain},
+		{json: `1`, schema: JSONSchemaDetailed},
+		{json: `{"int": "1"}`, schema: JSONSchemaDetailed},
+		{json: `{"int": 1.5}`, schema: JSONSchemaDetailed},
+		{json: `{"bytes": "0"}`, schema: JSONSchemaDetailed},
+		{json: `{"int": 1, "bytes": ""}`, schema: JSONSchemaDetailed},
+		{json: `{"map": [{"k": {"int": 1}}]}`, schema: JSONSchemaDetailed},
+		{json: `{"constructor": -1, "fields": []}`, schema: JSONSchemaDetailed},
+		{json: `{"list": [1]}`, schema: JSONSchemaDetailed},
+	}
+
+	for _, testCase := range testCases {
+		_, err := FromJSON([]byte(testCase.json), JSONOptions{Schema: testCase.schema})
+		if assert.NotNil(t, err, testCase.json) {
+			assert.Equal(t, errors.ErrCborInvalidJSON, errorCode(err), testCase.json)
+		}
+	}
+}
+
+func TestJSONBignumRoundTrip(t *testing.T) {
+
+	n, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	actual, err := ToJSON(NewNegativeBignumber(n), JSONOptions{Schema: JSONSchemaDetailed})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"int":-123456789012345678901234567890}`, string(actual))
+
+	item, err := FromJSON(actual, JSONOptions{Schema: JSONSchemaDetailed})
+	assert.Nil(t, err)
+	assert.Equal(t, n, item.(*NegativeBignum).V)
+}
+
+func TestJSONDetailedDuplicateMapKey(t *testing.T) {
+
+	options := JSONOptions{Schema: JSONSchemaDetailed}
+
+	// a decoded map with a duplicate key has no lossless representation
+	item, err := Decode([]byte{0xa2, 0x01, 0x02, 0x01, 0x03})
+	if assert.Nil(t, err) {
+		_, err = ToJSON(item[0], options)
+		assert.Equal(t, errors.ErrCborDuplicateMapKey, errorCode(err))
+	}
+
+	_, err = FromJSON([]byte(`{"map": [{"k": {"int": 1}, "v": {"int": 2}}, {"k": {"int": 1}, "v": {"int": 3}}]}`), options)
+	assert.Equal(t, errors.ErrCborDuplicateMapKey, errorCode(err))
+}
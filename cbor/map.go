@@ -1,15 +1,17 @@
 package cbor
 
-import (
-	"fmt"
-	"math"
-	"sort"
-)
+import "fmt"
 
-// Map wraps a CBOR map
+// Map wraps a CBOR map.  Entries are kept in insertion order, and keys are
+// compared by value: two keys are the same if their core deterministic
+// encodings are equal, regardless of the integer width or data item instance.
 type Map struct {
 	baseDataItem
-	m map[DataItem]DataItem
+	keys          []DataItem
+	values        []DataItem
+	index         map[string]int
+	duplicateKeys []DataItem
+	indefinite    bool
 }
 
 // NewMap returns a new map instance
@@ -18,24 +20,88 @@ func NewMap() *Map {
 		baseDataItem: baseDataItem{
 			majorType: MajorTypeMap,
 		},
-		m: map[DataItem]DataItem{},
+		keys:   []DataItem{},
+		values: []DataItem{},
+		index:  map[string]int{},
 	}
 }
 
+// NewIndefiniteMap returns an empty map that is encoded with an indefinite length
+func NewIndefiniteMap() *Map {
+	m := NewMap()
+	m.indefinite = true
+	return m
+}
+
 // AdditionalTypeValue returns the length of the byte string
 func (m *Map) AdditionalTypeValue() uint64 {
-	return uint64(len(m.m))
+	return uint64(len(m.keys))
 }
 
-// Add a key/vaklue pair to the map
+// Add a key/value pair to the map.  If the map already has an equal key, its
+// value is replaced and the entry keeps its position.  The map holds the key
+// as given, indexed by its value at the time, so the key must not be modified
+// after it is added.
 func (m *Map) Add(key, value DataItem) {
-	m.m[key] = value
+	m.addEntry(key, value)
+	m.raw = nil
+}
+
+// addEntry adds a key/value pair and returns false if the key already existed
+func (m *Map) addEntry(key, value DataItem) bool {
+	k := mapKey(key)
+	if i, found := m.index[k]; found {
+		m.values[i] = value
+		return false
+	}
+	m.index[k] = len(m.keys)
+	m.keys = append(m.keys, key)
+	m.values = append(m.values, value)
+	return true
 }
 
-// Get returns the value for the given key
+// Get returns the value for the key equal to the given key
 func (m *Map) Get(key DataItem) (DataItem, bool) {
-	value, ok := m.m[key]
-	return value, ok
+	i, found := m.index[mapKey(key)]
+	if !found {
+		return nil, false
+	}
+	return m.values[i], true
+}
+
+// GetUint returns the value for the unsigned integer key
+func (m *Map) GetUint(key uint64) (DataItem, bool) {
+	return m.Get(NewPositiveInteger(key))
+}
+
+// GetInt returns the value for the integer key
+func (m *Map) GetInt(key int64) (DataItem, bool) {
+	if key >= 0 {
+		return m.GetUint(uint64(key))
+	}
+	return m.Get(NewNegativeInteger(key))
+}
+
+// GetText returns the value for the text string key
+func (m *Map) GetText(key string) (DataItem, bool) {
+	return m.Get(NewTextString(key))
+}
+
+// Keys returns the keys in insertion order.  The keys are those held by the
+// map, and must not be modified.
+func (m *Map) Keys() []DataItem {
+	return append([]DataItem{}, m.keys...)
+}
+
+// Values returns the values in insertion order
+func (m *Map) Values() []DataItem {
+	return append([]DataItem{}, m.values...)
+}
+
+// DuplicateKeys returns the keys that appeared more than once in the decoded
+// data.  Only the last value of a duplicate key is kept in the map.
+func (m *Map) DuplicateKeys() []DataItem {
+	return m.duplicateKeys
 }
 
 // Length returns the number of map entries
@@ -45,25 +111,63 @@ func (m *Map) Length() int {
 
 // Value returns the map
 func (m *Map) Value() interface{} {
-	return m.m
+	return m.ValueAsMap()
 }
 
-// EncodeCBOR returns CBOR representation for this item
+// EncodeCBOR returns CBOR representation for this item, with the entries in
+// insertion order.  Use EncodeWithOptions for a deterministic encoding.
 func (m *Map) EncodeCBOR() []byte {
-	if uint64(m.Length()) > math.MaxUint64 {
-		return m.doEncodeCBOR(false)
+	return m.doEncodeCBOR(!m.indefinite)
+}
+
+// IsIndefinite returns true if the map is encoded with an indefinite length
+func (m *Map) IsIndefinite() bool {
+	return m.indefinite
+}
+
+// SetIndefinite selects an indefinite or definite length for the encoding of the map
+func (m *Map) SetIndefinite(indefinite bool) {
+	m.indefinite = indefinite
+	m.raw = nil
+}
+
+// Raw returns the bytes this map was decoded from, as long as its entries were
+// not modified (see baseDataItem.Raw)
+func (m *Map) Raw() []byte {
+	if len(m.duplicateKeys) > 0 {
+		// the original bytes hold entries that were replaced by later duplicates
+		for i, key := range m.keys {
+			if m.raw == nil || key.Raw() == nil || m.values[i].Raw() == nil {
+				return nil
+			}
+		}
+		return m.raw
 	}
-	return m.doEncodeCBOR(true)
+	items := make([]DataItem, 0, 2*len(m.keys))
+	for i, key := range m.keys {
+		items = append(items, key, m.values[i])
+	}
+	if !rawItemsHold(m.raw, items) {
+		return nil
+	}
+	return m.raw
 }
 
-// ValueAsMap returns the value as a map
+// ValueAsMap returns a copy of the entries as a Go map, keyed by the key data
+// items held by the map, which are those added or decoded.  Adding to the Go
+// map does not change this map, and the Go map is keyed by pointer, so use Get
+// to look up a key by value.
 func (m *Map) ValueAsMap() map[DataItem]DataItem {
-	return m.m
+	result := make(map[DataItem]DataItem, len(m.keys))
+	for i, key := range m.keys {
+		result[key] = m.values[i]
+	}
+	return result
 }
 
 // String returns description of this item
 func (m *Map) String() string {
-	return fmt.Sprintf("Map - Items: [%d]", len(m.m))
+	return fmt.Sprintf("Map - Items: [%d]", len(m.keys))
 }
 
 // doEncodeCBOR returns CBOR representation for this map.
@@ -81,16 +185,9 @@ func (m *Map) doEncodeCBOR(fixedLength bool) []byte {
 		result = []byte{MajorTypeMap.EncodeCBOR() | additionalTypeIndefinite}
 	}
 
-	keys := []DataItem{}
-	for key := range m.m {
-		keys = append(keys, key)
-	}
-	keySorter := NewDataItemSorter(keys)
-	sort.Sort(keySorter)
-
-	for _, key := range keys {
-		result = append(result, key.(DataItem).EncodeCBOR()...)
-		result = append(result, m.m[key].(DataItem).EncodeCBOR()...)
+	for i, key := range m.keys {
+		result = append(result, key.EncodeCBOR()...)
+		result = append(result, m.values[i].EncodeCBOR()...)
 	}
 
 	if !fixedLength {
@@ -100,3 +197,8 @@ func (m *Map) doEncodeCBOR(fixedLength bool) []byte {
 
 	return result
 }
+
+// mapKey returns the string used to compare map keys by value
+func mapKey(key DataItem) string {
+	return string(deterministicEncoding(key))
+}
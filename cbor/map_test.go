@@ -3,6 +3,7 @@ package cbor
 import (
 	"testing"
 
+	"github.com/gocardano/go-cardano-client/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,3 +60,136 @@ func TestMapIndefiniteLengthEncoding(t *testing.T) {
 	// Parse the generated encoded CBOR
 	assert.Equal(t, input, c[0].(*Map).doEncodeCBOR(false))
 }
+
+func TestMapGetByValue(t *testing.T) {
+
+	// {0: "a", 24: "b", -1: "c", "name": "d", h'01': "e"} with a 16 bit encoding for 24
+	input := []byte{0xa5,
+		0x00, 0x61, 0x61,
+		0x19, 0x00, 0x18, 0x61, 0x62,
+		0x20, 0x61, 0x63,
+		0x64, 0x6e, 0x61, 0x6d, 0x65, 0x61, 0x64,
+		0x41, 0x01, 0x61, 0x65,
+	}
+
+	c, err := Decode(input)
+	assert.Nil(t, err)
+	m := c[0].(*Map)
+
+	value, ok := m.GetUint(0)
+	assert.True(t, ok)
+	assert.Equal(t, "a", value.Value())
+
+	value, ok = m.GetUint(24)
+	assert.True(t, ok)
+	assert.Equal(t, "b", value.Value())
+
+	value, ok = m.GetInt(-1)
+	assert.True(t, ok)
+	assert.Equal(t, "c", value.Value())
+
+	value, ok = m.GetText("name")
+	assert.True(t, ok)
+	assert.Equal(t, "d", value.Value())
+
+	value, ok = m.Get(NewByteString([]byte{0x01}))
+	assert.True(t, ok)
+	assert.Equal(t, "e", value.Value())
+
+	_, ok = m.GetUint(1)
+	assert.False(t, ok)
+	_, ok = m.GetText("Name")
+	assert.False(t, ok)
+}
+
+func TestMapInsertionOrder(t *testing.T) {
+
+	m := NewMap()
+	m.Add(NewTextString("b"), NewPositiveInteger8(1))
+	m.Add(NewPositiveInteger8(10), NewPositiveInteger8(2))
+	m.Add(NewTextString("a"), NewPositiveInteger8(3))
+
+	// replacing a value keeps the position of the entry
+	m.Add(NewPositiveInteger16(10), NewPositiveInteger8(4))
+
+	assert.Equal(t, 3, m.Length())
+	assert.Equal(t, "b", m.Keys()[0].Value())
+	assert.Equal(t, uint8(4), m.Values()[1].Value())
+	assert.Equal(t, []byte{0xa3, 0x61, 0x62, 0x01, 0x0a, 0x04, 0x61, 0x61, 0x03}, m.EncodeCBOR())
+
+	// decoding keeps the order of the input
+	input := []byte{0xa3, 0x61, 0x7a, 0x01, 0x61, 0x61, 0x02, 0x00, 0x03}
+	c, err := Decode(input)
+	assert.Nil(t, err)
+	assert.Equal(t, input, c[0].EncodeCBOR())
+}
+
+func TestMapAddKeepsKey(t *testing.T) {
+
+	key := NewArrayWithItems([]DataItem{NewPositiveInteger16(1)})
+	m := NewMap()
+	m.Add(key, NewPositiveInteger8(2))
+
+	// the key added is the one held by the map, so it can be looked up by pointer
+	assert.True(t, m.Keys()[0] == DataItem(key))
+	value, ok := m.ValueAsMap()[key]
+	assert.True(t, ok)
+	assert.Equal(t, uint8(2), value.Value())
+
+	value, ok = m.Get(NewArrayWithItems([]DataItem{NewPositiveInteger8(1)}))
+	assert.True(t, ok)
+	assert.Equal(t, uint8(2), value.Value())
+}
+
+func TestMapDuplicateKeys(t *testing.T) {
+
+	// {1: "a", 2: "b", 1: "c"}
+	input := []byte{0xa3, 0x01, 0x61, 0x61, 0x02, 0x61, 0x62, 0x01, 0x61, 0x63}
+
+	c, err := Decode(input)
+	assert.Nil(t, err)
+	m := c[0].(*Map)
+	assert.Equal(t, 2, m.Length())
+	assert.Equal(t, 1, len(m.DuplicateKeys()))
+	value, _ := m.GetUint(1)
+	assert.Equal(t, "c", value.Value())
+
+	// the original bytes still hold every entry
+	assert.Equal(t, input, m.Raw())
+
+	_, err = DecodeWithOptions(input, DecodeOptions{DuplicateMapKeys: DuplicateMapKeyReject})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborDuplicateMapKey, errorCode(err))
+	}
+
+	// no duplicates in a valid map
+	c, err = DecodeWithOptions([]byte{0xa1, 0x01, 0x02}, DecodeOptions{DuplicateMapKeys: DuplicateMapKeyReject})
+	assert.Nil(t, err)
+	assert.Nil(t, c[0].(*Map).DuplicateKeys())
+}
+
+func TestIndefiniteMap(t *testing.T) {
+
+	m := NewIndefiniteMap()
+	assert.True(t, m.IsIndefinite())
+	m.Add(NewTextString("b"), NewPositiveInteger8(1))
+	m.Add(NewTextString("a"), NewPositiveInteger8(2))
+	assert.Equal(t, []byte{0xbf, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02, 0xff}, m.EncodeCBOR())
+	assert.Equal(t, `{_ "b": 1, "a": 2}`, Diagnostic(m))
+
+	actual, err := EncodeWithOptions(m, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xa2, 0x61, 0x61, 0x02, 0x61, 0x62, 0x01}, actual)
+
+	m.SetIndefinite(false)
+	assert.Equal(t, []byte{0xa2, 0x61, 0x62, 0x01, 0x61, 0x61, 0x02}, m.EncodeCBOR())
+
+	c, err := Decode([]byte{0xbf, 0x01, 0x02, 0xff})
+	if !assert.Nil(t, err) {
+		return
+	}
+	decoded := c[0].(*Map)
+	assert.True(t, decoded.IsIndefinite())
+	decoded.Add(NewPositiveInteger8(3), NewPositiveInteger8(4))
+	assert.Equal(t, []byte{0xbf, 0x01, 0x02, 0x03, 0x04, 0xff}, decoded.EncodeCBOR())
+}
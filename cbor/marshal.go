@@ -0,0 +1,248 @@
+package cbor
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// Marshaler is implemented by types that can convert themselves into a data item
+type Marshaler interface {
+	MarshalCBOR() (DataItem, error)
+}
+
+var (
+	dataItemType  = reflect.TypeOf((*DataItem)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	bigIntType    = reflect.TypeOf(big.Int{})
+)
+
+// Marshal returns the CBOR encoding of v.
+//
+// Go values are mapped to data items as follows:
+//
+//   - bool                    -> PrimitiveTrue / PrimitiveFalse
+//   - uint*, int*             -> PositiveInteger* / NegativeInteger* (most compact width)
+//   - float32, float64        -> PrimitiveSinglePrecisionFloat / PrimitiveDoublePrecisionFloat
+//   - string                  -> TextString
+//   - []byte, [N]byte         -> ByteString
+//   - slices and arrays       -> Array
+//   - maps                    -> Map (entries ordered by their encoded key)
+//   - structs                 -> Map keyed by field name, or Array when tagged with toarray
+//   - big.Int                 -> integer, or PositiveBignum / NegativeBignum when beyond 64 bits
+//   - nil pointer / interface -> PrimitiveNull
+//   - DataItem                -> used as is
+//   - Marshaler               -> result of MarshalCBOR
+func Marshal(v interface{}) ([]byte, error) {
+	item, err := MarshalDataItem(v)
+	if err != nil {
+		return nil, err
+	}
+	return item.EncodeCBOR(), nil
+}
+
+// MarshalWithOptions returns the CBOR encoding of v (see Marshal) in the given mode
+func MarshalWithOptions(v interface{}, options EncodeOptions) ([]byte, error) {
+	item, err := MarshalDataItem(v)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeWithOptions(item, options)
+}
+
+// MarshalDataItem returns the data item representation of v (see Marshal)
+func MarshalDataItem(v interface{}) (DataItem, error) {
+	if v == nil {
+		return NewPrimitiveNull(), nil
+	}
+	return marshalValue(reflect.ValueOf(v))
+}
+
+// marshalValue returns the data item for the reflected value
+func marshalValue(v reflect.Value) (DataItem, error) {
+
+	if !v.IsValid() {
+		return NewPrimitiveNull(), nil
+	}
+
+	t := v.Type()
+
+	if t.Implements(marshalerType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return NewPrimitiveNull(), nil
+		}
+		return v.Interface().(Marshaler).MarshalCBOR()
+	}
+
+	if t.Implements(dataItemType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return NewPrimitiveNull(), nil
+		}
+		return v.Interface().(DataItem), nil
+	}
+
+	if t == bigIntType {
+		n := v.Interface().(big.Int)
+		return newIntegerFromBig(&n), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return NewPrimitiveTrue(), nil
+		}
+		return NewPrimitiveFalse(), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return NewPositiveInteger(v.Uint()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() >= 0 {
+			return NewPositiveInteger(uint64(v.Int())), nil
+		}
+		return NewNegativeInteger(v.Int()), nil
+
+	case reflect.Float32:
+		return NewPrimitiveSinglePrecisionFloat(float32(v.Float())), nil
+
+	case reflect.Float64:
+		return NewPrimitiveDoublePrecisionFloat(v.Float()), nil
+
+	case reflect.String:
+		return NewTextString(v.String()), nil
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return NewPrimitiveNull(), nil
+		}
+		return marshalValue(v.Elem())
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return NewPrimitiveNull(), nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return NewByteString(v.Bytes()), nil
+		}
+		return marshalArray(v)
+
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return NewByteString(buf), nil
+		}
+		return marshalArray(v)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return NewPrimitiveNull(), nil
+		}
+		return marshalMap(v)
+
+	case reflect.Struct:
+		return marshalStruct(v)
+	}
+
+	return nil, errors.NewMessageErrorf(errors.ErrCborUnsupportedType, "Unable to marshal Go type [%s]", t)
+}
+
+// marshalArray returns an array data item for a slice or array value
+func marshalArray(v reflect.Value) (DataItem, error) {
+	items := make([]DataItem, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item, err := marshalValue(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return NewArrayWithItems(items), nil
+}
+
+// marshalMap returns a map data item for a map value.  Since Go maps have no
+// ordering, the entries are added in the order of their encoded keys.
+func marshalMap(v reflect.Value) (DataItem, error) {
+
+	type entry struct {
+		encodedKey []byte
+		key        DataItem
+		value      DataItem
+	}
+
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := marshalValue(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalValue(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{encodedKey: key.EncodeCBOR(), key: key, value: value})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].encodedKey, entries[j].encodedKey) < 0
+	})
+
+	m := NewMap()
+	for _, e := range entries {
+		m.Add(e.key, e.value)
+	}
+	return m, nil
+}
+
+// marshalStruct returns a map (or an array if tagged with toarray) data item for a struct value
+func marshalStruct(v reflect.Value) (DataItem, error) {
+
+	info, err := getStructInfo(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	if info.toArray {
+		items := make([]DataItem, 0, len(info.fields))
+		for _, field := range info.fields {
+			item, err := marshalValue(v.Field(field.index))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return NewArrayWithItems(items), nil
+	}
+
+	m := NewMap()
+	for _, field := range info.fields {
+		fieldValue := v.Field(field.index)
+		if field.omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+		item, err := marshalValue(fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		m.Add(field.key, item)
+	}
+	return m, nil
+}
+
+// newIntegerFromBig returns the most compact integer data item for the big integer
+func newIntegerFromBig(n *big.Int) DataItem {
+	switch {
+	case n.IsUint64():
+		return NewPositiveInteger(n.Uint64())
+	case n.IsInt64():
+		return NewNegativeInteger(n.Int64())
+	case n.Sign() > 0:
+		return NewPositiveBignumber(new(big.Int).Set(n))
+	default:
+		return NewNegativeBignumber(new(big.Int).Set(n))
+	}
+}
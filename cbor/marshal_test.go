@@ -0,0 +1,152 @@
+package cbor
+
+import (
+	e "errors"
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPoint struct {
+	_    struct{} `cbor:",toarray"`
+	Slot uint64
+	Hash []byte
+}
+
+type testTxBody struct {
+	Inputs []testPoint `cbor:"0,keyasint"`
+	Fee    uint64      `cbor:"2,keyasint"`
+	TTL    uint32      `cbor:"3,keyasint,omitempty"`
+}
+
+type testNamed struct {
+	Name    string `cbor:"name"`
+	Enabled bool
+	Skipped int `cbor:"-"`
+	hidden  int
+}
+
+func TestMarshalScalars(t *testing.T) {
+
+	testCases := []struct {
+		input  interface{}
+		expect []byte
+	}{
+		{input: nil, expect: []byte{0xf6}},
+		{input: true, expect: []byte{0xf5}},
+		{input: false, expect: []byte{0xf4}},
+		{input: uint8(10), expect: []byte{0x0a}},
+		{input: 500, expect: []byte{0x19, 0x01, 0xf4}},
+		{input: -1, expect: []byte{0x20}},
+		{input: int64(-1000), expect: []byte{0x39, 0x03, 0xe7}},
+		{input: "a", expect: []byte{0x61, 0x61}},
+		{input: []byte{0x01, 0x02}, expect: []byte{0x42, 0x01, 0x02}},
+		{input: [2]byte{0x01, 0x02}, expect: []byte{0x42, 0x01, 0x02}},
+		{input: []int{1, -1}, expect: []byte{0x82, 0x01, 0x20}},
+		{input: float32(1.5), expect: []byte{0xfa, 0x3f, 0xc0, 0x00, 0x00}},
+		{input: (*int)(nil), expect: []byte{0xf6}},
+		{input: NewTextString("b"), expect: []byte{0x61, 0x62}},
+		{input: big.NewInt(1), expect: []byte{0x01}},
+		{
+			input:  new(big.Int).Lsh(big.NewInt(1), 64),
+			expect: []byte{0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := Marshal(testCase.input)
+		assert.Nil(t, err)
+		assert.Equal(t, testCase.expect, actual, "%#v", testCase.input)
+	}
+}
+
+func TestMarshalStruct(t *testing.T) {
+
+	body := testTxBody{
+		Inputs: []testPoint{{Slot: 1, Hash: []byte{0xab}}},
+		Fee:    170000,
+	}
+
+	// {0: [[1, h'ab']], 2: 170000}
+	expect := []byte{0xa2,
+		0x00, 0x81, 0x82, 0x01, 0x41, 0xab,
+		0x02, 0x1a, 0x00, 0x02, 0x98, 0x10,
+	}
+
+	actual, err := Marshal(body)
+	assert.Nil(t, err)
+	assert.Equal(t, expect, actual)
+
+	var decoded testTxBody
+	assert.Nil(t, Unmarshal(actual, &decoded))
+	assert.Equal(t, body, decoded)
+}
+
+func TestMarshalStructTextKeys(t *testing.T) {
+
+	actual, err := Marshal(&testNamed{Name: "x", Enabled: true, Skipped: 1, hidden: 1})
+	assert.Nil(t, err)
+
+	// {"name": "x", "Enabled": true} (map keys are sorted by their encoding)
+	assert.Equal(t, []byte{0xa2,
+		0x64, 0x6e, 0x61, 0x6d, 0x65, 0x61, 0x78,
+		0x67, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0xf5,
+	}, actual)
+
+	var decoded testNamed
+	assert.Nil(t, Unmarshal(actual, &decoded))
+	assert.Equal(t, testNamed{Name: "x", Enabled: true}, decoded)
+}
+
+func TestMarshalMapIsOrdered(t *testing.T) {
+
+	actual, err := Marshal(map[uint]string{10: "a", 1: "b", 100: "c"})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xa3, 0x01, 0x61, 0x62, 0x0a, 0x61, 0x61, 0x18, 0x64, 0x61, 0x63}, actual)
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.NotNil(t, err)
+}
+
+func TestMarshalStructDuplicateKey(t *testing.T) {
+
+	type sameInt struct {
+		Fee uint64 `cbor:"2,keyasint"`
+		TTL uint64 `cbor:"2,keyasint"`
+	}
+	type sameName struct {
+		Fee uint64
+		Ttl uint64 `cbor:"Fee"`
+	}
+
+	for _, v := range []interface{}{sameInt{}, sameName{}} {
+		_, err := Marshal(v)
+		assert.True(t, e.Is(err, errors.NewError(errors.ErrCborInvalidStructTag)), "%T", v)
+	}
+
+	var decoded sameInt
+	err := Unmarshal([]byte{0xa1, 0x02, 0x01}, &decoded)
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrCborInvalidStructTag)))
+}
+
+func TestMarshalStructKeyAsIntNotInteger(t *testing.T) {
+
+	type invalid struct {
+		Fee uint64 `cbor:"fee,keyasint"`
+	}
+
+	_, err := Marshal(invalid{Fee: 1})
+	if assert.NotNil(t, err) {
+		assert.True(t, e.Is(err, errors.NewError(errors.ErrCborInvalidStructTag)))
+	}
+
+	var decoded invalid
+	err = Unmarshal([]byte{0xa1, 0x63, 0x66, 0x65, 0x65, 0x01}, &decoded)
+	if assert.NotNil(t, err) {
+		assert.True(t, e.Is(err, errors.NewError(errors.ErrCborInvalidStructTag)))
+	}
+}
@@ -77,6 +77,26 @@ func NewNegativeInteger64(value int64) *NegativeInteger64 {
 	}
 }
 
+// NewNegativeInteger returns a negative integer using the most compact
+// struct that would fit the value.  A value of zero or more is returned as a
+// positive integer.
+func NewNegativeInteger(value int64) DataItem {
+	if value >= 0 {
+		return NewPositiveInteger(uint64(value))
+	}
+	encodedValue := uint64(int64(-1) - value)
+	switch {
+	case encodedValue > math.MaxUint32:
+		return NewNegativeInteger64(value)
+	case encodedValue > math.MaxUint16:
+		return NewNegativeInteger32(value)
+	case encodedValue > math.MaxUint8:
+		return NewNegativeInteger16(value)
+	default:
+		return NewNegativeInteger8(value)
+	}
+}
+
 // newBaseNegativeInteger returns new base negative integer instance
 func newBaseNegativeInteger(value int64, additionalType uint8) baseNegativeInteger {
 	return baseNegativeInteger{
@@ -16,6 +16,8 @@ func TestNegativeIntErrorCaseZeroOrPositive(t *testing.T) {
 		assert.Nil(t, NewNegativeInteger16(i))
 		assert.Nil(t, NewNegativeInteger32(i))
 		assert.Nil(t, NewNegativeInteger64(i))
+		assert.Equal(t, uint8(i), NewNegativeInteger(i).Value())
+		assert.Equal(t, MajorTypePositiveInt, NewNegativeInteger(i).MajorType())
 	}
 }
 
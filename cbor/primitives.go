@@ -242,7 +242,7 @@ func NewPrimitiveHalfPrecisionFloat(value uint16) *PrimitiveHalfPrecisionFloat {
 	}
 }
 
-// AdditionalTypeValue returns the value as uint64
+// AdditionalTypeValue returns the bits of the value as uint64
 func (p *PrimitiveHalfPrecisionFloat) AdditionalTypeValue() uint64 {
 	return uint64(p.V.Bits())
 }
@@ -252,6 +252,11 @@ func (p *PrimitiveHalfPrecisionFloat) Value() interface{} {
 	return p.V.Float32()
 }
 
+// Float64 returns the value as float64, which represents it exactly
+func (p *PrimitiveHalfPrecisionFloat) Float64() float64 {
+	return float64(p.V.Float32())
+}
+
 // EncodeCBOR returns CBOR representation for this item
 func (p *PrimitiveHalfPrecisionFloat) EncodeCBOR() []byte {
 	return []byte{MajorTypePrimitive.EncodeCBOR() | primitiveHalfPrecisionFloat,
@@ -279,9 +284,9 @@ func NewPrimitiveSinglePrecisionFloat(value float32) *PrimitiveSinglePrecisionFl
 
 }
 
-// AdditionalTypeValue returns the value as uint64
+// AdditionalTypeValue returns the bits of the value as uint64
 func (p *PrimitiveSinglePrecisionFloat) AdditionalTypeValue() uint64 {
-	return uint64(p.V)
+	return uint64(math.Float32bits(p.V))
 }
 
 // Value returns the single precision float value
@@ -289,6 +294,11 @@ func (p *PrimitiveSinglePrecisionFloat) Value() interface{} {
 	return p.V
 }
 
+// Float64 returns the value as float64, which represents it exactly
+func (p *PrimitiveSinglePrecisionFloat) Float64() float64 {
+	return float64(p.V)
+}
+
 // EncodeCBOR returns CBOR representation for this item
 func (p *PrimitiveSinglePrecisionFloat) EncodeCBOR() []byte {
 	return []byte{MajorTypePrimitive.EncodeCBOR() | primitiveSinglePrecisionFloat,
@@ -317,7 +327,7 @@ func NewPrimitiveDoublePrecisionFloat(value float64) *PrimitiveDoublePrecisionFl
 	}
 }
 
-// AdditionalTypeValue returns the value as uint64
+// AdditionalTypeValue returns the bits of the value as uint64
 func (p *PrimitiveDoublePrecisionFloat) AdditionalTypeValue() uint64 {
 	return math.Float64bits(p.V)
 }
@@ -327,9 +337,24 @@ func (p *PrimitiveDoublePrecisionFloat) Value() interface{} {
 	return p.V
 }
 
+// Float64 returns the value
+func (p *PrimitiveDoublePrecisionFloat) Float64() float64 {
+	return p.V
+}
+
 // EncodeCBOR returns CBOR representation for this item
 func (p *PrimitiveDoublePrecisionFloat) EncodeCBOR() []byte {
-	return dataItemPrefix(MajorTypePrimitive, math.Float64bits(p.V))
+	bits := math.Float64bits(p.V)
+	return []byte{MajorTypePrimitive.EncodeCBOR() | primitiveDoublePrecisionFloat,
+		byte(bits >> 56),
+		byte(bits >> 48),
+		byte(bits >> 40),
+		byte(bits >> 32),
+		byte(bits >> 24),
+		byte(bits >> 16),
+		byte(bits >> 8),
+		byte(bits),
+	}
 }
 
 // String returns description of this item
@@ -339,6 +364,28 @@ func (p *PrimitiveDoublePrecisionFloat) String() string {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// NewFloat returns the half, single or double precision float with the fewest
+// bytes that represents the value exactly, as the preferred serialization of
+// RFC 8949 section 4.1 requires.  Infinities and zeros are half precision
+// floats, and NaN is always the half precision quiet NaN 0xf97e00, whatever
+// its payload.
+func NewFloat(f float64) DataItem {
+
+	switch shortestFloatType(f) {
+	case primitiveHalfPrecisionFloat:
+		if math.IsNaN(f) {
+			return NewPrimitiveHalfPrecisionFloat(halfPrecisionQuietNaN)
+		}
+		return NewPrimitiveHalfPrecisionFloat(float16.Fromfloat32(float32(f)).Bits())
+	case primitiveSinglePrecisionFloat:
+		return NewPrimitiveSinglePrecisionFloat(float32(f))
+	}
+
+	return NewPrimitiveDoublePrecisionFloat(f)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 // NewPrimitiveBreakStopCode returns instance of PrimitiveFalse struct
 func NewPrimitiveBreakStopCode() *PrimitiveBreakStopCode {
 	return &PrimitiveBreakStopCode{
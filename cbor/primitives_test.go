@@ -1,9 +1,11 @@
 package cbor
 
 import (
+	"encoding/hex"
 	"math"
 	"testing"
 
+	"github.com/gocardano/go-cardano-client/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -153,3 +155,71 @@ func TestNewPrimitiveFloatRange(t *testing.T) {
 	assert.NotNil(t, NewPrimitiveDoublePrecisionFloat(math.MaxFloat64))
 	assert.NotNil(t, NewPrimitiveDoublePrecisionFloat(math.SmallestNonzeroFloat64))
 }
+
+func TestNewFloat(t *testing.T) {
+
+	testCases := []struct {
+		value  float64
+		expect string
+	}{
+		{value: 0.0, expect: "f90000"},
+		{value: math.Copysign(0, -1), expect: "f98000"},
+		{value: 1.0, expect: "f93c00"},
+		{value: 1.1, expect: "fb3ff199999999999a"},
+		{value: 1.5, expect: "f93e00"},
+		{value: 65504.0, expect: "f97bff"},
+		{value: 65505.0, expect: "fa477fe100"},
+		{value: 100000.0, expect: "fa47c35000"},
+		{value: 3.4028234663852886e+38, expect: "fa7f7fffff"},
+		{value: 1.0e+300, expect: "fb7e37e43c8800759c"},
+		{value: 5.960464477539063e-8, expect: "f90001"},
+		{value: 0.00006103515625, expect: "f90400"},
+		{value: -4.0, expect: "f9c400"},
+		{value: -4.1, expect: "fbc010666666666666"},
+		{value: math.Inf(1), expect: "f97c00"},
+		{value: math.Inf(-1), expect: "f9fc00"},
+		{value: math.NaN(), expect: "f97e00"},
+		{value: math.Float64frombits(0x7ff0000000000001), expect: "f97e00"},
+	}
+
+	for _, testCase := range testCases {
+		item := NewFloat(testCase.value)
+		assert.Equal(t, testCase.expect, hex.EncodeToString(item.EncodeCBOR()), "%v", testCase.value)
+
+		f, err := AsFloat64(item)
+		assert.Nil(t, err)
+		if math.IsNaN(testCase.value) {
+			assert.True(t, math.IsNaN(f))
+		} else {
+			assert.Equal(t, math.Float64bits(testCase.value), math.Float64bits(f), "%v", testCase.value)
+		}
+	}
+}
+
+func TestFloatAccessors(t *testing.T) {
+
+	items, err := Decode([]byte{0xf9, 0x3e, 0x00, 0xfa, 0x47, 0xc3, 0x50, 0x00, 0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a, 0x01})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for i, expect := range []float64{1.5, 100000, 1.1} {
+		f, err := AsFloat64(items[i])
+		assert.Nil(t, err)
+		assert.Equal(t, expect, f)
+	}
+
+	_, err = AsFloat64(items[3])
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	_, err = AsFloat64(nil)
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	// the additional type value holds the bits of every float width
+	assert.Equal(t, uint64(0x3e00), items[0].AdditionalTypeValue())
+	assert.Equal(t, uint64(0x47c35000), items[1].AdditionalTypeValue())
+	assert.Equal(t, uint64(0x3ff199999999999a), items[2].AdditionalTypeValue())
+
+	f, err := Path(NewArrayWithItems(items)).Index(1).Float64()
+	assert.Nil(t, err)
+	assert.Equal(t, float64(100000), f)
+}
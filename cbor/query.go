@@ -0,0 +1,333 @@
+package cbor
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// QueryError reports the step of a path that could not be followed, or the
+// data item that could not be converted at the end of it
+type QueryError struct {
+	// Path leads to the data item that is missing or has another type, in the
+	// syntax of Query
+	Path string
+
+	Err error
+}
+
+// Error string
+func (e *QueryError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "top level"
+	}
+	return fmt.Sprintf("CBOR query failed at path [%s]: %s", path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// Cursor walks down a data item step by step.  Each step returns a new
+// cursor.  Steps after a failed step do nothing, and the first failure is
+// returned by the method ending the walk, so the steps can be chained:
+//
+//	hash, err := cbor.Path(item).Index(2).Index(0).Key(1).Bytes()
+type Cursor struct {
+	item DataItem
+	path string
+	err  error
+}
+
+// Path returns a cursor at the data item
+func Path(item DataItem) *Cursor {
+	c := &Cursor{item: item}
+	if item == nil {
+		return c.fail(errors.NewMessageErrorf(errors.ErrCborPathNotFound, "No data item"))
+	}
+	return c
+}
+
+// Query returns the data item at the path below item.  The path is a sequence
+// of steps, the same as those of a DecodeError: [i] for the element at index
+// i of an array, {k} for the value of the key k of a map, written in
+// diagnostic notation, and (n) for the content of semantic tag n.  For
+// example [2][0]{"fee"} or {1}(258)[0].
+func Query(item DataItem, path string) (DataItem, error) {
+	return Path(item).Query(path).Item()
+}
+
+// Index moves to the element at index i of an array
+func (c *Cursor) Index(i int) *Cursor {
+	c = c.step(fmt.Sprintf("[%d]", i))
+	if c.err != nil {
+		return c
+	}
+	array, ok := c.item.(*Array)
+	if !ok {
+		return c.fail(accessorMismatch(c.item, "array"))
+	}
+	if i < 0 || i >= array.Length() {
+		return c.fail(errors.NewMessageErrorf(errors.ErrCborPathNotFound,
+			"Index [%d] is outside of an array of length [%d]", i, array.Length()))
+	}
+	c.item = array.Get(i)
+	return c
+}
+
+// Key moves to the value of a map entry.  The key is a data item, or a Go
+// value that is marshaled to one (see MarshalDataItem), such as 1 or "fee".
+// Keys are compared by value, regardless of their integer width.
+func (c *Cursor) Key(key interface{}) *Cursor {
+	if c.err != nil {
+		return c
+	}
+	k, err := MarshalDataItem(key)
+	if err != nil {
+		return c.step(fmt.Sprintf("{%v}", key)).fail(err)
+	}
+	return c.key(k)
+}
+
+// key moves to the value of the map entry with the key data item
+func (c *Cursor) key(key DataItem) *Cursor {
+	c = c.step("{" + Diagnostic(key) + "}")
+	if c.err != nil {
+		return c
+	}
+	m, ok := c.item.(*Map)
+	if !ok {
+		return c.fail(accessorMismatch(c.item, "map"))
+	}
+	value, found := m.Get(key)
+	if !found {
+		return c.fail(errors.NewMessageErrorf(errors.ErrCborPathNotFound, "Map has no key [%s]", Diagnostic(key)))
+	}
+	c.item = value
+	return c
+}
+
+// Tag moves to the content of semantic tag number n
+func (c *Cursor) Tag(n uint64) *Cursor {
+	c = c.step(fmt.Sprintf("(%d)", n))
+	if c.err != nil {
+		return c
+	}
+	if c.item.MajorType() != MajorTypeSemantic || c.item.AdditionalTypeValue() != n {
+		return c.fail(accessorMismatch(c.item, fmt.Sprintf("tag [%d]", n)))
+	}
+	content, known, err := tagContent(c.item)
+	if err != nil {
+		return c.fail(err)
+	}
+	if !known {
+		return c.fail(errors.NewMessageErrorf(errors.ErrCborUnsupportedType,
+			"Content of tag [%d] is not available", n))
+	}
+	c.item = content
+	return c
+}
+
+// Query follows the steps of a path (see Query)
+func (c *Cursor) Query(path string) *Cursor {
+
+	for pos := 0; pos < len(path) && c.err == nil; {
+		switch path[pos] {
+		case '[', '(':
+			close := byte(']')
+			if path[pos] == '(' {
+				close = ')'
+			}
+			end := pos + 1
+			for end < len(path) && path[end] != close {
+				end++
+			}
+			if end == len(path) {
+				return c.invalidPath(path, pos, "Unterminated step")
+			}
+			// indexes are limited to the size of an int
+			bitSize := 64
+			if close == ']' {
+				bitSize = strconv.IntSize - 1
+			}
+			n, err := strconv.ParseUint(path[pos+1:end], 10, bitSize)
+			if err != nil {
+				return c.invalidPath(path, pos, "Invalid number in step")
+			}
+			if close == ']' {
+				c = c.Index(int(n))
+			} else {
+				c = c.Tag(n)
+			}
+			pos = end + 1
+
+		case '{':
+			p := &diagnosticParser{input: path, pos: pos + 1}
+			p.skipSpace()
+			if err := p.parseItem(); err != nil {
+				return c.invalidPath(path, pos, "Invalid map key")
+			}
+			p.skipSpace()
+			if !p.consume("}") {
+				return c.invalidPath(path, pos, "Unterminated step")
+			}
+			key, err := newDecoder(p.buf, DecodeOptions{}).decodeNext()
+			if err != nil {
+				return c.invalidPath(path, pos, "Invalid map key")
+			}
+			c = c.key(key)
+			pos = p.pos
+
+		default:
+			return c.invalidPath(path, pos, "Unexpected character")
+		}
+	}
+
+	return c
+}
+
+// Err returns the error of the first failed step
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Item returns the data item at the cursor
+func (c *Cursor) Item() (DataItem, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.item, nil
+}
+
+// Array returns the array at the cursor
+func (c *Cursor) Array() (*Array, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	array, ok := c.item.(*Array)
+	if !ok {
+		return nil, c.wrap(accessorMismatch(c.item, "array"))
+	}
+	return array, nil
+}
+
+// Map returns the map at the cursor
+func (c *Cursor) Map() (*Map, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	m, ok := c.item.(*Map)
+	if !ok {
+		return nil, c.wrap(accessorMismatch(c.item, "map"))
+	}
+	return m, nil
+}
+
+// Len returns the number of elements of the array or entries of the map at the cursor
+func (c *Cursor) Len() (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	switch obj := c.item.(type) {
+	case *Array:
+		return obj.Length(), nil
+	case *Map:
+		return obj.Length(), nil
+	}
+	return 0, c.wrap(accessorMismatch(c.item, "array or map"))
+}
+
+// Uint64 returns the unsigned integer at the cursor (see AsUint64)
+func (c *Cursor) Uint64() (uint64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	value, err := AsUint64(c.item)
+	return value, c.wrap(err)
+}
+
+// Int64 returns the integer at the cursor (see AsInt64)
+func (c *Cursor) Int64() (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	value, err := AsInt64(c.item)
+	return value, c.wrap(err)
+}
+
+// BigInt returns the integer or bignum at the cursor (see AsBigInt)
+func (c *Cursor) BigInt() (*big.Int, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	value, err := AsBigInt(c.item)
+	return value, c.wrap(err)
+}
+
+// Float64 returns the float at the cursor (see AsFloat64)
+func (c *Cursor) Float64() (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	value, err := AsFloat64(c.item)
+	return value, c.wrap(err)
+}
+
+// Bytes returns the byte string at the cursor (see AsBytes)
+func (c *Cursor) Bytes() ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	value, err := AsBytes(c.item)
+	return value, c.wrap(err)
+}
+
+// Text returns the text string at the cursor (see AsText)
+func (c *Cursor) Text() (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	value, err := AsText(c.item)
+	return value, c.wrap(err)
+}
+
+// Bool returns the boolean at the cursor (see AsBool)
+func (c *Cursor) Bool() (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	value, err := AsBool(c.item)
+	return value, c.wrap(err)
+}
+
+// step returns a cursor with the step appended to the path, or the cursor
+// itself if an earlier step failed
+func (c *Cursor) step(step string) *Cursor {
+	if c.err != nil {
+		return c
+	}
+	return &Cursor{item: c.item, path: c.path + step}
+}
+
+// fail returns a cursor with the error of the current step
+func (c *Cursor) fail(err error) *Cursor {
+	return &Cursor{path: c.path, err: c.wrap(err)}
+}
+
+// wrap returns err as a QueryError at the current path
+func (c *Cursor) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &QueryError{Path: c.path, Err: err}
+}
+
+// invalidPath records a syntax error in a path given to Query
+func (c *Cursor) invalidPath(path string, pos int, reason string) *Cursor {
+	return c.fail(errors.NewMessageErrorf(errors.ErrCborInvalidPath,
+		"%s at offset [%d] of path [%s]", reason, pos, path))
+}
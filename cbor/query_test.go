@@ -0,0 +1,117 @@
+package cbor
+
+import (
+	e "errors"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const queryTestData = `[0, {1: h'ab', "fee": 7_2, [1]: true}, 258([3, 4]), 2(h'0100')]`
+
+func TestQuery(t *testing.T) {
+
+	item, err := ParseDiagnostic(queryTestData)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	testCases := []struct {
+		path       string
+		expect     string
+		expectCode int
+		errorPath  string
+	}{
+		{path: "", expect: queryTestData},
+		{path: "[0]", expect: "0"},
+		{path: "[1]{1}", expect: "h'ab'"},
+		{path: "[1]{1_1}", expect: "h'ab'"},
+		{path: `[1]{ "fee" }`, expect: "7_2"},
+		{path: "[1]{[1]}", expect: "true"},
+		{path: "[2](258)[1]", expect: "4"},
+		{path: "[3](2)", expect: "h'0100'"},
+		{path: "[4]", expectCode: errors.ErrCborPathNotFound, errorPath: "[4]"},
+		{path: "[0][0]", expectCode: errors.ErrCborTypeMismatch, errorPath: "[0][0]"},
+		{path: "[1]{2}[0]", expectCode: errors.ErrCborPathNotFound, errorPath: "[1]{2}"},
+		{path: "[1]{1}(24)", expectCode: errors.ErrCborTypeMismatch, errorPath: "[1]{1}(24)"},
+		{path: "[2](259)", expectCode: errors.ErrCborTypeMismatch, errorPath: "[2](259)"},
+		{path: "[1", expectCode: errors.ErrCborInvalidPath},
+		{path: "[-1]", expectCode: errors.ErrCborInvalidPath},
+		{path: "[99999999999999999999]", expectCode: errors.ErrCborInvalidPath},
+		{path: "[1]{1", expectCode: errors.ErrCborInvalidPath, errorPath: "[1]"},
+		{path: "[1]{}", expectCode: errors.ErrCborInvalidPath, errorPath: "[1]"},
+		{path: ".1", expectCode: errors.ErrCborInvalidPath},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := Query(item, testCase.path)
+		if testCase.expectCode == 0 {
+			if assert.Nil(t, err, testCase.path) {
+				assert.Equal(t, testCase.expect, Diagnostic(actual), testCase.path)
+			}
+			continue
+		}
+		var queryError *QueryError
+		if assert.True(t, e.As(err, &queryError), "%s: %v", testCase.path, err) {
+			assert.Equal(t, testCase.expectCode, errorCode(err), testCase.path)
+			assert.Equal(t, testCase.errorPath, queryError.Path, testCase.path)
+		}
+		assert.Nil(t, actual, testCase.path)
+	}
+}
+
+func TestCursor(t *testing.T) {
+
+	item, err := ParseDiagnostic(queryTestData)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	entries := Path(item).Index(1)
+
+	b, err := entries.Key(1).Bytes()
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xab}, b)
+
+	fee, err := entries.Key("fee").Uint64()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(7), fee)
+
+	flag, err := entries.Key(NewArrayWithItems([]DataItem{NewPositiveInteger8(1)})).Bool()
+	assert.Nil(t, err)
+	assert.True(t, flag)
+
+	n, err := entries.Len()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+
+	n, err = Path(item).Query("[2](258)").Len()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+
+	bignum, err := Path(item).Index(3).BigInt()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(256), bignum.Int64())
+
+	// the failing step is reported, and later steps are skipped
+	_, err = entries.Key(1).Uint64()
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	assert.Equal(t, "[1]{1}", err.(*QueryError).Path)
+
+	_, err = Path(item).Index(0).Index(2).Key("x").Text()
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	assert.Equal(t, "[0][2]", err.(*QueryError).Path)
+
+	_, err = Path(item).Index(9).Array()
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrCborPathNotFound)))
+
+	_, err = Path(item).Index(0).Map()
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	_, err = Path(nil).Int64()
+	assert.Equal(t, errors.ErrCborPathNotFound, errorCode(err))
+
+	assert.Nil(t, entries.Err())
+	assert.NotNil(t, entries.Key(2).Err())
+}
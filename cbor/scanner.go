@@ -0,0 +1,205 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// scanner finds the boundary of the next CBOR data item without decoding it.
+// It is resumable: when the data ends in the middle of an item, scan can be
+// called again once more bytes have been appended to the same buffer, and it
+// continues from where it stopped instead of starting over.
+type scanner struct {
+	offset int
+	stack  []scanFrame
+}
+
+// scanFrame tracks a container (or indefinite length string) that is still open
+type scanFrame struct {
+	majorType  MajorType
+	indefinite bool
+	remaining  uint64
+}
+
+// Scanner finds the boundaries of the data items of data received in parts,
+// without decoding them.  The zero value is ready to use.
+type Scanner struct {
+	s scanner
+}
+
+// Scan returns the length in bytes of the first data item in data, or false
+// if the data item is not complete yet.  After false, Scan must be called
+// again with the same data extended with more bytes, and continues where it
+// stopped.  After a complete data item or an error, it starts over.
+func (s *Scanner) Scan(data []byte) (int, bool, error) {
+	end, complete, err := s.s.scan(data)
+	if err != nil {
+		s.s.reset()
+		return 0, false, rescanError(data, DecodeOptions{}, err)
+	}
+	if complete {
+		s.s.reset()
+	}
+	return end, complete, nil
+}
+
+// reset prepares the scanner for the next data item
+func (s *scanner) reset() {
+	s.offset = 0
+	s.stack = s.stack[:0]
+}
+
+// scan returns the length in bytes of the first data item in data.  If the
+// data item is not complete yet, false is returned with a nil error.
+func (s *scanner) scan(data []byte) (int, bool, error) {
+
+	for s.offset < len(data) {
+
+		initial := data[s.offset]
+		majorType := MajorType(initial >> 5)
+		additionalType := initial & 0x1f
+
+		if initial == indefiniteBreakCode {
+			if len(s.stack) == 0 || !s.stack[len(s.stack)-1].indefinite {
+				return 0, false, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+					"Unexpected break code at offset [%d]", s.offset)
+			}
+			s.offset++
+			s.stack = s.stack[:len(s.stack)-1]
+			if s.completeItem() {
+				return s.offset, true, nil
+			}
+			continue
+		}
+
+		if len(s.stack) > 0 {
+			top := s.stack[len(s.stack)-1]
+			if top.indefinite && isStringMajorType(top.majorType) &&
+				(majorType != top.majorType || additionalType == additionalTypeIndefinite) {
+				return 0, false, errors.NewMessageErrorf(errors.ErrCborMajorTypeUnhandled,
+					"Invalid chunk in indefinite length string at offset [%d]", s.offset)
+			}
+		}
+
+		length, ok := headerLength(additionalType)
+		if !ok {
+			return 0, false, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+				"Unhandled additional type [%d] at offset [%d]", additionalType, s.offset)
+		}
+		if s.offset+length > len(data) {
+			return 0, false, nil
+		}
+		value := readArgument(data[s.offset:], additionalType)
+
+		complete := true
+
+		switch majorType {
+		case MajorTypePositiveInt, MajorTypeNegativeInt:
+			if additionalType == additionalTypeIndefinite {
+				return 0, false, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+					"Indefinite length integer at offset [%d]", s.offset)
+			}
+
+		case MajorTypeByteString, MajorTypeTextString:
+			if additionalType == additionalTypeIndefinite {
+				s.stack = append(s.stack, scanFrame{majorType: majorType, indefinite: true})
+				complete = false
+			} else if uint64(len(data)-s.offset-length) < value {
+				// header is complete but the payload isn't, revisit the header next time
+				return 0, false, nil
+			} else {
+				s.offset += int(value)
+			}
+
+		case MajorTypeArray, MajorTypeMap:
+			count := value
+			if majorType == MajorTypeMap {
+				if value > math.MaxUint64/2 {
+					return 0, false, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+						"Invalid map length [%d] at offset [%d]", value, s.offset)
+				}
+				count = 2 * value
+			}
+			if additionalType == additionalTypeIndefinite {
+				s.stack = append(s.stack, scanFrame{majorType: majorType, indefinite: true})
+				complete = false
+			} else if count > 0 {
+				s.stack = append(s.stack, scanFrame{majorType: majorType, remaining: count})
+				complete = false
+			}
+
+		case MajorTypeSemantic:
+			if additionalType == additionalTypeIndefinite {
+				return 0, false, errors.NewMessageErrorf(errors.ErrCborAdditionalTypeUnhandled,
+					"Indefinite length semantic tag at offset [%d]", s.offset)
+			}
+			s.stack = append(s.stack, scanFrame{majorType: majorType, remaining: 1})
+			complete = false
+		}
+
+		s.offset += length
+
+		if complete && s.completeItem() {
+			return s.offset, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// completeItem records a completed item within the open containers, and
+// returns true if this completes the top level data item.
+func (s *scanner) completeItem() bool {
+	for len(s.stack) > 0 {
+		top := &s.stack[len(s.stack)-1]
+		if top.indefinite {
+			return false
+		}
+		top.remaining--
+		if top.remaining > 0 {
+			return false
+		}
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+	return true
+}
+
+// headerLength returns the number of bytes used by the initial byte and the
+// argument that follows it for the additional type
+func headerLength(additionalType uint8) (int, bool) {
+	switch {
+	case additionalType <= additionalTypeDirectValue23:
+		return 1, true
+	case additionalType == additionalType8Bits:
+		return 2, true
+	case additionalType == additionalType16Bits:
+		return 3, true
+	case additionalType == additionalType32Bits:
+		return 5, true
+	case additionalType == additionalType64Bits:
+		return 9, true
+	case additionalType == additionalTypeIndefinite:
+		return 1, true
+	}
+	return 0, false
+}
+
+// readArgument returns the argument of the header starting at data[0].  The
+// caller guarantees that data holds at least headerLength bytes.
+func readArgument(data []byte, additionalType uint8) uint64 {
+	switch additionalType {
+	case additionalType8Bits:
+		return uint64(data[1])
+	case additionalType16Bits:
+		return uint64(binary.BigEndian.Uint16(data[1:3]))
+	case additionalType32Bits:
+		return uint64(binary.BigEndian.Uint32(data[1:5]))
+	case additionalType64Bits:
+		return binary.BigEndian.Uint64(data[1:9])
+	case additionalTypeIndefinite:
+		return 0
+	}
+	return uint64(additionalType)
+}
@@ -37,6 +37,10 @@ type PositiveBignum struct {
 type NegativeBignum struct {
 	baseSemantic
 	V *big.Int
+
+	// integer is true for a value decoded from a negative integer beyond the
+	// int64 range, down to -2^64, which is encoded again as such
+	integer bool
 }
 
 // URI wraps a URI string (semantic additional type value: 32)
@@ -138,12 +142,7 @@ func (d *DateTimeEpoch) EncodeCBOR() []byte {
 	buf := dataItemPrefix(MajorTypeSemantic, semanticDateTimeEpoch)
 
 	// text tag prefix
-	epoch := d.V.Unix()
-	if epoch > 0 {
-		buf = append(buf, NewPositiveInteger64(uint64(epoch)).EncodeCBOR()...)
-	} else {
-		buf = append(buf, NewNegativeInteger64(epoch).EncodeCBOR()...)
-	}
+	buf = append(buf, newInteger(d.V.Unix()).EncodeCBOR()...)
 
 	return buf
 }
@@ -215,6 +214,9 @@ func (n *NegativeBignum) Value() interface{} {
 
 // EncodeCBOR returns CBOR representation for this item
 func (n *NegativeBignum) EncodeCBOR() []byte {
+	if encodedValue := n.encodedValue(); n.integer && encodedValue.IsUint64() {
+		return dataItemPrefix(MajorTypeNegativeInt, encodedValue.Uint64())
+	}
 	result := dataItemPrefix(MajorTypeSemantic, semanticNegativeBignum)
 	result = append(result, NewByteString(n.encodedValue().Bytes()).EncodeCBOR()...)
 	return result
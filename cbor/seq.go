@@ -0,0 +1,283 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+const (
+	// SeqIndexSuffix is appended to the name of a sequence file to name its index
+	SeqIndexSuffix = ".idx"
+
+	// seqIndexRecordSize is the size of an index record: the key and the
+	// offset of the data item, both as big endian uint64
+	seqIndexRecordSize = 16
+)
+
+// SeqWriter appends data items to a CBOR sequence file (RFC 8742), which is
+// the concatenation of their encodings.  Data items are written as they were
+// received (see EncodeModePreserve), so that raw blocks keep their hashes.
+//
+// An optional index file next to the sequence file records a key, such as the
+// slot number of a block, and the offset of each data item written with
+// WriteIndexed, for SeqReader.Lookup.  Writes are not atomic: a data item cut
+// short by a crash leaves the end of the file invalid, which SeqReader reports
+// as io.ErrUnexpectedEOF.
+type SeqWriter struct {
+	f      *os.File
+	index  *os.File
+	offset int64
+}
+
+// SeqReader reads the data items of a CBOR sequence file, in order with Next
+// or at a known offset with ReadAt and Lookup
+type SeqReader struct {
+	f       *os.File
+	size    int64
+	counter *countingReader
+	decoder *Decoder
+	offset  int64
+	keys    map[uint64]int64
+	options DecodeOptions
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// OpenSeqWriter opens the sequence file for appending, creating it if it does
+// not exist.  With index set, the index file (path + SeqIndexSuffix) is opened
+// for appending as well.
+func OpenSeqWriter(path string, index bool) (*SeqWriter, error) {
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w := &SeqWriter{
+		f:      f,
+		offset: offset,
+	}
+
+	if index {
+		w.index, err = os.OpenFile(path+SeqIndexSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err == nil {
+			err = checkSeqIndexSize(w.index)
+		}
+		if err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+// Write appends the data item and returns its offset in the file
+func (w *SeqWriter) Write(item DataItem) (int64, error) {
+
+	data, err := EncodeWithOptions(item, EncodeOptions{Mode: EncodeModePreserve})
+	if err != nil {
+		return 0, err
+	}
+
+	offset := w.offset
+	n, err := w.f.Write(data)
+	w.offset += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// WriteIndexed appends the data item and records its offset under the key in
+// the index.  A key written again refers to the latest data item.
+func (w *SeqWriter) WriteIndexed(key uint64, item DataItem) (int64, error) {
+
+	if w.index == nil {
+		return 0, errors.NewMessageErrorf(errors.ErrCborSequenceIndexInvalid, "Sequence writer has no index")
+	}
+
+	offset, err := w.Write(item)
+	if err != nil {
+		return 0, err
+	}
+
+	var record [seqIndexRecordSize]byte
+	binary.BigEndian.PutUint64(record[:8], key)
+	binary.BigEndian.PutUint64(record[8:], uint64(offset))
+	if _, err := w.index.Write(record[:]); err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+// Sync commits the sequence file and its index to stable storage
+func (w *SeqWriter) Sync() error {
+	if err := w.f.Sync(); err != nil {
+		return err
+	}
+	if w.index != nil {
+		return w.index.Sync()
+	}
+	return nil
+}
+
+// Close closes the sequence file and its index
+func (w *SeqWriter) Close() error {
+	err := w.f.Close()
+	if w.index != nil {
+		if indexErr := w.index.Close(); err == nil {
+			err = indexErr
+		}
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// OpenSeqReader opens the sequence file for reading, together with its index
+// if there is one
+func OpenSeqReader(path string) (*SeqReader, error) {
+	return OpenSeqReaderWithOptions(path, DecodeOptions{})
+}
+
+// OpenSeqReaderWithOptions opens the sequence file for reading with the given options
+func OpenSeqReaderWithOptions(path string, options DecodeOptions) (*SeqReader, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := &SeqReader{
+		f:       f,
+		size:    info.Size(),
+		counter: &countingReader{r: f},
+		options: options,
+	}
+	r.decoder = NewDecoderWithOptions(r.counter, options)
+
+	keys, err := readSeqIndex(path+SeqIndexSuffix, r.size)
+	if err != nil && !os.IsNotExist(err) {
+		f.Close()
+		return nil, err
+	}
+	r.keys = keys
+
+	return r, nil
+}
+
+// Next returns the next data item of the sequence, or io.EOF after the last one
+func (r *SeqReader) Next() (DataItem, error) {
+	r.offset = r.counter.n - int64(len(r.decoder.Buffered()))
+	return r.decoder.Decode()
+}
+
+// Offset returns the offset of the data item last returned by Next
+func (r *SeqReader) Offset() int64 {
+	return r.offset
+}
+
+// ReadAt returns the data item at the offset, without moving Next
+func (r *SeqReader) ReadAt(offset int64) (DataItem, error) {
+	if offset < 0 || offset >= r.size {
+		return nil, io.EOF
+	}
+	item, err := NewDecoderWithOptions(io.NewSectionReader(r.f, offset, r.size-offset), r.options).Decode()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return item, err
+}
+
+// HasIndex returns true if the sequence file has an index
+func (r *SeqReader) HasIndex() bool {
+	return r.keys != nil
+}
+
+// Lookup returns the data item recorded under the key in the index
+func (r *SeqReader) Lookup(key uint64) (DataItem, error) {
+	offset, found := r.keys[key]
+	if !found {
+		return nil, errors.NewMessageErrorf(errors.ErrCborSequenceKeyNotFound, "Key [%d] is not in the index", key)
+	}
+	return r.ReadAt(offset)
+}
+
+// Close closes the sequence file
+func (r *SeqReader) Close() error {
+	return r.f.Close()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Read counts the bytes read
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// checkSeqIndexSize returns an error if the index file ends with a partial record
+func checkSeqIndexSize(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size()%seqIndexRecordSize != 0 {
+		return errors.NewMessageErrorf(errors.ErrCborSequenceIndexInvalid,
+			"Index [%s] has a partial record", f.Name())
+	}
+	return nil
+}
+
+// readSeqIndex returns the offsets of the keys in the index file, checking
+// that they are within a sequence file of the given size
+func readSeqIndex(path string, size int64) (map[uint64]int64, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := checkSeqIndexSize(f); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[uint64]int64, len(data)/seqIndexRecordSize)
+	for i := 0; i < len(data); i += seqIndexRecordSize {
+		offset := binary.BigEndian.Uint64(data[i+8:])
+		if offset >= uint64(size) {
+			return nil, errors.NewMessageErrorf(errors.ErrCborSequenceIndexInvalid,
+				"Index [%s] refers to offset [%d] beyond the end of the sequence", path, offset)
+		}
+		keys[binary.BigEndian.Uint64(data[i:])] = int64(offset)
+	}
+	return keys, nil
+}
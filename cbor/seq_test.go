@@ -0,0 +1,163 @@
+package cbor
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeqWriteRead(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "blocks.cborseq")
+	diagnostics := []string{"[1, h'ab']", "[_ 2_1, {_ }]", `"three"`}
+	items := make([]DataItem, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		item, err := ParseDiagnostic(diagnostic)
+		if !assert.Nil(t, err, diagnostic) {
+			return
+		}
+		items = append(items, item)
+	}
+
+	// write in two sessions to check that the file is appended to
+	w, err := OpenSeqWriter(path, true)
+	if !assert.Nil(t, err) {
+		return
+	}
+	offset, err := w.WriteIndexed(100, items[0])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), offset)
+	offset, err = w.Write(items[1])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(items[0].Raw())), offset)
+	assert.Nil(t, w.Close())
+
+	w, err = OpenSeqWriter(path, true)
+	if !assert.Nil(t, err) {
+		return
+	}
+	offset, err = w.WriteIndexed(300, items[2])
+	assert.Nil(t, err)
+	assert.Equal(t, int64(len(items[0].Raw())+len(items[1].Raw())), offset)
+	assert.Nil(t, w.Sync())
+	assert.Nil(t, w.Close())
+
+	// the file is the concatenation of the encodings as received
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	expected, err := EncodeListWithOptions(items, EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, data)
+	decoded, err := Decode(data)
+	assert.Nil(t, err)
+	assert.Equal(t, len(items), len(decoded))
+
+	r, err := OpenSeqReader(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer r.Close()
+	assert.True(t, r.HasIndex())
+
+	var offsets []int64
+	for i := 0; ; i++ {
+		item, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, diagnostics[i], Diagnostic(item))
+		offsets = append(offsets, r.Offset())
+	}
+	assert.Equal(t, []int64{0, 4, 11}, offsets)
+
+	item, err := r.ReadAt(4)
+	if assert.Nil(t, err) {
+		assert.Equal(t, diagnostics[1], Diagnostic(item))
+	}
+	_, err = r.ReadAt(int64(len(data)))
+	assert.Equal(t, io.EOF, err)
+
+	item, err = r.Lookup(300)
+	if assert.Nil(t, err) {
+		assert.Equal(t, diagnostics[2], Diagnostic(item))
+	}
+	_, err = r.Lookup(200)
+	assert.Equal(t, errors.ErrCborSequenceKeyNotFound, errorCode(err))
+}
+
+func TestSeqWithoutIndex(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "items.cborseq")
+
+	w, err := OpenSeqWriter(path, false)
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = w.Write(NewPositiveInteger(1))
+	assert.Nil(t, err)
+	_, err = w.WriteIndexed(1, NewPositiveInteger(2))
+	assert.Equal(t, errors.ErrCborSequenceIndexInvalid, errorCode(err))
+	assert.Nil(t, w.Close())
+
+	_, err = os.Stat(path + SeqIndexSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	r, err := OpenSeqReader(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer r.Close()
+	assert.False(t, r.HasIndex())
+	_, err = r.Lookup(1)
+	assert.Equal(t, errors.ErrCborSequenceKeyNotFound, errorCode(err))
+}
+
+func TestSeqTruncated(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "truncated.cborseq")
+	assert.Nil(t, ioutil.WriteFile(path, []byte{0x01, 0x82, 0x01}, 0644))
+
+	r, err := OpenSeqReader(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer r.Close()
+
+	_, err = r.Next()
+	assert.Nil(t, err)
+	_, err = r.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = r.ReadAt(1)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestSeqInvalidIndex(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "invalid.cborseq")
+	assert.Nil(t, ioutil.WriteFile(path, []byte{0x01}, 0644))
+
+	// a partial record
+	assert.Nil(t, ioutil.WriteFile(path+SeqIndexSuffix, make([]byte, 15), 0644))
+	_, err := OpenSeqReader(path)
+	assert.Equal(t, errors.ErrCborSequenceIndexInvalid, errorCode(err))
+	_, err = OpenSeqWriter(path, true)
+	assert.Equal(t, errors.ErrCborSequenceIndexInvalid, errorCode(err))
+
+	// an offset beyond the end of the sequence
+	record := []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 1}
+	assert.Nil(t, ioutil.WriteFile(path+SeqIndexSuffix, record, 0644))
+	_, err = OpenSeqReader(path)
+	assert.Equal(t, errors.ErrCborSequenceIndexInvalid, errorCode(err))
+
+	_, err = OpenSeqReader(filepath.Join(dir, "missing.cborseq"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -8,11 +8,21 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// DataItemSorter provides functions to sort list of dataItems
+// DataItemSorter provides functions to sort list of dataItems.  Integers, byte
+// strings and text strings are ordered by value within their major type; see
+// DeterministicSorter for the order of the deterministic encoding.
 type DataItemSorter struct {
 	dataItems []DataItem
 }
 
+// DeterministicSorter sorts a list of data items as by Compare, the map key
+// order of the core deterministic encoding (RFC 8949 section 4.2.1), which
+// works across all major types
+type DeterministicSorter struct {
+	dataItems []DataItem
+	encoded   [][]byte
+}
+
 // NewDataItemSorter returns data item sorter
 func NewDataItemSorter(dataItems []DataItem) *DataItemSorter {
 	return &DataItemSorter{
@@ -59,3 +69,34 @@ func (sorter *DataItemSorter) Less(i, j int) bool {
 	// TBD: need to handle for other major types
 	return false
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewDeterministicSorter returns a sorter in the order of the deterministic
+// encoding
+func NewDeterministicSorter(dataItems []DataItem) *DeterministicSorter {
+	encoded := make([][]byte, len(dataItems))
+	for i, item := range dataItems {
+		encoded[i] = deterministicEncoding(item)
+	}
+	return &DeterministicSorter{
+		dataItems: dataItems,
+		encoded:   encoded,
+	}
+}
+
+// Len returns the size of the array
+func (sorter *DeterministicSorter) Len() int {
+	return len(sorter.dataItems)
+}
+
+// Swap exchanges the position of two items in the array
+func (sorter *DeterministicSorter) Swap(i, j int) {
+	sorter.dataItems[i], sorter.dataItems[j] = sorter.dataItems[j], sorter.dataItems[i]
+	sorter.encoded[i], sorter.encoded[j] = sorter.encoded[j], sorter.encoded[i]
+}
+
+// Less returns if the encoding of the item sorts before the other
+func (sorter *DeterministicSorter) Less(i, j int) bool {
+	return bytes.Compare(sorter.encoded[i], sorter.encoded[j]) < 0
+}
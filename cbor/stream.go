@@ -0,0 +1,191 @@
+package cbor
+
+import (
+	"io"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+const (
+	// minimum number of bytes requested from the reader on each read
+	defaultDecoderReadSize = 4096
+
+	// number of reads returning no data and no error before giving up, as in bufio
+	maxConsecutiveEmptyReads = 100
+)
+
+// Decoder reads CBOR data items one at a time from an input stream
+type Decoder struct {
+	r       io.Reader
+	buf     []byte
+	scanner scanner
+	err     error
+	options DecodeOptions
+}
+
+// Encoder writes CBOR data items to an output stream
+type Encoder struct {
+	w       io.Writer
+	options EncodeOptions
+}
+
+// NewDecoder returns a decoder reading from r.  The decoder buffers data from
+// r and may read past the end of the data item it returns.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, DecodeOptions{})
+}
+
+// NewDecoderWithOptions returns a decoder reading from r with the given options
+func NewDecoderWithOptions(r io.Reader, options DecodeOptions) *Decoder {
+	return &Decoder{
+		r:       r,
+		options: options,
+	}
+}
+
+// Decode returns the next data item from the stream.  It reads only as much
+// as is needed to complete the data item, so it can be used on a connection
+// where data arrives in segments.
+//
+// The errors returned distinguish the following situations:
+//
+//   - io.EOF: the stream ended cleanly between data items
+//   - io.ErrUnexpectedEOF: the stream ended in the middle of a data item
+//   - any other error: the data is malformed, or the reader failed
+func (d *Decoder) Decode() (DataItem, error) {
+
+	for {
+		end, complete, err := d.scanner.scan(d.buf)
+		if err != nil {
+			return nil, rescanError(d.buf, d.options, err)
+		}
+
+		if complete {
+			if err := d.options.checkTotalBytes(end); err != nil {
+				return nil, err
+			}
+			item, err := newDecoder(d.buf[:end], d.options).decodeNext()
+			d.buf = d.buf[end:]
+			d.scanner.reset()
+			return item, err
+		}
+
+		// stop buffering a data item that can no longer fit the limit
+		if err := d.options.checkTotalBytes(len(d.buf)); err != nil {
+			return nil, err
+		}
+
+		if d.err != nil {
+			if d.err == io.EOF && len(d.buf) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, d.err
+		}
+
+		d.fill()
+	}
+}
+
+// Buffered returns the bytes read from the stream that have not been decoded yet
+func (d *Decoder) Buffered() []byte {
+	return d.buf
+}
+
+// fill reads more data from the reader into the buffer.  A reader returning
+// no data and no error too many times in a row fails with io.ErrNoProgress.
+func (d *Decoder) fill() {
+
+	if cap(d.buf)-len(d.buf) < defaultDecoderReadSize {
+		// Allocate a new buffer instead of compacting the existing one, since
+		// decoded data items may still reference slices of it.
+		size := 2 * cap(d.buf)
+		if size < len(d.buf)+defaultDecoderReadSize {
+			size = len(d.buf) + defaultDecoderReadSize
+		}
+		buf := make([]byte, len(d.buf), size)
+		copy(buf, d.buf)
+		d.buf = buf
+	}
+
+	for i := 0; i < maxConsecutiveEmptyReads; i++ {
+		n, err := d.r.Read(d.buf[len(d.buf):cap(d.buf)])
+		d.buf = d.buf[:len(d.buf)+n]
+		if err != nil {
+			d.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	d.err = io.ErrNoProgress
+}
+
+// DecodeFirst decodes the first data item in data and returns it together with
+// the remaining bytes.  If data ends before the data item is complete, the
+// error has the code ErrCborIncompleteDataItem, which allows callers receiving
+// data in segments to tell "need more bytes" apart from malformed data.
+func DecodeFirst(data []byte) (DataItem, []byte, error) {
+	return DecodeFirstWithOptions(data, DecodeOptions{})
+}
+
+// DecodeFirstWithOptions decodes the first data item in data (see DecodeFirst)
+// with the given options
+func DecodeFirstWithOptions(data []byte, options DecodeOptions) (DataItem, []byte, error) {
+
+	var s scanner
+	end, complete, err := s.scan(data)
+	if err != nil {
+		return nil, data, rescanError(data, options, err)
+	}
+	if !complete {
+		if err := options.checkTotalBytes(len(data)); err != nil {
+			return nil, data, err
+		}
+		return nil, data, errors.NewMessageErrorf(errors.ErrCborIncompleteDataItem,
+			"Data item incomplete after [%d] bytes", len(data))
+	}
+	if err := options.checkTotalBytes(end); err != nil {
+		return nil, data, err
+	}
+
+	item, err := newDecoder(data[:end], options).decodeNext()
+	if err != nil {
+		return nil, data, err
+	}
+	return item, data[end:], nil
+}
+
+// NewEncoder returns an encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w: w,
+	}
+}
+
+// NewEncoderWithOptions returns an encoder writing to w in the given mode
+func NewEncoderWithOptions(w io.Writer, options EncodeOptions) *Encoder {
+	return &Encoder{
+		w:       w,
+		options: options,
+	}
+}
+
+// Encode writes the CBOR representation of the data item to the stream
+func (e *Encoder) Encode(item DataItem) error {
+	data, err := EncodeWithOptions(item, e.options)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+// EncodeValue marshals v (see Marshal) and writes it to the stream
+func (e *Encoder) EncodeValue(v interface{}) error {
+	item, err := MarshalDataItem(v)
+	if err != nil {
+		return err
+	}
+	return e.Encode(item)
+}
@@ -0,0 +1,165 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoderMultipleItems(t *testing.T) {
+
+	// 1, "a", [1, [2]], {1: h'01'}, tag(2, h'01'), [_ 1, 2], (_ h'01', h'02')
+	data := []byte{
+		0x01,
+		0x61, 0x61,
+		0x82, 0x01, 0x81, 0x02,
+		0xa1, 0x01, 0x41, 0x01,
+		0xc2, 0x41, 0x01,
+		0x9f, 0x01, 0x02, 0xff,
+		0x5f, 0x41, 0x01, 0x41, 0x02, 0xff,
+	}
+
+	readers := map[string]io.Reader{
+		"whole":   bytes.NewReader(data),
+		"onebyte": iotest.OneByteReader(bytes.NewReader(data)),
+		"halves":  iotest.HalfReader(bytes.NewReader(data)),
+	}
+
+	for name, r := range readers {
+		decoder := NewDecoder(r)
+
+		var items []DataItem
+		for {
+			item, err := decoder.Decode()
+			if err == io.EOF {
+				break
+			}
+			assert.Nil(t, err, name)
+			if err != nil {
+				break
+			}
+			items = append(items, item)
+		}
+
+		assert.Equal(t, 7, len(items), name)
+		if len(items) == 7 {
+			// indefinite length items are re-encoded with a definite length
+			assert.Equal(t, data[:14], EncodeList(items[:5]), name)
+			assert.Equal(t, 2, items[5].(*Array).Length(), name)
+			assert.Equal(t, []byte{0x01, 0x02}, items[6].Value(), name)
+		}
+	}
+}
+
+func TestDecoderUnexpectedEOF(t *testing.T) {
+
+	decoder := NewDecoder(bytes.NewReader([]byte{0x01, 0x82, 0x01}))
+
+	item, err := decoder.Decode()
+	assert.Nil(t, err)
+	assert.Equal(t, uint8(1), item.Value())
+
+	_, err = decoder.Decode()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+// emptyReader returns no data and no error
+type emptyReader struct{}
+
+func (emptyReader) Read(p []byte) (int, error) {
+	return 0, nil
+}
+
+func TestDecoderNoProgress(t *testing.T) {
+	_, err := NewDecoder(emptyReader{}).Decode()
+	assert.Equal(t, io.ErrNoProgress, err)
+}
+
+func TestDecoderMalformed(t *testing.T) {
+
+	testCases := [][]byte{
+		{0xff},                   // break outside of an indefinite length item
+		{0x1c},                   // reserved additional type
+		{0x5f, 0x61, 0x61, 0xff}, // text string chunk inside a byte string
+		{0x5f, 0x01, 0xff},       // integer chunk inside a byte string
+		{0x82, 0x01, 0xff},       // break inside a definite length array
+	}
+
+	for _, testCase := range testCases {
+		_, err := NewDecoder(bytes.NewReader(testCase)).Decode()
+		assert.NotNil(t, err, "%x", testCase)
+		assert.NotEqual(t, io.ErrUnexpectedEOF, err, "%x", testCase)
+	}
+}
+
+func TestDecodeFirst(t *testing.T) {
+
+	item, rest, err := DecodeFirst([]byte{0x82, 0x01, 0x02, 0x03})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, item.(*Array).Length())
+	assert.Equal(t, []byte{0x03}, rest)
+
+	// every strict prefix of the data item needs more bytes
+	data := []byte{0x82, 0x19, 0x01, 0xf4, 0x43, 0x01, 0x02, 0x03}
+	for i := 0; i < len(data); i++ {
+		_, rest, err = DecodeFirst(data[:i])
+		assert.Equal(t, errors.ErrCborIncompleteDataItem, errorCode(err), "prefix %d", i)
+		assert.Equal(t, data[:i], rest)
+	}
+
+	// malformed data is reported as such, not as incomplete
+	_, _, err = DecodeFirst([]byte{0x82, 0xff})
+	assert.NotEqual(t, errors.ErrCborIncompleteDataItem, errorCode(err))
+}
+
+func TestScanner(t *testing.T) {
+
+	// the data item is scanned as its bytes are appended, then the next one
+	var s Scanner
+	data := []byte{0x82, 0x19, 0x01, 0xf4, 0x43, 0x01, 0x02, 0x03, 0x01}
+	for i := 0; i < 8; i++ {
+		_, complete, err := s.Scan(data[:i])
+		assert.Nil(t, err, "prefix %d", i)
+		assert.False(t, complete, "prefix %d", i)
+	}
+	end, complete, err := s.Scan(data)
+	assert.Nil(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, 8, end)
+
+	end, complete, err = s.Scan(data[end:])
+	assert.Nil(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, 1, end)
+
+	// malformed data is an error, after which the scanner starts over
+	_, _, err = s.Scan([]byte{0x82, 0xff})
+	assert.NotNil(t, err)
+	end, complete, err = s.Scan([]byte{0x01})
+	assert.Nil(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, 1, end)
+}
+
+func TestEncoder(t *testing.T) {
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+
+	assert.Nil(t, encoder.Encode(NewPositiveInteger8(1)))
+	assert.Nil(t, encoder.Encode(NewTextString("a")))
+	assert.Nil(t, encoder.EncodeValue([]uint{1, 2}))
+	assert.Equal(t, []byte{0x01, 0x61, 0x61, 0x82, 0x01, 0x02}, buf.Bytes())
+
+	decoder := NewDecoder(&buf)
+	for i := 0; i < 3; i++ {
+		_, err := decoder.Decode()
+		assert.Nil(t, err)
+	}
+	_, err := decoder.Decode()
+	assert.Equal(t, io.EOF, err)
+}
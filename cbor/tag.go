@@ -0,0 +1,270 @@
+package cbor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// Tag wraps a semantic tag that has no registered decoder, so that any tag
+// round-trips losslessly
+type Tag struct {
+	baseDataItem
+	Number  uint64
+	Content DataItem
+}
+
+// TagDecoder converts the decoded content of a semantic tag into a data item
+type TagDecoder func(content DataItem) (DataItem, error)
+
+// TagEncoder returns the content of the semantic tag represented by a data item.
+// It is used wherever a tagged data item is processed generically, such as in
+// the deterministic encoding modes.
+type TagEncoder func(item DataItem) (DataItem, error)
+
+// tagHandler holds the functions registered for a semantic tag
+type tagHandler struct {
+	decoder TagDecoder
+	encoder TagEncoder
+}
+
+var (
+	tagRegistryLock sync.RWMutex
+	tagRegistry     = map[uint64]tagHandler{}
+)
+
+func init() {
+	RegisterTag(semanticDateTimeString, decodeDateTimeString, encodeDateTimeString)
+	RegisterTag(semanticDateTimeEpoch, decodeDateTimeEpoch, encodeDateTimeEpoch)
+	RegisterTag(semanticPositiveBignum, decodeBignum(semanticPositiveBignum), encodeBignum)
+	RegisterTag(semanticNegativeBignum, decodeBignum(semanticNegativeBignum), encodeBignum)
+	RegisterTag(semanticURI, decodeUTF8String(semanticURI), encodeUTF8String)
+	RegisterTag(semanticBase64URL, decodeUTF8String(semanticBase64URL), encodeUTF8String)
+	RegisterTag(semanticBase64, decodeUTF8String(semanticBase64), encodeUTF8String)
+	RegisterTag(semanticRegularExpression, decodeUTF8String(semanticRegularExpression), encodeUTF8String)
+	RegisterTag(semanticMimeMessage, decodeUTF8String(semanticMimeMessage), encodeUTF8String)
+}
+
+// RegisterTag registers the functions converting between the content of a
+// semantic tag and the data item representing it, replacing any previous
+// registration for the tag.  The data items returned by decoder must report
+// MajorTypeSemantic and the tag number as AdditionalTypeValue, and encoder
+// must return the content for them.  Tags without a registered decoder are
+// decoded as a generic Tag.
+func RegisterTag(tag uint64, decoder TagDecoder, encoder TagEncoder) {
+	tagRegistryLock.Lock()
+	defer tagRegistryLock.Unlock()
+	tagRegistry[tag] = tagHandler{
+		decoder: decoder,
+		encoder: encoder,
+	}
+}
+
+// lookupTag returns the functions registered for the tag
+func lookupTag(tag uint64) (tagHandler, bool) {
+	tagRegistryLock.RLock()
+	defer tagRegistryLock.RUnlock()
+	handler, found := tagRegistry[tag]
+	return handler, found
+}
+
+// decodeTag returns the data item for a semantic tag with the decoded content
+func decodeTag(tag uint64, content DataItem) (DataItem, error) {
+	if handler, found := lookupTag(tag); found && handler.decoder != nil {
+		return handler.decoder(content)
+	}
+	return NewTag(tag, content), nil
+}
+
+// tagContent returns the content of a semantic tag data item.  The boolean is
+// false if the content is unknown because no encoder is registered for it.
+func tagContent(item DataItem) (DataItem, bool, error) {
+	if tag, ok := item.(*Tag); ok {
+		return tag.Content, true, nil
+	}
+	handler, found := lookupTag(item.AdditionalTypeValue())
+	if !found || handler.encoder == nil {
+		return nil, false, nil
+	}
+	content, err := handler.encoder(item)
+	if err != nil {
+		return nil, false, err
+	}
+	return content, true, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewTag returns a generic semantic tag with the given content
+func NewTag(number uint64, content DataItem) *Tag {
+	return &Tag{
+		baseDataItem: baseDataItem{
+			majorType: MajorTypeSemantic,
+		},
+		Number:  number,
+		Content: content,
+	}
+}
+
+// AdditionalTypeValue returns the tag number
+func (t *Tag) AdditionalTypeValue() uint64 {
+	return t.Number
+}
+
+// Value returns the content of the tag
+func (t *Tag) Value() interface{} {
+	return t.Content
+}
+
+// Raw returns the bytes this tag was decoded from, as long as its content was
+// not replaced or modified (see baseDataItem.Raw)
+func (t *Tag) Raw() []byte {
+	if t.Content == nil || !rawItemsHold(t.raw, []DataItem{t.Content}) {
+		return nil
+	}
+	return t.raw
+}
+
+// EncodeCBOR returns CBOR representation for this item
+func (t *Tag) EncodeCBOR() []byte {
+	return append(dataItemPrefix(MajorTypeSemantic, t.Number), t.Content.EncodeCBOR()...)
+}
+
+// String returns description of this item
+func (t *Tag) String() string {
+	return fmt.Sprintf("Tag - Number: [%d]; Content: [%s]", t.Number, t.Content.String())
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// decodeDateTimeString decodes the content of tag 0
+func decodeDateTimeString(content DataItem) (DataItem, error) {
+	text, ok := content.(*TextString)
+	if !ok {
+		return nil, tagContentMismatch(semanticDateTimeString, content)
+	}
+	result := NewDateTimeString(text.ValueAsString())
+	if result == nil {
+		return nil, errors.NewMessageErrorf(errors.ErrCborTypeMismatch,
+			"Invalid date/time string [%s]", text.ValueAsString())
+	}
+	return result, nil
+}
+
+// encodeDateTimeString returns the content of tag 0
+func encodeDateTimeString(item DataItem) (DataItem, error) {
+	d, ok := item.(*DateTimeString)
+	if !ok {
+		return nil, tagContentMismatch(semanticDateTimeString, item)
+	}
+	return NewTextString(d.V.Format(time.RFC3339)), nil
+}
+
+// decodeDateTimeEpoch decodes the content of tag 1
+func decodeDateTimeEpoch(content DataItem) (DataItem, error) {
+	switch value := content.(type) {
+	case *PositiveInteger8, *PositiveInteger16, *PositiveInteger32, *PositiveInteger64:
+		epoch := value.AdditionalTypeValue()
+		if epoch > math.MaxInt64 {
+			return nil, tagContentMismatch(semanticDateTimeEpoch, content)
+		}
+		return NewDateTimeEpoch(int64(epoch)), nil
+	case *NegativeInteger8:
+		return NewDateTimeEpoch(value.ValueAsInt64()), nil
+	case *NegativeInteger16:
+		return NewDateTimeEpoch(value.ValueAsInt64()), nil
+	case *NegativeInteger32:
+		return NewDateTimeEpoch(value.ValueAsInt64()), nil
+	case *NegativeInteger64:
+		return NewDateTimeEpoch(value.ValueAsInt64()), nil
+	}
+	return nil, tagContentMismatch(semanticDateTimeEpoch, content)
+}
+
+// encodeDateTimeEpoch returns the content of tag 1
+func encodeDateTimeEpoch(item DataItem) (DataItem, error) {
+	d, ok := item.(*DateTimeEpoch)
+	if !ok {
+		return nil, tagContentMismatch(semanticDateTimeEpoch, item)
+	}
+	return newInteger(d.V.Unix()), nil
+}
+
+// decodeBignum returns the decoder for the content of tag 2 or 3
+func decodeBignum(tag uint64) TagDecoder {
+	return func(content DataItem) (DataItem, error) {
+		bytes, ok := content.(*ByteString)
+		if !ok {
+			return nil, tagContentMismatch(tag, content)
+		}
+		n := new(big.Int).SetBytes(bytes.ValueAsBytes())
+		if tag == semanticPositiveBignum {
+			return NewPositiveBignumber(n), nil
+		}
+		return NewNegativeBignumber(n.Sub(big.NewInt(-1), n)), nil
+	}
+}
+
+// encodeBignum returns the content of tag 2 or 3
+func encodeBignum(item DataItem) (DataItem, error) {
+	switch n := item.(type) {
+	case *PositiveBignum:
+		return NewByteString(n.V.Bytes()), nil
+	case *NegativeBignum:
+		return NewByteString(n.encodedValue().Bytes()), nil
+	}
+	return nil, tagContentMismatch(item.AdditionalTypeValue(), item)
+}
+
+// decodeUTF8String returns the decoder for the content of tags 32 to 36
+func decodeUTF8String(tag uint64) TagDecoder {
+	return func(content DataItem) (DataItem, error) {
+		text, ok := content.(*TextString)
+		if !ok {
+			return nil, tagContentMismatch(tag, content)
+		}
+		switch tag {
+		case semanticURI:
+			return NewURI(text.ValueAsString()), nil
+		case semanticBase64URL, semanticBase64:
+			if newBaseSemanticUTF8Base64(text.ValueAsString(), tag) == nil {
+				return nil, errors.NewMessageErrorf(errors.ErrCborTypeMismatch,
+					"Invalid base64 string [%s] for semantic tag [%d]", text.ValueAsString(), tag)
+			}
+			if tag == semanticBase64URL {
+				return NewBase64URL(text.ValueAsString()), nil
+			}
+			return NewBase64String(text.ValueAsString()), nil
+		case semanticRegularExpression:
+			return NewRegularExpression(text.ValueAsString()), nil
+		}
+		return NewMimeMessage(text.ValueAsString()), nil
+	}
+}
+
+// encodeUTF8String returns the content of tags 32 to 36
+func encodeUTF8String(item DataItem) (DataItem, error) {
+	str, ok := item.Value().(string)
+	if !ok {
+		return nil, tagContentMismatch(item.AdditionalTypeValue(), item)
+	}
+	return NewTextString(str), nil
+}
+
+// tagContentMismatch returns the error for a semantic tag with content of the wrong type
+func tagContentMismatch(tag uint64, content DataItem) error {
+	return errors.NewMessageErrorf(errors.ErrCborTypeMismatch,
+		"Unexpected %s content for semantic tag [%d]", content.MajorType(), tag)
+}
+
+// newInteger returns the most compact integer data item for the value
+func newInteger(value int64) DataItem {
+	if value < 0 {
+		return NewNegativeInteger(value)
+	}
+	return NewPositiveInteger(uint64(value))
+}
@@ -0,0 +1,91 @@
+package cbor
+
+import (
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSet represents the tag 258 set used by the ledger
+type testSet struct {
+	Tag
+}
+
+func TestTagUnknownRoundTrip(t *testing.T) {
+
+	testCases := [][]byte{
+		// 258([1, 2])
+		{0xd9, 0x01, 0x02, 0x82, 0x01, 0x02},
+		// 121([]) (plutus constructor 0)
+		{0xd8, 0x79, 0x80},
+		// 1280(h'01')
+		{0xd9, 0x05, 0x00, 0x41, 0x01},
+		// 55799(1) (self-described CBOR)
+		{0xd9, 0xd9, 0xf7, 0x01},
+	}
+
+	for _, testCase := range testCases {
+		items, err := Decode(testCase)
+		assert.Nil(t, err, "%x", testCase)
+		tag, ok := items[0].(*Tag)
+		if assert.True(t, ok, "%x", testCase) {
+			assert.Equal(t, tag.Number, tag.AdditionalTypeValue())
+			assert.Equal(t, testCase, tag.EncodeCBOR())
+		}
+	}
+}
+
+func TestTagBuiltinsAreRegistered(t *testing.T) {
+
+	items, err := Decode([]byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0, 0xd8, 0x20, 0x61, 0x61})
+	assert.Nil(t, err)
+	assert.IsType(t, &DateTimeEpoch{}, items[0])
+	assert.IsType(t, &URI{}, items[1])
+
+	// content of the wrong type
+	_, err = Decode([]byte{0xc2, 0x01})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+	}
+}
+
+func TestRegisterTag(t *testing.T) {
+
+	RegisterTag(258,
+		func(content DataItem) (DataItem, error) {
+			if _, ok := content.(*Array); !ok {
+				return nil, errors.NewError(errors.ErrCborTypeMismatch)
+			}
+			return &testSet{Tag: *NewTag(258, content)}, nil
+		},
+		func(item DataItem) (DataItem, error) {
+			return item.(*testSet).Content, nil
+		})
+	defer RegisterTag(258, nil, nil)
+
+	// 258([{2: 0, 1: 0}])
+	data := []byte{0xd9, 0x01, 0x02, 0x81, 0xa2, 0x02, 0x00, 0x01, 0x00}
+
+	items, err := Decode(data)
+	assert.Nil(t, err)
+	set, ok := items[0].(*testSet)
+	if assert.True(t, ok) {
+		assert.Equal(t, 1, set.Content.(*Array).Length())
+	}
+
+	// the content of registered tags is encoded deterministically
+	actual, err := EncodeWithOptions(items[0], EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xd9, 0x01, 0x02, 0x81, 0xa2, 0x01, 0x00, 0x02, 0x00}, actual)
+
+	// errors from the registered decoder are returned
+	_, err = Decode([]byte{0xd9, 0x01, 0x02, 0x01})
+	assert.NotNil(t, err)
+
+	// without a decoder, the tag is generic again
+	RegisterTag(258, nil, nil)
+	items, err = Decode(data)
+	assert.Nil(t, err)
+	assert.IsType(t, &Tag{}, items[0])
+}
@@ -11,3 +11,17 @@ func NewTextString(value string) *TextString {
 		baseByteString: newBaseByteString(MajorTypeTextString, []byte(value)),
 	}
 }
+
+// NewChunkedTextString returns a new indefinite length text string made of the
+// chunks, which is encoded as such
+func NewChunkedTextString(chunks ...string) *TextString {
+	value := []byte{}
+	lengths := make([]uint64, 0, len(chunks))
+	for _, chunk := range chunks {
+		value = append(value, chunk...)
+		lengths = append(lengths, uint64(len(chunk)))
+	}
+	return &TextString{
+		baseByteString: newChunkedBaseByteString(MajorTypeTextString, value, lengths),
+	}
+}
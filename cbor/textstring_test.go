@@ -50,7 +50,7 @@ func TestTextString(t *testing.T) {
 		},
 		{
 			scenario:    "additionalType == 31 (indefinite length); pattern: [ignore 3 bits, 5 bits length, ... until 0xFF break code",
-			input:       []byte{0x7f, 0x44, 0x61, 0x61, 0x61, 0x61, 0x43, 0x61, 0x61, 0x61, 0xff},
+			input:       []byte{0x7f, 0x64, 0x61, 0x61, 0x61, 0x61, 0x63, 0x61, 0x61, 0x61, 0xff},
 			expectValue: "aaaaaaa",
 		},
 	}
@@ -63,3 +63,23 @@ func TestTextString(t *testing.T) {
 		assert.Equal(t, testCase.expectValue, c[0].Value(), "incorrect value")
 	}
 }
+
+func TestChunkedTextString(t *testing.T) {
+
+	s := NewChunkedTextString("a", "bc")
+	assert.True(t, s.IsIndefinite())
+	assert.Equal(t, "abc", s.ValueAsString())
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("bc")}, s.Chunks())
+	assert.Equal(t, []byte{0x7f, 0x61, 0x61, 0x62, 0x62, 0x63, 0xff}, s.EncodeCBOR())
+	assert.Equal(t, `(_ "a", "bc")`, Diagnostic(s))
+
+	actual, err := EncodeWithOptions(s, EncodeOptions{Mode: EncodeModeCoreDeterministic})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x63, 0x61, 0x62, 0x63}, actual)
+
+	c, err := Decode(s.EncodeCBOR())
+	if assert.Nil(t, err) {
+		assert.True(t, c[0].(*TextString).IsIndefinite())
+		assert.Equal(t, s.EncodeCBOR(), c[0].EncodeCBOR())
+	}
+}
@@ -50,6 +50,9 @@ const (
 	primitiveBreakStopCode        uint8 = 31
 
 	indefiniteBreakCode = 0xff
+
+	// halfPrecisionQuietNaN is the bits of the NaN used by deterministic encodings
+	halfPrecisionQuietNaN uint16 = 0x7e00
 )
 
 const (
@@ -63,6 +66,7 @@ const (
 	semanticExpectedConversionToBase64    uint64 = 22
 	semanticExpectedConversionToBase16    uint64 = 23
 	semanticEncodedCBORDataItems          uint64 = 24
+	semanticRational                      uint64 = 30
 	semanticURI                           uint64 = 32
 	semanticBase64URL                     uint64 = 33
 	semanticBase64                        uint64 = 34
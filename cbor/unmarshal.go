@@ -0,0 +1,402 @@
+package cbor
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// Unmarshaler is implemented by types that can populate themselves from a data item
+type Unmarshaler interface {
+	UnmarshalCBOR(item DataItem) error
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Unmarshal decodes the CBOR encoded data and stores the result in the value
+// pointed to by v.  The data must contain exactly one data item.  The mapping
+// between data items and Go values is the inverse of Marshal.  When decoding
+// into an empty interface, integers are stored as uint64/int64 (or *big.Int),
+// floats as float64, arrays as []interface{} and maps as
+// map[interface{}]interface{}.
+//
+// Unmarshal decodes with the default DecodeOptions, so like Decode it is
+// unsafe for untrusted data; decode that with DecodeWithOptions and
+// DecodeOptions{Hardened: true}, then use UnmarshalDataItem.
+func Unmarshal(data []byte, v interface{}) error {
+
+	items, err := Decode(data)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(items) == 0:
+		return errors.NewError(errors.ErrBitstreamReaderEOF)
+	case len(items) > 1:
+		return errors.NewMessageErrorf(errors.ErrCborExtraneousData, "Expected 1 data item, found [%d]", len(items))
+	}
+
+	return UnmarshalDataItem(items[0], v)
+}
+
+// UnmarshalDataItem stores the data item in the value pointed to by v (see Unmarshal)
+func UnmarshalDataItem(item DataItem, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.NewMessageErrorf(errors.ErrCborInvalidUnmarshalTarget, "Invalid target [%T]", v)
+	}
+	return unmarshalValue(item, rv.Elem())
+}
+
+// unmarshalValue stores the data item into the settable reflected value
+func unmarshalValue(item DataItem, v reflect.Value) error {
+
+	// Unmarshaler is normally implemented on the pointer receiver
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshalerType) {
+		return v.Addr().Interface().(Unmarshaler).UnmarshalCBOR(item)
+	}
+
+	// data items are assigned as is, when the types allow it
+	if v.Type().Implements(dataItemType) || v.Type() == dataItemType {
+		itemValue := reflect.ValueOf(item)
+		if itemValue.Type().AssignableTo(v.Type()) {
+			v.Set(itemValue)
+			return nil
+		}
+		return mismatch(item, v.Type())
+	}
+
+	if isNilDataItem(item) {
+		return mismatch(item, v.Type())
+	}
+
+	if isNullOrUndefined(item) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	if v.Type() == bigIntType {
+		n, ok := bigIntFromDataItem(item)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		v.Set(reflect.ValueOf(*n))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return unmarshalValue(item, v.Elem())
+
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch(item, v.Type())
+		}
+		value, err := naturalValue(item)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+
+	case reflect.Bool:
+		switch item.(type) {
+		case *PrimitiveTrue:
+			v.SetBool(true)
+		case *PrimitiveFalse:
+			v.SetBool(false)
+		default:
+			return mismatch(item, v.Type())
+		}
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := bigIntFromDataItem(item)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		if !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+			return overflow(n, v.Type())
+		}
+		v.SetUint(n.Uint64())
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := bigIntFromDataItem(item)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		if !n.IsInt64() || v.OverflowInt(n.Int64()) {
+			return overflow(n, v.Type())
+		}
+		v.SetInt(n.Int64())
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := floatFromDataItem(item)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		v.SetFloat(f)
+		return nil
+
+	case reflect.String:
+		s, ok := item.(*TextString)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		v.SetString(s.ValueAsString())
+		return nil
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := item.(*ByteString)
+			if !ok {
+				return mismatch(item, v.Type())
+			}
+			buf := make([]byte, len(b.ValueAsBytes()))
+			copy(buf, b.ValueAsBytes())
+			v.SetBytes(buf)
+			return nil
+		}
+		arr, ok := item.(*Array)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		slice := reflect.MakeSlice(v.Type(), arr.Length(), arr.Length())
+		for i, element := range arr.List() {
+			if err := unmarshalValue(element, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b, ok := item.(*ByteString)
+			if !ok || len(b.ValueAsBytes()) != v.Len() {
+				return mismatch(item, v.Type())
+			}
+			reflect.Copy(v, reflect.ValueOf(b.ValueAsBytes()))
+			return nil
+		}
+		arr, ok := item.(*Array)
+		if !ok || arr.Length() != v.Len() {
+			return mismatch(item, v.Type())
+		}
+		for i, element := range arr.List() {
+			if err := unmarshalValue(element, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		m, ok := item.(*Map)
+		if !ok {
+			return mismatch(item, v.Type())
+		}
+		result := reflect.MakeMapWithSize(v.Type(), m.Length())
+		for i, key := range m.keys {
+			value := m.values[i]
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := unmarshalValue(key, k); err != nil {
+				return err
+			}
+			if !k.Type().Comparable() {
+				return mismatch(key, v.Type().Key())
+			}
+			val := reflect.New(v.Type().Elem()).Elem()
+			if err := unmarshalValue(value, val); err != nil {
+				return err
+			}
+			result.SetMapIndex(k, val)
+		}
+		v.Set(result)
+		return nil
+
+	case reflect.Struct:
+		return unmarshalStruct(item, v)
+	}
+
+	return errors.NewMessageErrorf(errors.ErrCborUnsupportedType, "Unable to unmarshal into Go type [%s]", v.Type())
+}
+
+// unmarshalStruct populates the struct fields from a map (or an array with one
+// item per field if tagged with toarray)
+func unmarshalStruct(item DataItem, v reflect.Value) error {
+
+	info, err := getStructInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	if info.toArray {
+		arr, ok := item.(*Array)
+		if !ok || arr.Length() != len(info.fields) {
+			return mismatch(item, v.Type())
+		}
+		for i, element := range arr.List() {
+			if err := unmarshalValue(element, v.Field(info.fields[i].index)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m, ok := item.(*Map)
+	if !ok {
+		return mismatch(item, v.Type())
+	}
+	for i, key := range m.keys {
+		value := m.values[i]
+		idx, found := info.keyIndex[string(key.EncodeCBOR())]
+		if !found {
+			// ignore unknown keys
+			continue
+		}
+		if err := unmarshalValue(value, v.Field(info.fields[idx].index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// naturalValue returns the Go value used when unmarshaling into an empty interface
+func naturalValue(item DataItem) (interface{}, error) {
+
+	switch obj := item.(type) {
+	case *Array:
+		result := make([]interface{}, 0, obj.Length())
+		for _, element := range obj.List() {
+			value, err := naturalValue(element)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+
+	case *Map:
+		result := make(map[interface{}]interface{}, obj.Length())
+		for i, key := range obj.keys {
+			element := obj.values[i]
+			k, err := naturalValue(key)
+			if err != nil {
+				return nil, err
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, errors.NewMessageErrorf(errors.ErrCborUnsupportedType,
+					"Map key [%s] cannot be used as a Go map key", key.String())
+			}
+			value, err := naturalValue(element)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = value
+		}
+		return result, nil
+
+	case *PositiveBignum, *NegativeBignum:
+		n, ok := bigIntFromDataItem(item)
+		if !ok {
+			return nil, mismatch(item, reflect.TypeOf((*interface{})(nil)).Elem())
+		}
+		return n, nil
+	}
+
+	switch item.MajorType() {
+	case MajorTypePositiveInt:
+		return item.AdditionalTypeValue(), nil
+	case MajorTypeNegativeInt:
+		n, _ := bigIntFromDataItem(item)
+		return n.Int64(), nil
+	case MajorTypeByteString:
+		return item.(*ByteString).ValueAsBytes(), nil
+	}
+
+	if f, ok := floatFromDataItem(item); ok {
+		return f, nil
+	}
+
+	return item.Value(), nil
+}
+
+// bigIntFromDataItem returns the integer value of an integer or bignum data item
+func bigIntFromDataItem(item DataItem) (*big.Int, bool) {
+	if isNilDataItem(item) {
+		return nil, false
+	}
+	switch obj := item.(type) {
+	case *PositiveBignum:
+		if obj.V == nil {
+			return nil, false
+		}
+		return new(big.Int).Set(obj.V), true
+	case *NegativeBignum:
+		if obj.V == nil {
+			return nil, false
+		}
+		return new(big.Int).Set(obj.V), true
+	case interface{ ValueAsInt64() int64 }:
+		return big.NewInt(obj.ValueAsInt64()), true
+	}
+	if item.MajorType() == MajorTypePositiveInt {
+		return new(big.Int).SetUint64(item.AdditionalTypeValue()), true
+	}
+	return nil, false
+}
+
+// floatFromDataItem returns the value of a float or integer data item as float64
+func floatFromDataItem(item DataItem) (float64, bool) {
+	if f, err := AsFloat64(item); err == nil {
+		return f, true
+	}
+	if n, ok := bigIntFromDataItem(item); ok {
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f, !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// isNullOrUndefined returns true for the null and undefined primitives
+func isNullOrUndefined(item DataItem) bool {
+	switch item.(type) {
+	case *PrimitiveNull, *PrimitiveUndefined:
+		return true
+	}
+	return false
+}
+
+// isNilDataItem returns true for a nil data item, or a nil pointer to one
+func isNilDataItem(item DataItem) bool {
+	if item == nil {
+		return true
+	}
+	v := reflect.ValueOf(item)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// mismatch returns the error for a data item that cannot be stored in the Go type
+func mismatch(item DataItem, t reflect.Type) error {
+	if isNilDataItem(item) {
+		return errors.NewMessageErrorf(errors.ErrCborTypeMismatch, "Cannot unmarshal no data item into Go type [%s]", t)
+	}
+	return errors.NewMessageErrorf(errors.ErrCborTypeMismatch, "Cannot unmarshal [%s] into Go type [%s]", item.String(), t)
+}
+
+// overflow returns the error for an integer that does not fit the Go type
+func overflow(n *big.Int, t reflect.Type) error {
+	return errors.NewMessageErrorf(errors.ErrCborIntegerOverflow, "Integer [%s] overflows Go type [%s]", n.String(), t)
+}
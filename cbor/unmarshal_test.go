@@ -0,0 +1,154 @@
+package cbor
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUnmarshaler struct {
+	value string
+}
+
+func (u *testUnmarshaler) UnmarshalCBOR(item DataItem) error {
+	u.value = item.String()
+	return nil
+}
+
+func TestUnmarshalIntegers(t *testing.T) {
+
+	var u8 uint8
+	assert.Nil(t, Unmarshal([]byte{0x18, 0xff}, &u8))
+	assert.Equal(t, uint8(255), u8)
+
+	// 256 does not fit a uint8
+	assert.NotNil(t, Unmarshal([]byte{0x19, 0x01, 0x00}, &u8))
+
+	// negative does not fit an unsigned
+	assert.NotNil(t, Unmarshal([]byte{0x20}, &u8))
+
+	var i64 int64
+	assert.Nil(t, Unmarshal([]byte{0x39, 0x03, 0xe7}, &i64))
+	assert.Equal(t, int64(-1000), i64)
+
+	var u64 uint64
+	assert.Nil(t, Unmarshal([]byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, &u64))
+	assert.Equal(t, uint64(math.MaxUint64), u64)
+
+	var n *big.Int
+	assert.Nil(t, Unmarshal([]byte{0xc2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, &n))
+	assert.Equal(t, "18446744073709551616", n.String())
+
+	var f float64
+	assert.Nil(t, Unmarshal([]byte{0xf9, 0x3c, 0x00}, &f))
+	assert.Equal(t, 1.0, f)
+}
+
+func TestUnmarshalContainers(t *testing.T) {
+
+	var list []string
+	assert.Nil(t, Unmarshal([]byte{0x82, 0x61, 0x61, 0x61, 0x62}, &list))
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	var hash [2]byte
+	assert.Nil(t, Unmarshal([]byte{0x42, 0x01, 0x02}, &hash))
+	assert.Equal(t, [2]byte{0x01, 0x02}, hash)
+
+	// length of the byte string must match the array
+	assert.NotNil(t, Unmarshal([]byte{0x41, 0x01}, &hash))
+
+	var m map[uint]bool
+	assert.Nil(t, Unmarshal([]byte{0xa2, 0x01, 0xf5, 0x02, 0xf4}, &m))
+	assert.Equal(t, map[uint]bool{1: true, 2: false}, m)
+
+	var ptr *string
+	assert.Nil(t, Unmarshal([]byte{0x61, 0x61}, &ptr))
+	assert.Equal(t, "a", *ptr)
+	assert.Nil(t, Unmarshal([]byte{0xf6}, &ptr))
+	assert.Nil(t, ptr)
+}
+
+func TestUnmarshalToArrayStruct(t *testing.T) {
+
+	var p testPoint
+	assert.Nil(t, Unmarshal([]byte{0x82, 0x01, 0x41, 0xab}, &p))
+	assert.Equal(t, uint64(1), p.Slot)
+	assert.Equal(t, []byte{0xab}, p.Hash)
+
+	// more or fewer items than fields
+	assert.NotNil(t, Unmarshal([]byte{0x83, 0x01, 0x41, 0xab, 0x01}, &p))
+	assert.NotNil(t, Unmarshal([]byte{0x81, 0x01}, &p))
+
+	// a map is not an array
+	assert.NotNil(t, Unmarshal([]byte{0xa0}, &p))
+}
+
+func TestUnmarshalNilBignum(t *testing.T) {
+
+	items := []DataItem{
+		(*PositiveBignum)(nil),
+		(*NegativeBignum)(nil),
+		&PositiveBignum{},
+		&NegativeBignum{},
+	}
+
+	for _, item := range items {
+		var n big.Int
+		var i int64
+		var u uint64
+		var v interface{}
+		for _, target := range []interface{}{&n, &i, &u, &v} {
+			err := UnmarshalDataItem(item, target)
+			assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err), "%T %T", item, target)
+		}
+	}
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+
+	var v interface{}
+	assert.Nil(t, Unmarshal([]byte{0x83, 0x01, 0x20, 0xa1, 0x61, 0x61, 0x42, 0x01, 0x02}, &v))
+	assert.Equal(t, []interface{}{
+		uint64(1),
+		int64(-1),
+		map[interface{}]interface{}{"a": []byte{0x01, 0x02}},
+	}, v)
+}
+
+func TestUnmarshalDataItemsAndUnmarshaler(t *testing.T) {
+
+	var target struct {
+		_      struct{} `cbor:",toarray"`
+		Header DataItem
+		Body   *Array
+		Custom testUnmarshaler
+	}
+
+	assert.Nil(t, Unmarshal([]byte{0x83, 0x01, 0x80, 0x20}, &target))
+	assert.Equal(t, uint8(1), target.Header.Value())
+	assert.Equal(t, 0, target.Body.Length())
+	assert.Equal(t, "NegativeInteger(-1)", target.Custom.value)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+
+	var s string
+
+	// not a pointer
+	assert.NotNil(t, Unmarshal([]byte{0x61, 0x61}, s))
+
+	// nil pointer
+	assert.NotNil(t, Unmarshal([]byte{0x61, 0x61}, (*string)(nil)))
+
+	// type mismatch
+	assert.NotNil(t, Unmarshal([]byte{0x01}, &s))
+
+	// more than one data item
+	assert.NotNil(t, Unmarshal([]byte{0x61, 0x61, 0x01}, &s))
+
+	// no data item
+	assert.NotNil(t, Unmarshal([]byte{}, &s))
+}
@@ -3,6 +3,7 @@ package cbor
 import (
 	"fmt"
 	"math"
+	"strings"
 )
 
 const (
@@ -13,36 +14,30 @@ const (
 // Debug string representation for a CBOR encoded data item
 func Debug(leadingSpace int, obj DataItem) string {
 
-	switch obj.MajorType() {
-	case MajorTypePositiveInt,
-		MajorTypeNegativeInt,
-		MajorTypeByteString,
-		MajorTypeTextString,
-		MajorTypeSemantic,
-		MajorTypePrimitive:
-		return whitespace(leadingSpace) + obj.String() + newline
+	var sb strings.Builder
+	Walk(obj, func(path WalkPath, item DataItem) error {
 
-	case MajorTypeArray:
-		contents := ""
-		for _, item := range obj.(*Array).V {
-			contents += Debug(leadingSpace+indent, item)
+		// map values are indented below their key
+		depth := leadingSpace
+		for _, step := range path {
+			depth += indent
+			if step.Kind == StepKey {
+				depth += indent
+			}
 		}
-		return whitespace(leadingSpace) + obj.String() + newline + contents
 
-	case MajorTypeMap:
-		contents := ""
-		count := 0
-		m := obj.(*Map).ValueAsMap()
-		for key, value := range m {
-			contents += fmt.Sprintf("%s- key: %+v / value: %+v\n", whitespace(leadingSpace+indent), key, value)
-			contents += Debug(leadingSpace+indent+indent, value)
-			count++
+		if len(path) > 0 && path[len(path)-1].Kind == StepMapKey {
+			sb.WriteString(fmt.Sprintf("%s- key: %s\n", whitespace(depth), Diagnostic(item)))
+			return SkipSubtree
 		}
-		return whitespace(leadingSpace) + obj.String() + newline + contents
 
-	default:
-		return fmt.Sprintf("ERROR, unhandled major type %+v", obj.MajorType())
-	}
+		sb.WriteString(whitespace(depth) + item.String() + newline)
+		if item.MajorType() == MajorTypeSemantic {
+			return SkipSubtree
+		}
+		return nil
+	})
+	return sb.String()
 }
 
 // DebugList return debug string for each item in the list
@@ -0,0 +1,256 @@
+package cbor
+
+import (
+	e "errors"
+	"fmt"
+	"strings"
+
+	"github.com/gocardano/go-cardano-client/errors"
+)
+
+// StepKind is the kind of a step of a WalkPath
+type StepKind uint8
+
+// Steps from a data item to the data items it contains
+const (
+	// StepIndex leads to the element at Index of an array
+	StepIndex StepKind = iota
+
+	// StepKey leads to the value of the map entry with Key
+	StepKey
+
+	// StepMapKey leads to the key of the map entry at Index
+	StepMapKey
+
+	// StepTag leads to the content of semantic tag number Tag
+	StepTag
+)
+
+// PathStep is one step of a WalkPath
+type PathStep struct {
+	Kind  StepKind
+	Index int
+	Key   DataItem
+	Tag   uint64
+}
+
+// WalkPath leads from the data item given to Walk or Transform to one of the
+// data items it contains.  It is empty for the top level data item.
+type WalkPath []PathStep
+
+// WalkFunc is called by Walk for each data item.  Returning SkipSubtree skips
+// the data items contained in item, and any other error stops the walk.
+type WalkFunc func(path WalkPath, item DataItem) error
+
+// TransformFunc is called by Transform for each data item, and returns the
+// data item replacing it: item itself to keep it, or nil to remove it from
+// the array or map containing it.
+type TransformFunc func(path WalkPath, item DataItem) (DataItem, error)
+
+// SkipSubtree is returned by a WalkFunc to skip the data items contained in
+// the current data item
+var SkipSubtree = e.New("skip subtree")
+
+////////////////////////////////////////////////////////////////////////////////
+
+// String returns the step in the syntax of Query: [i], {k} or (n).  The key
+// of a map entry is written {#i}, as in a DecodeError, which Query does not
+// accept.
+func (s PathStep) String() string {
+	switch s.Kind {
+	case StepIndex:
+		return fmt.Sprintf("[%d]", s.Index)
+	case StepKey:
+		return "{" + Diagnostic(s.Key) + "}"
+	case StepMapKey:
+		return fmt.Sprintf("{#%d}", s.Index)
+	case StepTag:
+		return fmt.Sprintf("(%d)", s.Tag)
+	}
+	return fmt.Sprintf("<unknown step kind %d>", s.Kind)
+}
+
+// String returns the path in the syntax of Query (see PathStep.String)
+func (p WalkPath) String() string {
+	var sb strings.Builder
+	for _, step := range p {
+		sb.WriteString(step.String())
+	}
+	return sb.String()
+}
+
+// append returns a copy of the path with the step appended, so that a
+// WalkFunc can keep the path it is given
+func (p WalkPath) append(step PathStep) WalkPath {
+	path := make(WalkPath, len(p), len(p)+1)
+	copy(path, p)
+	return append(path, step)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Walk calls fn for the data item and every data item it contains, depth
+// first: the elements of arrays, the key then the value of each map entry,
+// and the content of semantic tags that have one (see RegisterTag).  The
+// error returned by fn, other than SkipSubtree, is returned by Walk.
+func Walk(item DataItem, fn WalkFunc) error {
+	if item == nil {
+		return nil
+	}
+	err := walk(nil, item, fn)
+	if err == SkipSubtree {
+		return nil
+	}
+	return err
+}
+
+// walk visits the data item at the path
+func walk(path WalkPath, item DataItem, fn WalkFunc) error {
+
+	if err := fn(path, item); err != nil {
+		return err
+	}
+
+	switch obj := item.(type) {
+	case *Array:
+		for i, element := range obj.V {
+			if err := walkChild(path, PathStep{Kind: StepIndex, Index: i}, element, fn); err != nil {
+				return err
+			}
+		}
+
+	case *Map:
+		for i, key := range obj.keys {
+			if err := walkChild(path, PathStep{Kind: StepMapKey, Index: i}, key, fn); err != nil {
+				return err
+			}
+			if err := walkChild(path, PathStep{Kind: StepKey, Key: key}, obj.values[i], fn); err != nil {
+				return err
+			}
+		}
+
+	default:
+		if item.MajorType() != MajorTypeSemantic {
+			return nil
+		}
+		content, known, err := tagContent(item)
+		if err != nil {
+			return err
+		}
+		if known {
+			step := PathStep{Kind: StepTag, Tag: item.AdditionalTypeValue()}
+			return walkChild(path, step, content, fn)
+		}
+	}
+
+	return nil
+}
+
+// walkChild visits a data item contained in the data item at the path
+func walkChild(path WalkPath, step PathStep, item DataItem, fn WalkFunc) error {
+	err := walk(path.append(step), item, fn)
+	if err == SkipSubtree {
+		return nil
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// Transform returns a copy of the data item with each data item replaced by
+// the result of fn.  The data items are visited in the order of Walk, but fn
+// is called for a data item after the data items it contains, with them
+// already replaced.  Arrays, maps and semantic tags are rebuilt only if a
+// data item they contain was replaced, so unchanged parts keep their Raw
+// bytes.  Rebuilt arrays and maps keep their definite or indefinite length,
+// a rebuilt semantic tag goes through its registered decoder again, and a map
+// entry with a replaced key takes the place of an existing entry with an
+// equal key.
+func Transform(item DataItem, fn TransformFunc) (DataItem, error) {
+	if item == nil {
+		return nil, nil
+	}
+	return transform(nil, item, fn)
+}
+
+// transform replaces the data item at the path
+func transform(path WalkPath, item DataItem, fn TransformFunc) (DataItem, error) {
+
+	switch obj := item.(type) {
+	case *Array:
+		var elements []DataItem
+		for i, element := range obj.V {
+			replaced, err := transform(path.append(PathStep{Kind: StepIndex, Index: i}), element, fn)
+			if err != nil {
+				return nil, err
+			}
+			if replaced != element && elements == nil {
+				elements = make([]DataItem, i, len(obj.V))
+				copy(elements, obj.V[:i])
+			}
+			if elements != nil && replaced != nil {
+				elements = append(elements, replaced)
+			}
+		}
+		if elements != nil {
+			array := NewArrayWithItems(elements)
+			array.indefinite = obj.indefinite
+			item = array
+		}
+
+	case *Map:
+		changed := false
+		keys := make([]DataItem, len(obj.keys))
+		values := make([]DataItem, len(obj.values))
+		for i, key := range obj.keys {
+			var err error
+			keys[i], err = transform(path.append(PathStep{Kind: StepMapKey, Index: i}), key, fn)
+			if err != nil {
+				return nil, err
+			}
+			values[i], err = transform(path.append(PathStep{Kind: StepKey, Key: key}), obj.values[i], fn)
+			if err != nil {
+				return nil, err
+			}
+			changed = changed || keys[i] != key || values[i] != obj.values[i]
+		}
+		if changed {
+			m := NewMap()
+			m.indefinite = obj.indefinite
+			for i, key := range keys {
+				if key != nil && values[i] != nil {
+					m.Add(key, values[i])
+				}
+			}
+			item = m
+		}
+
+	default:
+		if item.MajorType() != MajorTypeSemantic {
+			break
+		}
+		tag := item.AdditionalTypeValue()
+		content, known, err := tagContent(item)
+		if err != nil {
+			return nil, err
+		}
+		if !known {
+			break
+		}
+		replaced, err := transform(path.append(PathStep{Kind: StepTag, Tag: tag}), content, fn)
+		if err != nil {
+			return nil, err
+		}
+		if replaced == nil {
+			return nil, errors.NewMessageErrorf(errors.ErrCborTypeMismatch,
+				"Content of tag [%d] at path [%s] cannot be removed", tag, path.String())
+		}
+		if replaced != content {
+			if item, err = decodeTag(tag, replaced); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return fn(path, item)
+}
@@ -0,0 +1,224 @@
+package cbor
+
+import (
+	e "errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const walkTestData = `[0, {1: h'ab', "fee": [7_2]}, 258([3]), 2(h'0100')]`
+
+func TestWalk(t *testing.T) {
+
+	item := walkTestDataItem(t)
+
+	var visited []string
+	err := Walk(item, func(path WalkPath, item DataItem) error {
+		visited = append(visited, path.String()+" "+Diagnostic(item))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		" " + walkTestData,
+		"[0] 0",
+		`[1] {1: h'ab', "fee": [7_2]}`,
+		"[1]{#0} 1",
+		"[1]{1} h'ab'",
+		`[1]{#1} "fee"`,
+		`[1]{"fee"} [7_2]`,
+		`[1]{"fee"}[0] 7_2`,
+		"[2] 258([3])",
+		"[2](258) [3]",
+		"[2](258)[0] 3",
+		"[3] 2(h'0100')",
+		"[3](2) h'0100'",
+	}, visited)
+
+	// the paths without map keys are accepted by Query
+	err = Walk(item, func(path WalkPath, item DataItem) error {
+		if len(path) > 0 && path[len(path)-1].Kind == StepMapKey {
+			return nil
+		}
+		queried, err := Query(walkTestDataItem(t), path.String())
+		if assert.Nil(t, err, path.String()) {
+			assert.True(t, Equal(item, queried), path.String())
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
+func walkTestDataItem(t *testing.T) DataItem {
+	item, err := ParseDiagnostic(walkTestData)
+	assert.Nil(t, err)
+	return item
+}
+
+func TestWalkSkipAndStop(t *testing.T) {
+
+	item := walkTestDataItem(t)
+
+	var visited []string
+	err := Walk(item, func(path WalkPath, item DataItem) error {
+		visited = append(visited, path.String())
+		if item.MajorType() == MajorTypeMap || item.MajorType() == MajorTypeSemantic {
+			return SkipSubtree
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"", "[0]", "[1]", "[2]", "[3]"}, visited)
+
+	assert.Nil(t, Walk(item, func(path WalkPath, item DataItem) error {
+		return SkipSubtree
+	}))
+
+	stop := e.New("stop")
+	count := 0
+	err = Walk(item, func(path WalkPath, item DataItem) error {
+		count++
+		if item.MajorType() == MajorTypeByteString {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 5, count)
+
+	assert.Nil(t, Walk(nil, func(path WalkPath, item DataItem) error {
+		return stop
+	}))
+}
+
+func TestTransform(t *testing.T) {
+
+	item := walkTestDataItem(t)
+
+	testCases := []struct {
+		name   string
+		fn     TransformFunc
+		expect string
+	}{
+		{
+			name:   "identity",
+			fn:     func(path WalkPath, item DataItem) (DataItem, error) { return item, nil },
+			expect: walkTestData,
+		},
+		{
+			name: "redact byte strings",
+			fn: func(path WalkPath, item DataItem) (DataItem, error) {
+				if item.MajorType() == MajorTypeByteString {
+					return NewByteString([]byte{}), nil
+				}
+				return item, nil
+			},
+			expect: `[0, {1: h'', "fee": [7_2]}, 258([3]), 2(h'')]`,
+		},
+		{
+			name: "remove positive integers",
+			fn: func(path WalkPath, item DataItem) (DataItem, error) {
+				if item.MajorType() == MajorTypePositiveInt {
+					return nil, nil
+				}
+				return item, nil
+			},
+			expect: `[{"fee": []}, 258([]), 2(h'0100')]`,
+		},
+		{
+			name: "rewrite keys",
+			fn: func(path WalkPath, item DataItem) (DataItem, error) {
+				if len(path) > 0 && path[len(path)-1].Kind == StepMapKey {
+					return NewTextString(fmt.Sprintf("key%d", path[len(path)-1].Index)), nil
+				}
+				return item, nil
+			},
+			expect: `[0, {"key0": h'ab', "key1": [7_2]}, 258([3]), 2(h'0100')]`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		actual, err := Transform(item, testCase.fn)
+		if assert.Nil(t, err, testCase.name) {
+			assert.Equal(t, testCase.expect, Diagnostic(actual), testCase.name)
+		}
+	}
+
+	// the source is not modified
+	assert.Equal(t, walkTestData, Diagnostic(item))
+}
+
+func TestTransformKeepsUnchanged(t *testing.T) {
+
+	items, err := Decode([]byte{0x82, 0x9f, 0x01, 0xff, 0x82, 0x02, 0x03})
+	if !assert.Nil(t, err) {
+		return
+	}
+	array := items[0].(*Array)
+
+	actual, err := Transform(array, func(path WalkPath, item DataItem) (DataItem, error) {
+		if path.String() == "[1][1]" {
+			return NewPositiveInteger(4), nil
+		}
+		return item, nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// the indefinite length array was not rebuilt and keeps its encoding
+	assert.True(t, array.Get(0) == actual.(*Array).Get(0))
+	assert.Nil(t, actual.Raw())
+	encoded, err := EncodeWithOptions(actual, EncodeOptions{Mode: EncodeModePreserve})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x82, 0x9f, 0x01, 0xff, 0x82, 0x02, 0x04}, encoded)
+
+	same, err := Transform(array, func(path WalkPath, item DataItem) (DataItem, error) {
+		return item, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, same == DataItem(array))
+}
+
+func TestTransformTags(t *testing.T) {
+
+	item := walkTestDataItem(t)
+
+	// rebuilt tags go through their decoder again
+	actual, err := Transform(item, func(path WalkPath, item DataItem) (DataItem, error) {
+		if path.String() == "[3](2)" {
+			return NewByteString([]byte{0x02, 0x00}), nil
+		}
+		return item, nil
+	})
+	if assert.Nil(t, err) {
+		n, err := Path(actual).Index(3).BigInt()
+		assert.Nil(t, err)
+		assert.Equal(t, big.NewInt(512), n)
+	}
+
+	_, err = Transform(item, func(path WalkPath, item DataItem) (DataItem, error) {
+		if path.String() == "[3](2)" {
+			return NewTextString("x"), nil
+		}
+		return item, nil
+	})
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	_, err = Transform(item, func(path WalkPath, item DataItem) (DataItem, error) {
+		if path.String() == "[2](258)" {
+			return nil, nil
+		}
+		return item, nil
+	})
+	assert.Equal(t, errors.ErrCborTypeMismatch, errorCode(err))
+
+	stop := e.New("stop")
+	_, err = Transform(item, func(path WalkPath, item DataItem) (DataItem, error) {
+		return nil, stop
+	})
+	assert.Equal(t, stop, err)
+}
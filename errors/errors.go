@@ -32,7 +32,10 @@ const (
 	ErrSocketReadingFromSocket         = 102
 	ErrSocketReceivedInvalidHeaderSize = 103
 
-	ErrMuxHeaderInvalidSize = 201
+	ErrMuxHeaderInvalidSize         = 201
+	ErrMuxClosed                    = 202
+	ErrMuxIngressQueueLimitExceeded = 203
+	ErrMuxMiniProtocolUnexpected    = 204
 
 	ErrBitstreamReaderEOF               = 301
 	ErrBitstreamVarInsufficientCapacity = 302
@@ -42,11 +45,31 @@ const (
 	ErrCborNegativeIntsOnly                = 403
 	ErrCborUnhandledReadBytesInTermsOfBits = 404
 	ErrCborBignumParsingFailed             = 405
+	ErrCborUnsupportedType                 = 406
+	ErrCborTypeMismatch                    = 407
+	ErrCborInvalidUnmarshalTarget          = 408
+	ErrCborIntegerOverflow                 = 409
+	ErrCborExtraneousData                  = 410
+	ErrCborIncompleteDataItem              = 411
+	ErrCborDuplicateMapKey                 = 412
+	ErrCborInvalidDiagnostic               = 413
+	ErrCborInvalidJSON                     = 414
+	ErrCborMaxNestingDepthExceeded         = 415
+	ErrCborMaxArrayElementsExceeded        = 416
+	ErrCborMaxMapPairsExceeded             = 417
+	ErrCborMaxByteStringLengthExceeded     = 418
+	ErrCborMaxTotalBytesExceeded           = 419
+	ErrCborPathNotFound                    = 420
+	ErrCborInvalidPath                     = 421
+	ErrCborSequenceKeyNotFound             = 422
+	ErrCborSequenceIndexInvalid            = 423
+	ErrCborInvalidStructTag                = 424
 
 	ErrShelleyPayloadInvalid     = 501
 	ErrShelleyInvalidMessageMode = 502
 	ErrShellyUnexpectedCborItem  = 503
 	ErrShellyHandshakeFailed     = 504
+	ErrShellyKeepAliveTimeout    = 505
 )
 
 var cliErrorMap = map[int]CLIError{
@@ -75,6 +98,21 @@ var cliErrorMap = map[int]CLIError{
 		code:     ErrMuxHeaderInvalidSize,
 		desc:     "Invalid mux header size",
 	},
+	ErrMuxClosed: {
+		severity: ERROR,
+		code:     ErrMuxClosed,
+		desc:     "Multiplexer is closed",
+	},
+	ErrMuxIngressQueueLimitExceeded: {
+		severity: ERROR,
+		code:     ErrMuxIngressQueueLimitExceeded,
+		desc:     "Mini protocol ingress queue limit exceeded",
+	},
+	ErrMuxMiniProtocolUnexpected: {
+		severity: ERROR,
+		code:     ErrMuxMiniProtocolUnexpected,
+		desc:     "Segment received for an unexpected mini protocol",
+	},
 	ErrBitstreamReaderEOF: {
 		severity: ERROR,
 		code:     ErrBitstreamReaderEOF,
@@ -110,6 +148,101 @@ var cliErrorMap = map[int]CLIError{
 		code:     ErrCborBignumParsingFailed,
 		desc:     "Error converting string to bignum",
 	},
+	ErrCborUnsupportedType: {
+		severity: ERROR,
+		code:     ErrCborUnsupportedType,
+		desc:     "Go type cannot be represented as a CBOR data item",
+	},
+	ErrCborTypeMismatch: {
+		severity: ERROR,
+		code:     ErrCborTypeMismatch,
+		desc:     "CBOR data item does not match the expected type",
+	},
+	ErrCborInvalidUnmarshalTarget: {
+		severity: ERROR,
+		code:     ErrCborInvalidUnmarshalTarget,
+		desc:     "Unmarshal target must be a non-nil pointer",
+	},
+	ErrCborIntegerOverflow: {
+		severity: ERROR,
+		code:     ErrCborIntegerOverflow,
+		desc:     "CBOR integer overflows the destination type",
+	},
+	ErrCborExtraneousData: {
+		severity: ERROR,
+		code:     ErrCborExtraneousData,
+		desc:     "Unexpected data after the CBOR data item",
+	},
+	ErrCborIncompleteDataItem: {
+		severity: ERROR,
+		code:     ErrCborIncompleteDataItem,
+		desc:     "CBOR data item is incomplete, more bytes are needed",
+	},
+	ErrCborDuplicateMapKey: {
+		severity: ERROR,
+		code:     ErrCborDuplicateMapKey,
+		desc:     "CBOR map contains duplicate keys",
+	},
+	ErrCborInvalidDiagnostic: {
+		severity: ERROR,
+		code:     ErrCborInvalidDiagnostic,
+		desc:     "Invalid CBOR diagnostic notation",
+	},
+	ErrCborInvalidJSON: {
+		severity: ERROR,
+		code:     ErrCborInvalidJSON,
+		desc:     "Invalid JSON for conversion to CBOR",
+	},
+	ErrCborMaxNestingDepthExceeded: {
+		severity: ERROR,
+		code:     ErrCborMaxNestingDepthExceeded,
+		desc:     "CBOR data item exceeds the maximum nesting depth",
+	},
+	ErrCborMaxArrayElementsExceeded: {
+		severity: ERROR,
+		code:     ErrCborMaxArrayElementsExceeded,
+		desc:     "CBOR array exceeds the maximum number of elements",
+	},
+	ErrCborMaxMapPairsExceeded: {
+		severity: ERROR,
+		code:     ErrCborMaxMapPairsExceeded,
+		desc:     "CBOR map exceeds the maximum number of pairs",
+	},
+	ErrCborMaxByteStringLengthExceeded: {
+		severity: ERROR,
+		code:     ErrCborMaxByteStringLengthExceeded,
+		desc:     "CBOR string exceeds the maximum length",
+	},
+	ErrCborMaxTotalBytesExceeded: {
+		severity: ERROR,
+		code:     ErrCborMaxTotalBytesExceeded,
+		desc:     "CBOR data exceeds the maximum number of bytes",
+	},
+	ErrCborPathNotFound: {
+		severity: ERROR,
+		code:     ErrCborPathNotFound,
+		desc:     "CBOR data item not found at the path",
+	},
+	ErrCborInvalidPath: {
+		severity: ERROR,
+		code:     ErrCborInvalidPath,
+		desc:     "Invalid CBOR path",
+	},
+	ErrCborSequenceKeyNotFound: {
+		severity: ERROR,
+		code:     ErrCborSequenceKeyNotFound,
+		desc:     "Key not found in the CBOR sequence index",
+	},
+	ErrCborSequenceIndexInvalid: {
+		severity: ERROR,
+		code:     ErrCborSequenceIndexInvalid,
+		desc:     "Invalid CBOR sequence index",
+	},
+	ErrCborInvalidStructTag: {
+		severity: ERROR,
+		code:     ErrCborInvalidStructTag,
+		desc:     "Invalid cbor struct tag",
+	},
 	ErrShelleyPayloadInvalid: {
 		severity: ERROR,
 		code:     ErrShelleyPayloadInvalid,
@@ -130,6 +263,11 @@ var cliErrorMap = map[int]CLIError{
 		code:     ErrShellyHandshakeFailed,
 		desc:     "Handshake negotiation failed",
 	},
+	ErrShellyKeepAliveTimeout: {
+		severity: ERROR,
+		code:     ErrShellyKeepAliveTimeout,
+		desc:     "Keep alive response not received in time",
+	},
 }
 
 // Error string
@@ -142,6 +280,13 @@ func (e *CLIError) Code() int {
 	return e.code
 }
 
+// Is returns true if the target is a CLIError with the same code, so that
+// errors.Is matches any error of a code, such as NewError(ErrCborTypeMismatch)
+func (e *CLIError) Is(target error) bool {
+	t, ok := target.(*CLIError)
+	return ok && t != nil && e != nil && t.code == e.code
+}
+
 // Message of the error
 func (e *CLIError) Message() string {
 	if e != nil {
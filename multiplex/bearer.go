@@ -0,0 +1,161 @@
+package multiplex
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/gocardano/go-cardano-client/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	networkUnix = "unix"
+	networkTCP  = "tcp"
+)
+
+// Bearer carries the segments of the multiplexer between two peers
+type Bearer interface {
+
+	// ReadSegment reads the next segment, and returns io.EOF if the peer
+	// closed the connection between segments
+	ReadSegment() (*Header, []byte, error)
+
+	// WriteSegment writes the message as one segment
+	WriteSegment(message *Message) error
+
+	// SDUSize returns the maximum payload length of the segments to write
+	SDUSize() int
+
+	// SetReadDeadline sets the deadline of the reads, or clears it if zero
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline of the writes, or clears it if zero
+	SetWriteDeadline(t time.Time) error
+
+	// Close closes the connection
+	Close() error
+}
+
+// BearerOptions configures a bearer
+type BearerOptions struct {
+	// SDUSize is the maximum payload length of the segments written, from 1
+	// to 65535 bytes.  Zero selects MaxSDUSize.
+	SDUSize int
+
+	// DialTimeout limits the time to connect.  Zero means no limit.
+	DialTimeout time.Duration
+}
+
+// connBearer is a bearer over a stream connection
+type connBearer struct {
+	conn    net.Conn
+	sduSize int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewUnixBearer connects to the unix domain socket of a local node
+func NewUnixBearer(filename string) (Bearer, error) {
+	return NewUnixBearerWithOptions(filename, BearerOptions{})
+}
+
+// NewUnixBearerWithOptions connects to the unix domain socket of a local node
+// with the given options
+func NewUnixBearerWithOptions(filename string, options BearerOptions) (Bearer, error) {
+
+	if !utils.FileExists(filename) {
+		return nil, errors.NewMessageErrorf(errors.ErrSocketNotExists,
+			"Socket [%s] not found", filename)
+	}
+
+	conn, err := net.DialTimeout(networkUnix, filename, options.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnBearer(conn, options), nil
+}
+
+// NewTCPBearer connects to the node at the address, in the host:port form
+func NewTCPBearer(address string) (Bearer, error) {
+	return NewTCPBearerWithOptions(address, BearerOptions{})
+}
+
+// NewTCPBearerWithOptions connects to the node at the address, in the
+// host:port form, with the given options
+func NewTCPBearerWithOptions(address string, options BearerOptions) (Bearer, error) {
+
+	conn, err := net.DialTimeout(networkTCP, address, options.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewConnBearer(conn, options), nil
+}
+
+// NewPipeBearers returns the two ends of an in-memory connection, to run a
+// client against a local fake node
+func NewPipeBearers() (Bearer, Bearer) {
+	a, b := net.Pipe()
+	return NewConnBearer(a, BearerOptions{}), NewConnBearer(b, BearerOptions{})
+}
+
+// NewConnBearer returns a bearer over a connection, such as one accepted by a
+// listener.  The bearer owns the connection.
+func NewConnBearer(conn net.Conn, options BearerOptions) Bearer {
+
+	return &connBearer{
+		conn:    conn,
+		sduSize: validSDUSize(options.SDUSize),
+	}
+}
+
+// ReadSegment reads the next segment from the connection
+func (b *connBearer) ReadSegment() (*Header, []byte, error) {
+
+	buf := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(b.conn, buf); err != nil {
+		return nil, nil, err
+	}
+	header, err := ParseHeader(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload := make([]byte, header.PayloadLengthAsInt32())
+	if _, err := io.ReadFull(b.conn, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, nil, err
+	}
+	log.WithField("header", header.String()).Trace("Received segment")
+
+	return header, payload, nil
+}
+
+// WriteSegment writes the header and payload of the message in one write
+func (b *connBearer) WriteSegment(message *Message) error {
+	_, err := b.conn.Write(message.Bytes())
+	return err
+}
+
+// SDUSize returns the maximum payload length of the segments to write
+func (b *connBearer) SDUSize() int {
+	return b.sduSize
+}
+
+// SetReadDeadline sets the read deadline of the connection
+func (b *connBearer) SetReadDeadline(t time.Time) error {
+	return b.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the connection
+func (b *connBearer) SetWriteDeadline(t time.Time) error {
+	return b.conn.SetWriteDeadline(t)
+}
+
+// Close closes the connection
+func (b *connBearer) Close() error {
+	return b.conn.Close()
+}
@@ -0,0 +1,140 @@
+package multiplex
+
+import (
+	e "errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearers(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "bearer")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "node.socket")
+
+	unixListener, err := NewUnixListener(socket)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer unixListener.Close()
+	tcpListener, err := NewTCPListener("127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer tcpListener.Close()
+
+	testCases := []struct {
+		name     string
+		listener *Listener
+		dial     func() (Bearer, error)
+	}{
+		{
+			name:     "unix",
+			listener: unixListener,
+			dial:     func() (Bearer, error) { return NewUnixBearer(socket) },
+		},
+		{
+			name:     "tcp",
+			listener: tcpListener,
+			dial: func() (Bearer, error) {
+				return NewTCPBearerWithOptions(tcpListener.Addr().String(), BearerOptions{DialTimeout: time.Second})
+			},
+		},
+		{
+			name: "pipe",
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		var client, server Bearer
+		if testCase.listener == nil {
+			client, server = NewPipeBearers()
+		} else {
+			accepted := make(chan Bearer, 1)
+			go func() {
+				bearer, _ := testCase.listener.Accept()
+				accepted <- bearer
+			}()
+			client, err = testCase.dial()
+			if !assert.Nil(t, err, testCase.name) {
+				continue
+			}
+			server = <-accepted
+		}
+		assert.Equal(t, MaxSDUSize, client.SDUSize(), testCase.name)
+
+		// segments go both ways
+		go client.WriteSegment(NewMessage(MiniProtocolIDKeepAlive, MessageModeInitiator, []byte{0x01, 0x02}))
+		header, payload, err := server.ReadSegment()
+		if assert.Nil(t, err, testCase.name) {
+			assert.Equal(t, MiniProtocolIDKeepAlive, header.MiniProtocol(), testCase.name)
+			assert.True(t, header.IsFromInitiator(), testCase.name)
+			assert.Equal(t, []byte{0x01, 0x02}, payload, testCase.name)
+		}
+
+		go server.WriteSegment(NewMessage(MiniProtocolIDKeepAlive, MessageModeResponder, nil))
+		header, payload, err = client.ReadSegment()
+		if assert.Nil(t, err, testCase.name) {
+			assert.True(t, header.IsFromResponder(), testCase.name)
+			assert.Equal(t, []byte{}, payload, testCase.name)
+		}
+
+		// reads time out at the deadline
+		assert.Nil(t, client.SetReadDeadline(time.Now().Add(10*time.Millisecond)), testCase.name)
+		_, _, err = client.ReadSegment()
+		if netErr, ok := err.(net.Error); assert.True(t, ok, testCase.name) {
+			assert.True(t, netErr.Timeout(), testCase.name)
+		}
+		assert.Nil(t, client.SetReadDeadline(time.Time{}), testCase.name)
+
+		// the peer closing the connection between segments is io.EOF
+		assert.Nil(t, server.Close(), testCase.name)
+		_, _, err = client.ReadSegment()
+		assert.Equal(t, io.EOF, err, testCase.name)
+		assert.Nil(t, client.Close(), testCase.name)
+	}
+
+	_, err = NewUnixBearer(filepath.Join(dir, "missing.socket"))
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrSocketNotExists)))
+}
+
+func TestConnBearerPartialSegment(t *testing.T) {
+
+	conn, peer := net.Pipe()
+	bearer := NewConnBearer(conn, BearerOptions{SDUSize: 1 << 16})
+	assert.Equal(t, MaxSDUSize, bearer.SDUSize())
+
+	go func() {
+		message := NewMessage(MiniProtocolIDBlockFetch, MessageModeResponder, []byte{0x01, 0x02})
+		peer.Write(message.Bytes()[:HeaderSize+1])
+		peer.Close()
+	}()
+	_, _, err := bearer.ReadSegment()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestListenerClose(t *testing.T) {
+
+	listener, err := NewTCPListener("127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Nil(t, listener.Close())
+	_, err = listener.Accept()
+	assert.NotNil(t, err)
+
+	_, err = NewTCPBearer(listener.Addr().String())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,57 @@
+package multiplex
+
+import (
+	"net"
+)
+
+// Listener accepts the connections of peers as bearers
+type Listener struct {
+	listener net.Listener
+	options  BearerOptions
+}
+
+// NewUnixListener listens on a unix domain socket, which must not exist
+func NewUnixListener(filename string) (*Listener, error) {
+	listener, err := net.Listen(networkUnix, filename)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(listener, BearerOptions{}), nil
+}
+
+// NewTCPListener listens on the TCP address, in the host:port form
+func NewTCPListener(address string) (*Listener, error) {
+	listener, err := net.Listen(networkTCP, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewListener(listener, BearerOptions{}), nil
+}
+
+// NewListener returns a listener accepting bearers with the given options
+// from the network listener, which it owns
+func NewListener(listener net.Listener, options BearerOptions) *Listener {
+	return &Listener{
+		listener: listener,
+		options:  options,
+	}
+}
+
+// Accept waits for the next connection and returns its bearer
+func (l *Listener) Accept() (Bearer, error) {
+	conn, err := l.listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewConnBearer(conn, l.options), nil
+}
+
+// Addr returns the address the listener listens on
+func (l *Listener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// Close stops listening.  The bearers accepted are not closed.
+func (l *Listener) Close() error {
+	return l.listener.Close()
+}
@@ -45,7 +45,7 @@ type Message struct {
 // NewMessage returns a new message
 func NewMessage(miniProtocol MiniProtocol, messageMode MessageMode, data []byte) *Message {
 	return &Message{
-		header: NewHeader(miniProtocol, messageMode, 0),
+		header: NewHeader(miniProtocol, messageMode, uint16(len(data))),
 		data:   data,
 	}
 }
@@ -0,0 +1,373 @@
+package multiplex
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultIngressQueueLimit is the ingress queue limit of the mini
+	// protocols without one in DefaultIngressQueueLimits
+	DefaultIngressQueueLimit = 4 * 1024 * 1024
+)
+
+// DefaultIngressQueueLimits are the ingress queue limits of the node-to-node
+// mini protocols given by the network specification, with its safety margin
+// of 10% over the largest data a well behaved peer sends before reading
+var DefaultIngressQueueLimits = map[MiniProtocol]int{
+	MiniProtocolIDChainSyncHeaders: 462000,    // 300 headers of 1400 bytes
+	MiniProtocolIDBlockFetch:       230686940, // 100 blocks of 2 MiB
+	MiniProtocolIDKeepAlive:        1408,      // 1280 bytes
+}
+
+// MuxOptions configures a multiplexer
+type MuxOptions struct {
+	// IngressQueueLimits are the numbers of bytes received for the channels
+	// of a mini protocol that may wait to be read.  A peer sending more
+	// violates the mini protocol, and the multiplexer is stopped.  The mini
+	// protocols missing use DefaultIngressQueueLimits, then
+	// DefaultIngressQueueLimit, and a negative limit means no limit.
+	IngressQueueLimits map[MiniProtocol]int
+
+	// MiniProtocols are the mini protocols the peer may run, or all the known
+	// mini protocols when nil.  A segment received for another one violates
+	// the protocol, and the multiplexer is stopped instead of keeping it.
+	MiniProtocols []MiniProtocol
+}
+
+// Mux runs the mini protocols over one bearer.  A reader goroutine
+// dispatches the segments received to the channel of their mini protocol,
+// and a writer goroutine sends the segments of all the channels in turn, so
+// that the mini protocols run concurrently.
+type Mux struct {
+	bearer   Bearer
+	options  MuxOptions
+	sduSize  int
+	outbound chan *outboundSegment
+	done     chan struct{}
+
+	lock     sync.Mutex
+	channels map[channelKey]*Channel
+	err      error
+}
+
+// Channel carries one side of a mini protocol.  In mode MessageModeInitiator,
+// it sends the segments of the initiator and receives those of the responder,
+// and the other way around in mode MessageModeResponder.
+type Channel struct {
+	mux          *Mux
+	miniProtocol MiniProtocol
+	mode         MessageMode
+	ingressLimit int
+
+	writeLock sync.Mutex
+
+	lock    sync.Mutex
+	buffer  []byte
+	notify  chan struct{}
+	decoder *cbor.Decoder
+}
+
+// channelKey identifies a channel of the multiplexer
+type channelKey struct {
+	miniProtocol MiniProtocol
+	mode         MessageMode
+}
+
+// outboundSegment is a segment handed to the writer goroutine, which reports
+// the result of writing it on written
+type outboundSegment struct {
+	message *Message
+	written chan error
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewMux returns a multiplexer running over the bearer, which it owns and
+// closes when it stops.  The messages sent are split in segments of the SDU
+// size of the bearer, or MaxSDUSize if it is invalid.
+//
+// The peer may run any known mini protocol in both modes, and each channel it
+// sends to buffers up to its ingress queue limit until read, about 500 MB in
+// all with the default limits.  Use NewMuxWithOptions with the mini protocols actually
+// run to bound the memory a hostile peer can take.
+func NewMux(bearer Bearer) *Mux {
+	return NewMuxWithOptions(bearer, MuxOptions{})
+}
+
+// NewMuxWithOptions returns a multiplexer running over the bearer, which it
+// owns, with the given options
+func NewMuxWithOptions(bearer Bearer, options MuxOptions) *Mux {
+
+	m := &Mux{
+		bearer:   bearer,
+		options:  options,
+		sduSize:  validSDUSize(bearer.SDUSize()),
+		outbound: make(chan *outboundSegment),
+		done:     make(chan struct{}),
+		channels: map[channelKey]*Channel{},
+	}
+
+	go m.readLoop()
+	go m.writeLoop()
+
+	return m
+}
+
+// Channel returns the channel of the mini protocol in the given mode.  The
+// segments received for a channel before it is requested are kept for it.
+func (m *Mux) Channel(miniProtocol MiniProtocol, mode MessageMode) *Channel {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	key := channelKey{miniProtocol: miniProtocol, mode: mode}
+	c, found := m.channels[key]
+	if !found {
+		c = &Channel{
+			mux:          m,
+			miniProtocol: miniProtocol,
+			mode:         mode,
+			ingressLimit: m.ingressQueueLimit(miniProtocol),
+			notify:       make(chan struct{}, 1),
+		}
+		m.channels[key] = c
+	}
+	return c
+}
+
+// ingressQueueLimit returns the ingress queue limit of the mini protocol
+func (m *Mux) ingressQueueLimit(miniProtocol MiniProtocol) int {
+	if limit, found := m.options.IngressQueueLimits[miniProtocol]; found {
+		return limit
+	}
+	if limit, found := DefaultIngressQueueLimits[miniProtocol]; found {
+		return limit
+	}
+	return DefaultIngressQueueLimit
+}
+
+// Done returns a channel that is closed when the multiplexer stops
+func (m *Mux) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err returns the error that stopped the multiplexer: io.EOF if the peer
+// closed the connection, or ErrMuxClosed after Close
+func (m *Mux) Err() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.err
+}
+
+// Close stops the multiplexer and closes the bearer
+func (m *Mux) Close() error {
+	return m.stop(errors.NewMessageErrorf(errors.ErrMuxClosed, "Multiplexer closed"))
+}
+
+// stop records the error that stopped the multiplexer and closes the
+// bearer, the first time it is called
+func (m *Mux) stop(err error) error {
+
+	m.lock.Lock()
+	if m.err != nil {
+		m.lock.Unlock()
+		return nil
+	}
+	m.err = err
+	m.lock.Unlock()
+
+	close(m.done)
+	return m.bearer.Close()
+}
+
+// readLoop dispatches the segments received until the bearer fails
+func (m *Mux) readLoop() {
+	for {
+		header, payload, err := m.bearer.ReadSegment()
+		if err != nil {
+			m.stopReading(err)
+			return
+		}
+
+		// segments from the initiator are for the responder, and vice versa
+		mode := MessageModeInitiator
+		if header.IsFromInitiator() {
+			mode = MessageModeResponder
+		}
+		if !m.expects(header.MiniProtocol()) {
+			m.stop(errors.NewMessageErrorf(errors.ErrMuxMiniProtocolUnexpected,
+				"Segment received for mini protocol [%s]", header.MiniProtocol()))
+			return
+		}
+		if err := m.Channel(header.MiniProtocol(), mode).deliver(payload); err != nil {
+			m.stop(err)
+			return
+		}
+	}
+}
+
+// expects returns true if the peer may run the mini protocol
+func (m *Mux) expects(miniProtocol MiniProtocol) bool {
+	if m.options.MiniProtocols == nil {
+		_, known := miniProtocolNames[miniProtocol]
+		return known
+	}
+	for _, expected := range m.options.MiniProtocols {
+		if expected == miniProtocol {
+			return true
+		}
+	}
+	return false
+}
+
+// stopReading stops the multiplexer on a read error, which is expected once
+// the multiplexer is closed
+func (m *Mux) stopReading(err error) {
+	select {
+	case <-m.done:
+	default:
+		if err != io.EOF {
+			log.WithError(err).Error("Error reading from multiplexer bearer")
+		}
+		m.stop(err)
+	}
+}
+
+// stopWriting stops the multiplexer on a write error, which is expected once
+// the multiplexer is closed
+func (m *Mux) stopWriting(err error) {
+	select {
+	case <-m.done:
+	default:
+		log.WithError(err).Error("Error writing to multiplexer bearer")
+		m.stop(err)
+	}
+}
+
+// writeLoop sends the segments of the channels until the multiplexer stops
+func (m *Mux) writeLoop() {
+	for {
+		select {
+		case segment := <-m.outbound:
+			err := m.bearer.WriteSegment(segment.message)
+			segment.written <- err
+			if err != nil {
+				m.stopWriting(err)
+				return
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// MiniProtocol returns the mini protocol of the channel
+func (c *Channel) MiniProtocol() MiniProtocol {
+	return c.miniProtocol
+}
+
+// MessageMode returns the mode of the segments sent on the channel
+func (c *Channel) MessageMode() MessageMode {
+	return c.mode
+}
+
+// Write sends the data in segments of at most the SDU size of the multiplexer,
+// which are not interleaved with the segments of other writes on the channel.
+// It returns once the segments are written to the bearer.
+func (c *Channel) Write(data []byte) (int, error) {
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	written := 0
+	for written < len(data) {
+		end := written + c.mux.sduSize
+		if end > len(data) {
+			end = len(data)
+		}
+		segment := &outboundSegment{
+			message: NewMessage(c.miniProtocol, c.mode, data[written:end]),
+			written: make(chan error, 1),
+		}
+
+		select {
+		case c.mux.outbound <- segment:
+		case <-c.mux.done:
+			return written, c.mux.Err()
+		}
+		if err := <-segment.written; err != nil {
+			return written, err
+		}
+		written = end
+	}
+	return written, nil
+}
+
+// Read reads the data received on the channel.  Once the multiplexer stops,
+// the data received before is read, then the error that stopped it.
+func (c *Channel) Read(p []byte) (int, error) {
+	for {
+		c.lock.Lock()
+		if len(c.buffer) > 0 {
+			n := copy(p, c.buffer)
+			c.buffer = c.buffer[n:]
+			c.lock.Unlock()
+			return n, nil
+		}
+		c.lock.Unlock()
+
+		select {
+		case <-c.notify:
+		case <-c.mux.done:
+			c.lock.Lock()
+			empty := len(c.buffer) == 0
+			c.lock.Unlock()
+			if empty {
+				return 0, c.mux.Err()
+			}
+		}
+	}
+}
+
+// Send sends the data items as one message
+func (c *Channel) Send(dataItems ...cbor.DataItem) error {
+	_, err := c.Write(cbor.EncodeList(dataItems))
+	return err
+}
+
+// Receive returns the next data item received on the channel, reassembled
+// from as many segments as it spans, and decoded with the default limits of
+// hardened decoding.  It must be called from one goroutine at a time, and not
+// mixed with Read.
+func (c *Channel) Receive() (cbor.DataItem, error) {
+	if c.decoder == nil {
+		c.decoder = cbor.NewDecoderWithOptions(c, cbor.DecodeOptions{Hardened: true})
+	}
+	return c.decoder.Decode()
+}
+
+// deliver queues a segment received for the channel
+func (c *Channel) deliver(payload []byte) error {
+
+	c.lock.Lock()
+	if c.ingressLimit >= 0 && len(c.buffer)+len(payload) > c.ingressLimit {
+		c.lock.Unlock()
+		return errors.NewMessageErrorf(errors.ErrMuxIngressQueueLimitExceeded,
+			"Ingress queue of mini protocol [%s] exceeds [%d] bytes", c.miniProtocol, c.ingressLimit)
+	}
+	c.buffer = append(c.buffer, payload...)
+	c.lock.Unlock()
+
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
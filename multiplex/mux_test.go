@@ -0,0 +1,280 @@
+package multiplex
+
+import (
+	"bytes"
+	e "errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMuxExchange(t *testing.T) {
+
+	initiatorBearer, responderBearer := NewPipeBearers()
+	initiator := NewMux(initiatorBearer)
+	responder := NewMux(responderBearer)
+	defer initiator.Close()
+	defer responder.Close()
+
+	protocols := []MiniProtocol{
+		MiniProtocolIDChainSyncBlocks,
+		MiniProtocolIDLocalStateQuery,
+		MiniProtocolIDKeepAlive,
+	}
+
+	// the responders echo the requests with the mini protocol ID appended
+	for _, protocol := range protocols {
+		go func(c *Channel) {
+			for {
+				request, err := c.Receive()
+				if err != nil {
+					return
+				}
+				array := request.(*cbor.Array)
+				array.Add(cbor.NewPositiveInteger(uint64(c.MiniProtocol())))
+				if c.Send(array) != nil {
+					return
+				}
+			}
+		}(responder.Channel(protocol, MessageModeResponder))
+	}
+
+	var wg sync.WaitGroup
+	for _, protocol := range protocols {
+		wg.Add(1)
+		go func(c *Channel) {
+			defer wg.Done()
+			for i := uint64(0); i < 20; i++ {
+				err := c.Send(cbor.NewArrayWithItems([]cbor.DataItem{cbor.NewPositiveInteger(i)}))
+				if !assert.Nil(t, err) {
+					return
+				}
+				response, err := c.Receive()
+				if !assert.Nil(t, err) {
+					return
+				}
+				assert.Equal(t, []byte{0x82, byte(i), byte(c.MiniProtocol())}, response.EncodeCBOR())
+			}
+		}(initiator.Channel(protocol, MessageModeInitiator))
+	}
+	wg.Wait()
+}
+
+func TestMuxSegments(t *testing.T) {
+
+	bearer, peer := NewPipeBearers()
+	m := NewMux(bearer)
+	defer m.Close()
+
+	// messages longer than MaxSDUSize span several segments
+	payload := bytes.Repeat([]byte{0xab}, 2*MaxSDUSize+100)
+	item := cbor.NewByteString(payload)
+	go m.Channel(MiniProtocolIDLocalTXSubmission, MessageModeInitiator).Send(item)
+
+	encoded := item.EncodeCBOR()
+	received := []byte{}
+	for _, expectLength := range []int{MaxSDUSize, MaxSDUSize, len(encoded) - 2*MaxSDUSize} {
+		header, segment, err := peer.ReadSegment()
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, MiniProtocolIDLocalTXSubmission, header.MiniProtocol())
+		assert.True(t, header.IsFromInitiator())
+		assert.Equal(t, expectLength, header.PayloadLengthAsInt32())
+		received = append(received, segment...)
+	}
+	assert.Equal(t, encoded, received)
+
+	// segments received are reassembled, and routed by their mode
+	channel := m.Channel(MiniProtocolIDLocalTXSubmission, MessageModeInitiator)
+	go func() {
+		for start := 0; start < len(encoded); start += 1000 {
+			end := start + 1000
+			if end > len(encoded) {
+				end = len(encoded)
+			}
+			peer.WriteSegment(NewMessage(MiniProtocolIDLocalTXSubmission, MessageModeInitiator, []byte{0x01}))
+			peer.WriteSegment(NewMessage(MiniProtocolIDLocalTXSubmission, MessageModeResponder, encoded[start:end]))
+		}
+	}()
+	response, err := channel.Receive()
+	if assert.Nil(t, err) {
+		assert.Equal(t, payload, response.Value())
+	}
+
+	responderChannel := m.Channel(MiniProtocolIDLocalTXSubmission, MessageModeResponder)
+	for i := 0; i < 3; i++ {
+		response, err := responderChannel.Receive()
+		if assert.Nil(t, err) {
+			assert.Equal(t, uint8(1), response.Value())
+		}
+	}
+}
+
+func TestMuxClose(t *testing.T) {
+
+	initiatorBearer, responderBearer := NewPipeBearers()
+	initiator := NewMux(initiatorBearer)
+	responder := NewMux(responderBearer)
+
+	channel := responder.Channel(MiniProtocolIDKeepAlive, MessageModeResponder)
+	assert.Nil(t, initiator.Channel(MiniProtocolIDKeepAlive, MessageModeInitiator).Send(cbor.NewPositiveInteger(1)))
+	assert.Nil(t, initiator.Close())
+	<-initiator.Done()
+	assert.Nil(t, initiator.Close())
+
+	// the data received before the peer closed the connection is still read
+	<-responder.Done()
+	item, err := channel.Receive()
+	if assert.Nil(t, err) {
+		assert.Equal(t, uint8(1), item.Value())
+	}
+	_, err = channel.Receive()
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, io.EOF, responder.Err())
+
+	err = initiator.Channel(MiniProtocolIDKeepAlive, MessageModeInitiator).Send(cbor.NewPositiveInteger(2))
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrMuxClosed)))
+	_, err = initiator.Channel(MiniProtocolIDChainSyncBlocks, MessageModeInitiator).Receive()
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrMuxClosed)))
+}
+
+func TestMuxIngressQueueLimit(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		options      MuxOptions
+		miniProtocol MiniProtocol
+		segments     int
+		expectErr    int
+	}{
+		{"keep alive default", MuxOptions{}, MiniProtocolIDKeepAlive, 2, errors.ErrMuxIngressQueueLimitExceeded},
+		{"chain sync default", MuxOptions{}, MiniProtocolIDChainSyncHeaders,
+			462000/MaxSDUSize + 1, errors.ErrMuxIngressQueueLimitExceeded},
+		{"other default", MuxOptions{}, MiniProtocolIDLocalStateQuery,
+			DefaultIngressQueueLimit/MaxSDUSize + 1, errors.ErrMuxIngressQueueLimitExceeded},
+		{"configured", MuxOptions{IngressQueueLimits: map[MiniProtocol]int{MiniProtocolIDBlockFetch: MaxSDUSize}},
+			MiniProtocolIDBlockFetch, 2, errors.ErrMuxIngressQueueLimitExceeded},
+		{"within limit", MuxOptions{IngressQueueLimits: map[MiniProtocol]int{MiniProtocolIDKeepAlive: 2 * MaxSDUSize}},
+			MiniProtocolIDKeepAlive, 2, 0},
+		{"no limit", MuxOptions{IngressQueueLimits: map[MiniProtocol]int{MiniProtocolIDKeepAlive: -1}},
+			MiniProtocolIDKeepAlive, 10, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			bearer, peer := NewPipeBearers()
+			m := NewMuxWithOptions(bearer, test.options)
+
+			go func(miniProtocol MiniProtocol, segments int) {
+				segment := NewMessage(miniProtocol, MessageModeResponder, make([]byte, MaxSDUSize))
+				for i := 0; i < segments; i++ {
+					if err := peer.WriteSegment(segment); err != nil {
+						return
+					}
+				}
+				peer.Close()
+			}(test.miniProtocol, test.segments)
+
+			<-m.Done()
+			if test.expectErr == 0 {
+				assert.Equal(t, io.EOF, m.Err())
+			} else {
+				assert.True(t, e.Is(m.Err(), errors.NewError(test.expectErr)))
+			}
+		})
+	}
+}
+
+func TestMuxSDUSize(t *testing.T) {
+
+	conn, peerConn := net.Pipe()
+	m := NewMux(NewConnBearer(conn, BearerOptions{SDUSize: 10}))
+	defer m.Close()
+	peer := NewConnBearer(peerConn, BearerOptions{})
+
+	item := cbor.NewByteString(make([]byte, 20))
+	go m.Channel(MiniProtocolIDBlockFetch, MessageModeInitiator).Send(item)
+
+	for _, expectLength := range []int{10, 10, 1} {
+		header, _, err := peer.ReadSegment()
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Equal(t, expectLength, header.PayloadLengthAsInt32())
+	}
+}
+
+// sduSizeBearer is a bearer reporting another SDU size
+type sduSizeBearer struct {
+	Bearer
+	sduSize int
+}
+
+func (b *sduSizeBearer) SDUSize() int {
+	return b.sduSize
+}
+
+func TestMuxInvalidSDUSize(t *testing.T) {
+
+	for _, sduSize := range []int{0, -1, 65536} {
+
+		bearer, peer := NewPipeBearers()
+		m := NewMux(&sduSizeBearer{Bearer: bearer, sduSize: sduSize})
+
+		item := cbor.NewByteString(make([]byte, MaxSDUSize))
+		go m.Channel(MiniProtocolIDBlockFetch, MessageModeInitiator).Send(item)
+
+		header, _, err := peer.ReadSegment()
+		if assert.Nil(t, err, "%d", sduSize) {
+			assert.Equal(t, MaxSDUSize, header.PayloadLengthAsInt32(), "%d", sduSize)
+		}
+		m.Close()
+	}
+}
+
+func TestMuxUnexpectedMiniProtocol(t *testing.T) {
+
+	bearer, peer := NewPipeBearers()
+	m := NewMuxWithOptions(bearer, MuxOptions{MiniProtocols: []MiniProtocol{MiniProtocolIDKeepAlive}})
+
+	go func() {
+		peer.WriteSegment(NewMessage(MiniProtocolIDKeepAlive, MessageModeInitiator, []byte{0x01}))
+		peer.WriteSegment(NewMessage(MiniProtocolIDChainSyncHeaders, MessageModeInitiator, []byte{0x01}))
+	}()
+
+	// the segment of the expected mini protocol is received, then the
+	// segment of the other one stops the multiplexer
+	item, err := m.Channel(MiniProtocolIDKeepAlive, MessageModeResponder).Receive()
+	if assert.Nil(t, err) {
+		assert.Equal(t, uint8(1), item.Value())
+	}
+	<-m.Done()
+	assert.True(t, e.Is(m.Err(), errors.NewError(errors.ErrMuxMiniProtocolUnexpected)))
+}
+
+func TestMuxUnknownMiniProtocol(t *testing.T) {
+
+	bearer, peer := NewPipeBearers()
+	m := NewMux(bearer)
+
+	go func() {
+		peer.WriteSegment(NewMessage(MiniProtocolIDLocalStateQuery, MessageModeInitiator, []byte{0x01}))
+		peer.WriteSegment(NewMessage(MiniProtocol(100), MessageModeInitiator, []byte{0x01}))
+	}()
+
+	// the multiplexer accepts the known mini protocols by default, and stops
+	// on a segment for an unknown one
+	item, err := m.Channel(MiniProtocolIDLocalStateQuery, MessageModeResponder).Receive()
+	if assert.Nil(t, err) {
+		assert.Equal(t, uint8(1), item.Value())
+	}
+	<-m.Done()
+	assert.True(t, e.Is(m.Err(), errors.NewError(errors.ErrMuxMiniProtocolUnexpected)))
+}
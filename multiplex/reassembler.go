@@ -0,0 +1,66 @@
+package multiplex
+
+import (
+	"github.com/gocardano/go-cardano-client/cbor"
+)
+
+// Reassembler joins the segments of the messages received into service data
+// units.  A message may span any number of segments of any size, and ends
+// with the segment that completes its CBOR data items, so the segments of
+// each mini protocol and mode are kept until they scan completely, and only
+// then decoded.
+type Reassembler struct {
+	pending map[channelKey]*pendingMessage
+}
+
+// pendingMessage is a message waiting for more segments
+type pendingMessage struct {
+	data     []byte
+	complete int // length of the complete data items at the start of data
+	scanner  cbor.Scanner
+}
+
+// NewReassembler returns a new reassembler
+func NewReassembler() *Reassembler {
+	return &Reassembler{
+		pending: map[channelKey]*pendingMessage{},
+	}
+}
+
+// Add adds the payload of a segment, and returns the service data unit it
+// completes, or nil if the message continues in the next segments
+func (r *Reassembler) Add(header *Header, payload []byte) (*ServiceDataUnit, error) {
+
+	key := channelKey{miniProtocol: header.MiniProtocol(), mode: header.MessageMode()}
+	message, found := r.pending[key]
+	if !found {
+		message = &pendingMessage{}
+	}
+	message.data = append(message.data, payload...)
+
+	// only the bytes added are scanned, the scanner resuming where it stopped
+	for message.complete < len(message.data) {
+		end, complete, err := message.scanner.Scan(message.data[message.complete:])
+		if err != nil {
+			delete(r.pending, key)
+			return nil, err
+		}
+		if !complete {
+			r.pending[key] = message
+			return nil, nil
+		}
+		message.complete += end
+	}
+
+	delete(r.pending, key)
+	dataItems, err := cbor.DecodeWithOptions(message.data, cbor.DecodeOptions{Hardened: true})
+	if err != nil {
+		return nil, err
+	}
+	return NewServiceDataUnit(header.MiniProtocol(), header.MessageMode(), dataItems), nil
+}
+
+// Pending returns true if a message is waiting for more segments
+func (r *Reassembler) Pending() bool {
+	return len(r.pending) > 0
+}
@@ -5,6 +5,7 @@ package multiplex
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/gocardano/go-cardano-client/cbor"
 	"github.com/gocardano/go-cardano-client/errors"
@@ -34,6 +35,7 @@ func NewServiceDataUnit(miniProtocol MiniProtocol, messageMode MessageMode, data
 }
 
 // ParseServiceDataUnits returns a list of parsed SDU.  Assumes first 8 bytes are the header bits.
+// The segments of a message are reassembled as described in Reassembler.
 func ParseServiceDataUnits(data []byte) ([]*ServiceDataUnit, error) {
 
 	sdus := []*ServiceDataUnit{}
@@ -43,12 +45,16 @@ func ParseServiceDataUnits(data []byte) ([]*ServiceDataUnit, error) {
 		return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
 	}
 
+	reassembler := NewReassembler()
 	counter := 0
-	tmpCborBytes := []byte{}
 
 	for counter < len(data) {
 
 		// Parse header
+		if len(data) < counter+HeaderSize {
+			log.WithField("messageLength", len(data)).Error("Data length below header minimum size")
+			return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
+		}
 		header, err := ParseHeader(data[counter : counter+HeaderSize])
 		if err != nil {
 			log.WithError(err).Error("Error parsing header")
@@ -62,23 +68,26 @@ func ParseServiceDataUnits(data []byte) ([]*ServiceDataUnit, error) {
 			}).Error("Data length below expected size")
 			return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
 		}
-		tmpCborBytes = append(tmpCborBytes, data[counter+HeaderSize:counter+HeaderSize+header.PayloadLengthAsInt32()]...)
 
-		if header.PayloadLength() == MaxSDUSize {
-			log.Tracef("Found payload of MaxSDUSize, aggregating the payload")
-		} else {
-			dataItems, err := cbor.Decode(tmpCborBytes)
-			if err != nil {
-				log.WithError(err).Error("Error decoding cbor bytes")
-				return nil, err
-			}
-			sdu := NewServiceDataUnit(header.MiniProtocol(), header.MessageMode(), dataItems)
+		sdu, err := reassembler.Add(header, data[counter+HeaderSize:counter+HeaderSize+header.PayloadLengthAsInt32()])
+		if err != nil {
+			log.WithError(err).Error("Error decoding cbor bytes")
+			return nil, err
+		}
+		if sdu != nil {
 			sdus = append(sdus, sdu)
-			tmpCborBytes = tmpCborBytes[:0]
+		} else {
+			log.Tracef("Found incomplete payload, aggregating the payload")
 		}
 
 		counter += HeaderSize + header.PayloadLengthAsInt32()
 	}
+
+	if reassembler.Pending() {
+		log.Error("Data ends in the middle of a message")
+		return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
+	}
+
 	return sdus, nil
 }
 
@@ -87,25 +96,53 @@ func (s *ServiceDataUnit) DataItems() []cbor.DataItem {
 	return s.dataItems
 }
 
-// Bytes returns the byte array for this SDU wrapped in multiplexed message format
+// Bytes returns the byte array for this SDU wrapped in multiplexed message format,
+// in segments of at most MaxSDUSize bytes
 func (s *ServiceDataUnit) Bytes() []byte {
+	return s.SegmentedBytes(MaxSDUSize)
+}
 
+// SegmentedBytes returns the byte array for this SDU wrapped in multiplexed
+// message format, in segments of at most sduSize bytes (see Messages)
+func (s *ServiceDataUnit) SegmentedBytes(sduSize int) []byte {
 	buf := []byte{}
+	for _, message := range s.Messages(sduSize) {
+		buf = append(buf, message.Bytes()...)
+	}
+	return buf
+}
+
+// Messages returns the segments of this SDU, of at most sduSize bytes each.
+// A size outside 1 to 65535 bytes selects MaxSDUSize.
+func (s *ServiceDataUnit) Messages(sduSize int) []*Message {
+
+	sduSize = validSDUSize(sduSize)
+	messages := []*Message{}
 
 	cborPayload := cbor.EncodeList(s.dataItems)
 	cborPayloadLength := len(cborPayload)
 
-	for counter := 0; counter < MaxSDUSize; counter += MaxSDUSize {
-		if counter+MaxSDUSize > cborPayloadLength {
-			buf = append(buf, NewHeader(s.miniProtocol, s.messageMode, uint16(cborPayloadLength-counter)).Bytes()...)
-			buf = append(buf, cborPayload[counter:cborPayloadLength]...)
-		} else {
-			buf = append(buf, NewHeader(s.miniProtocol, s.messageMode, MaxSDUSize).Bytes()...)
-			buf = append(buf, cborPayload[counter:counter+MaxSDUSize]...)
+	for counter := 0; counter == 0 || counter < cborPayloadLength; counter += sduSize {
+		end := counter + sduSize
+		if end > cborPayloadLength {
+			end = cborPayloadLength
 		}
+		messages = append(messages, NewMessage(s.miniProtocol, s.messageMode, cborPayload[counter:end]))
 	}
 
-	return buf
+	return messages
+}
+
+// validSDUSize returns the SDU size, or MaxSDUSize if it does not fit the
+// payload length of a header
+func validSDUSize(sduSize int) int {
+	if sduSize <= 0 || sduSize > math.MaxUint16 {
+		if sduSize != 0 {
+			log.WithField("sduSize", sduSize).Warn("Invalid SDU size, using the default")
+		}
+		return MaxSDUSize
+	}
+	return sduSize
 }
 
 // Debug returns a string representation of the message
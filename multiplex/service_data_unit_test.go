@@ -3,6 +3,7 @@ package multiplex
 import (
 	"testing"
 
+	"github.com/gocardano/go-cardano-client/cbor"
 	"github.com/prometheus/common/log"
 	"github.com/stretchr/testify/assert"
 )
@@ -32,3 +33,143 @@ func TestParseServiceDataUnit(t *testing.T) {
 	assert.NotNil(t, err)
 	log.Error(err)
 }
+
+func TestServiceDataUnitSegments(t *testing.T) {
+
+	payload := make([]byte, 3*MaxSDUSize)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	dataItems := []cbor.DataItem{cbor.NewByteString(payload), cbor.NewPositiveInteger8(1)}
+	encoded := cbor.EncodeList(dataItems)
+
+	testCases := []struct {
+		sduSize        int
+		expectSegments int
+	}{
+		{sduSize: MaxSDUSize, expectSegments: 4},
+		{sduSize: len(encoded), expectSegments: 1},
+		{sduSize: len(encoded) - 1, expectSegments: 2},
+		{sduSize: 1000, expectSegments: 37},
+	}
+
+	for _, testCase := range testCases {
+
+		sdu := NewServiceDataUnit(MiniProtocolIDLocalTXSubmission, MessageModeInitiator, dataItems)
+		buf := sdu.SegmentedBytes(testCase.sduSize)
+		assert.Equal(t, len(encoded)+testCase.expectSegments*HeaderSize, len(buf), "%d", testCase.sduSize)
+
+		// every segment but the last has the full size
+		for i, counter := 0, 0; counter < len(buf); i++ {
+			header, err := ParseHeader(buf[counter : counter+HeaderSize])
+			if !assert.Nil(t, err) {
+				break
+			}
+			if i < testCase.expectSegments-1 {
+				assert.Equal(t, testCase.sduSize, header.PayloadLengthAsInt32())
+			}
+			counter += HeaderSize + header.PayloadLengthAsInt32()
+		}
+
+		// a segment ending between data items completes a message
+		sdus, err := ParseServiceDataUnits(buf)
+		if assert.Nil(t, err, "%d", testCase.sduSize) {
+			reassembled := []byte{}
+			for _, sdu := range sdus {
+				reassembled = append(reassembled, cbor.EncodeList(sdu.DataItems())...)
+			}
+			assert.Equal(t, encoded, reassembled)
+		}
+	}
+
+	// invalid sizes select the default instead of looping or truncating
+	sdu := NewServiceDataUnit(MiniProtocolIDLocalTXSubmission, MessageModeInitiator, dataItems)
+	for _, sduSize := range []int{0, -1, 65536} {
+		messages := sdu.Messages(sduSize)
+		if assert.Equal(t, 4, len(messages), "%d", sduSize) {
+			assert.Equal(t, MaxSDUSize, len(messages[0].Data()))
+		}
+	}
+
+	// an empty message is sent as one segment without payload
+	empty := NewServiceDataUnit(MiniProtocolIDBlockFetch, MessageModeInitiator, nil).Bytes()
+	assert.Equal(t, []byte{0x00, byte(MiniProtocolIDBlockFetch), 0x00, 0x00}, empty[4:])
+}
+
+func TestParseServiceDataUnitsInterleaved(t *testing.T) {
+
+	// segments of two mini protocols interleave on the wire
+	chainSync := NewServiceDataUnit(MiniProtocolIDChainSyncBlocks, MessageModeResponder,
+		[]cbor.DataItem{cbor.NewByteString(make([]byte, 100))}).SegmentedBytes(40)
+	keepAlive := NewServiceDataUnit(MiniProtocolIDKeepAlive, MessageModeResponder,
+		[]cbor.DataItem{cbor.NewTextString("ping")}).SegmentedBytes(3)
+
+	buf := append([]byte{}, chainSync[:48]...)
+	buf = append(buf, keepAlive[:11]...)
+	buf = append(buf, chainSync[48:]...)
+	buf = append(buf, keepAlive[11:]...)
+
+	sdus, err := ParseServiceDataUnits(buf)
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(sdus)) {
+		assert.Equal(t, MiniProtocolIDChainSyncBlocks, sdus[0].miniProtocol)
+		assert.Equal(t, make([]byte, 100), sdus[0].DataItems()[0].Value())
+		assert.Equal(t, MiniProtocolIDKeepAlive, sdus[1].miniProtocol)
+		assert.Equal(t, "ping", sdus[1].DataItems()[0].Value())
+	}
+
+	// data ending in the middle of a message or a header
+	_, err = ParseServiceDataUnits(chainSync[:len(chainSync)-HeaderSize-20])
+	assert.NotNil(t, err)
+	_, err = ParseServiceDataUnits(append(append([]byte{}, keepAlive...), keepAlive[:4]...))
+	assert.NotNil(t, err)
+}
+
+func TestReassembler(t *testing.T) {
+
+	r := NewReassembler()
+	header := NewHeader(MiniProtocolIDLocalStateQuery, MessageModeResponder, 0)
+
+	sdu, err := r.Add(header, []byte{0x82, 0x01})
+	assert.Nil(t, err)
+	assert.Nil(t, sdu)
+	assert.True(t, r.Pending())
+
+	sdu, err = r.Add(header, []byte{0x02})
+	assert.Nil(t, err)
+	if assert.NotNil(t, sdu) {
+		assert.Equal(t, []byte{0x82, 0x01, 0x02}, cbor.EncodeList(sdu.DataItems()))
+	}
+	assert.False(t, r.Pending())
+
+	// a data item split in segments of one byte
+	encoded := cbor.NewByteString(make([]byte, 300)).EncodeCBOR()
+	for i, b := range encoded {
+		sdu, err = r.Add(header, []byte{b})
+		if !assert.Nil(t, err) {
+			return
+		}
+		if i < len(encoded)-1 {
+			assert.Nil(t, sdu)
+		}
+	}
+	if assert.NotNil(t, sdu) {
+		assert.Equal(t, make([]byte, 300), sdu.DataItems()[0].Value())
+	}
+	assert.False(t, r.Pending())
+
+	// a segment completing several data items
+	sdu, err = r.Add(header, []byte{0x83, 0x01})
+	assert.Nil(t, err)
+	assert.Nil(t, sdu)
+	sdu, err = r.Add(header, []byte{0x02, 0x03, 0x64, 'p', 'i', 'n', 'g'})
+	assert.Nil(t, err)
+	if assert.NotNil(t, sdu) && assert.Equal(t, 2, len(sdu.DataItems())) {
+		assert.Equal(t, "ping", sdu.DataItems()[1].Value())
+	}
+	assert.False(t, r.Pending())
+
+	// malformed data is an error rather than an incomplete message
+	_, err = r.Add(header, []byte{0x1c})
+	assert.NotNil(t, err)
+	assert.False(t, r.Pending())
+}
@@ -1,5 +1,7 @@
 package shelley
 
+import "github.com/gocardano/go-cardano-client/cbor"
+
 ////////////////////////////////////////////////////////////////////////////////
 //
 // blockFetchMessage
@@ -49,9 +51,15 @@ type BlockFetchMessageNoBlocks struct {
 	MessageType BlockFetchMessageType
 }
 
+// BlockFetchMessageBlock holds the block embedded as encoded CBOR (tag 24)
 type BlockFetchMessageBlock struct {
 	MessageType BlockFetchMessageType
-	Value       []byte
+	Value       *cbor.EncodedCBOR
+}
+
+// Block returns the decoded block
+func (m *BlockFetchMessageBlock) Block() (cbor.DataItem, error) {
+	return m.Value.Item()
 }
 
 type BlockFetchMessageBatchDone struct {
@@ -1,5 +1,7 @@
 package shelley
 
+import "github.com/gocardano/go-cardano-client/cbor"
+
 ////////////////////////////////////////////////////////////////////////////////
 //
 // chainSyncMessage
@@ -86,8 +88,14 @@ type Tip struct {
 	Value uint
 }
 
+// WrappedHeader holds the block header embedded as encoded CBOR (tag 24)
 type WrappedHeader struct {
-	Value []byte
+	Value *cbor.EncodedCBOR
+}
+
+// Header returns the decoded block header
+func (w *WrappedHeader) Header() (cbor.DataItem, error) {
+	return w.Value.Item()
 }
 
 type Point struct {
@@ -99,3 +107,63 @@ type BlockHeaderHash struct {
 	SlotNo word64
 	Value  int
 }
+
+// ChainPoint identifies a block by its slot and header hash, or the origin of
+// the chain when Hash is nil
+type ChainPoint struct {
+	Slot uint64
+	Hash []byte
+}
+
+// ChainTip is the most recent block of the chain of a peer
+type ChainTip struct {
+	Point       ChainPoint
+	BlockNumber uint64
+}
+
+// dataItem returns the point as [] for the origin, or [slot, hash]
+func (p ChainPoint) dataItem() cbor.DataItem {
+	if p.Hash == nil {
+		return cbor.NewArray()
+	}
+	return cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger(p.Slot),
+		cbor.NewByteString(p.Hash),
+	})
+}
+
+// parseChainPoint returns the point at the cursor
+func parseChainPoint(cursor *cbor.Cursor) (*ChainPoint, error) {
+
+	length, err := cursor.Len()
+	if err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return &ChainPoint{}, nil
+	}
+
+	slot, err := cursor.Index(0).Uint64()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := cursor.Index(1).Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &ChainPoint{Slot: slot, Hash: hash}, nil
+}
+
+// parseChainTip returns the tip at the cursor, [point, blockNumber]
+func parseChainTip(cursor *cbor.Cursor) (*ChainTip, error) {
+
+	point, err := parseChainPoint(cursor.Index(0))
+	if err != nil {
+		return nil, err
+	}
+	blockNumber, err := cursor.Index(1).Uint64()
+	if err != nil {
+		return nil, err
+	}
+	return &ChainTip{Point: *point, BlockNumber: blockNumber}, nil
+}
@@ -17,18 +17,32 @@ const (
 
 // Client wraps interaction with the shelley node
 type Client struct {
-	socket *UnixSocket
+	bearer multiplex.Bearer
+	dial   DialFunc
 }
 
-// NewClient returns a new shelley client instance
+// DialFunc connects to the node, for instance with multiplex.NewUnixBearer
+type DialFunc func() (multiplex.Bearer, error)
+
+// NewClient returns a new shelley client instance connected to the unix
+// domain socket of the node
 func NewClient(socketFilename string) (*Client, error) {
-	socket, err := NewUnixSocket(socketFilename, defaultReadTimeoutMs, defaultWriteTimeoutMs)
+	return NewClientWithDialer(func() (multiplex.Bearer, error) {
+		return multiplex.NewUnixBearer(socketFilename)
+	})
+}
+
+// NewClientWithDialer returns a new shelley client instance connected to the
+// node by dial, which is called again on Reset
+func NewClientWithDialer(dial DialFunc) (*Client, error) {
+	bearer, err := dial()
 	if err != nil {
 		return nil, err
 	}
 
 	client := &Client{
-		socket: socket,
+		bearer: bearer,
+		dial:   dial,
 	}
 
 	if err := client.handshake(); err != nil {
@@ -38,21 +52,21 @@ func NewClient(socketFilename string) (*Client, error) {
 	return client, nil
 }
 
-// Disconnect client from socket
+// Disconnect client from the node
 func (c *Client) Disconnect() error {
-	return c.socket.Close()
+	return c.bearer.Close()
 }
 
-// Reset the socket by disconnecting and reconnecting
+// Reset the connection by disconnecting and reconnecting
 func (c *Client) Reset() error {
 
 	if err := c.Disconnect(); err != nil {
 		return fmt.Errorf("Error trying to reset the shelley client %s", err)
 	}
 
-	socket, err := NewUnixSocket(c.socket.filename, defaultReadTimeoutMs, defaultWriteTimeoutMs)
+	bearer, err := c.dial()
 	if err == nil {
-		c.socket = socket
+		c.bearer = bearer
 	}
 
 	if err := c.handshake(); err != nil {
@@ -65,7 +79,7 @@ func (c *Client) Reset() error {
 // Handshake negotiation with protocol version
 func (c *Client) handshake() error {
 
-	messageResponse, err := c.queryNode(multiplex.MiniProtocolIDMuxControl, handshakeRequest())
+	messageResponse, err := c.queryNode(multiplex.MiniProtocolIDMuxControl, nodeToClientHandshakeRequest(MainnetNetworkMagic))
 	if err != nil {
 		log.WithError(err).Error("Error querying node")
 	}
@@ -79,7 +93,6 @@ func (c *Client) handshake() error {
 	if response.accepted == false {
 		log.WithFields(log.Fields{
 			"versionNumber": response.versionNumber,
-			"extraParams":   response.extraParams,
 			"refuseReason":  response.refuseReason,
 		}).Debug("Handshake failed")
 		return errors.NewMessageErrorf(errors.ErrShellyHandshakeFailed, "Handshake failed due to %s", response.refuseReason)
@@ -87,14 +100,14 @@ func (c *Client) handshake() error {
 
 	log.WithFields(log.Fields{
 		"versionNumber": response.versionNumber,
-		"extraParams":   response.extraParams,
+		"extraParams":   cbor.Diagnostic(response.extraParams),
 	}).Debug("Handshake was successful")
 
 	return nil
 }
 
 // StakePools returns list of stake pools
-func (c *Client) StakePools(slotNumber uint32, hash []byte) (*multiplex.ServiceDataUnit, error) {
+func (c *Client) StakePools(slotNumber uint64, hash []byte) (*multiplex.ServiceDataUnit, error) {
 
 	// >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>> R E Q U E S T   #     1 >>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>>
 	// MiniProtocol: 7   /   MessageMode: 0
@@ -113,7 +126,7 @@ func (c *Client) StakePools(slotNumber uint32, hash []byte) (*multiplex.ServiceD
 	setBlockRequest := cbor.NewArrayWithItems([]cbor.DataItem{
 		cbor.NewPositiveInteger8(0),
 		cbor.NewArrayWithItems([]cbor.DataItem{
-			cbor.NewPositiveInteger32(slotNumber),
+			cbor.NewPositiveInteger(slotNumber),
 			cbor.NewByteString(hash),
 		}),
 	})
@@ -175,7 +188,7 @@ func (c *Client) StakePools(slotNumber uint32, hash []byte) (*multiplex.ServiceD
 }
 
 // QueryTip returns the block header hash (slotNumber, string, blockNumber, error)
-func (c *Client) QueryTip() (uint32, []byte, uint32, error) {
+func (c *Client) QueryTip() (uint64, []byte, uint64, error) {
 
 	// Step 1: Send the chain sync request object
 	log.Debug("Sending command: msgRequestNext")
@@ -184,6 +197,7 @@ func (c *Client) QueryTip() (uint32, []byte, uint32, error) {
 	messageResponse, err := c.queryNode(multiplex.MiniProtocolIDChainSyncBlocks, []cbor.DataItem{chainSyncRequest})
 	if err != nil {
 		log.WithError(err).Error("Error parsing block fetch response from node")
+		return 0, nil, 0, err
 	}
 
 	// Step 2: Parse the response
@@ -193,16 +207,27 @@ func (c *Client) QueryTip() (uint32, []byte, uint32, error) {
 	//   Array: [0]
 	//   Array: [2]
 	//     Array: [2]
-	// 	     PositiveInteger32(11918355)  // slot
+	// 	     PositiveInteger32(11918355)  // slot, any integer width
 	// 	     ByteString - Length: [32]; Value: [95a417047d3660f2dbd0d70f21b46d7348e9dd0b0e0156ca368cca2d54bcb61b];
-	//     PositiveInteger32(4857537)     // blockNumber
+	//     PositiveInteger32(4857537)     // blockNumber, any integer width
 
-	arr := messageResponse.DataItems()[0].(*cbor.Array)
-	// arr.Get(0).(*cbor.PositiveInteger8).ValueAsUint8()) // ignore: blockFetch "3"
-	// arr.Get(1).(*cbor.Array))                           // ignore: Empty Array
-	slotNumber := arr.Get(2).(*cbor.Array).Get(0).(*cbor.Array).Get(0).(*cbor.PositiveInteger32).ValueAsUint32()
-	hash := arr.Get(2).(*cbor.Array).Get(0).(*cbor.Array).Get(1).(*cbor.ByteString).ValueAsBytes()
-	blockNumber := arr.Get(2).(*cbor.Array).Get(1).(*cbor.PositiveInteger32).ValueAsUint32()
+	if len(messageResponse.DataItems()) == 0 {
+		return 0, nil, 0, errors.NewError(errors.ErrShellyUnexpectedCborItem)
+	}
+	// the node encodes slot and block numbers in the shortest integer width
+	tip := cbor.Path(messageResponse.DataItems()[0]).Index(2)
+	slotNumber, err := tip.Index(0).Index(0).Uint64()
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	hash, err := tip.Index(0).Index(1).Bytes()
+	if err != nil {
+		return 0, nil, 0, err
+	}
+	blockNumber, err := tip.Index(1).Uint64()
+	if err != nil {
+		return 0, nil, 0, err
+	}
 
 	// Step 3: Send the chainSyncMessageDone to terminate
 	log.Debug("Sending command: chainSyncMessageDone")
@@ -228,9 +253,9 @@ func (c *Client) queryNode(miniProtocol multiplex.MiniProtocol, dataItems []cbor
 	}
 
 	// Step 2: Send the request
-	messageResponse, err := c.socket.Write(sdu.Bytes())
+	messageResponse, err := roundTrip(c.bearer, sdu, defaultReadTimeoutMs, defaultWriteTimeoutMs)
 	if err != nil && err != io.EOF {
-		return nil, fmt.Errorf("Error writing to socket %w", err)
+		return nil, fmt.Errorf("Error writing to bearer %w", err)
 	}
 	if log.IsLevelEnabled(log.DebugLevel) && messageResponse != nil {
 		log.Debug("Multiplexed Response:")
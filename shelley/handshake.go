@@ -33,6 +33,9 @@ package shelley
 // refuseReasonVersionMismatch      = [0, [ *versionNumber ] ]
 // refuseReasonHandshakeDecodeError = [1, versionNumber, tstr]
 // refuseReasonRefused              = [2, versionNumber, tstr]
+//
+// The node-to-node versions 7 to 10 take [networkMagic, initiatorOnlyDiffusionMode]
+// as params, and the later ones [networkMagic, initiatorOnlyDiffusionMode, peerSharing, query].
 
 import (
 	"fmt"
@@ -45,23 +48,42 @@ import (
 )
 
 const (
-	handshakeMessagePropose uint8 = 0
-	handshakeMessageAccept  uint8 = 1
-	handshakeMessageRefuse  uint8 = 2
+	handshakeMessagePropose = 0
+	handshakeMessageAccept  = 1
+	handshakeMessageRefuse  = 2
+
+	handshakeRefuseReasonVersionMismatch      = 0
+	handshakeRefuseReasonHandshakeDecodeError = 1
+	handshakeRefuseReasonRefused              = 2
+
+	// MainnetNetworkMagic identifies the mainnet in the handshake
+	MainnetNetworkMagic uint64 = 764824073
 
-	handshakeRefuseReasonVersionMismatch      uint8 = 0
-	handshakeRefuseReasonHandshakeDecodeError uint8 = 1
-	handshakeRefuseReasonRefused              uint8 = 2
+	// node-to-node version data is a list of 2 items up to this version, and
+	// of 4 items after
+	nodeToNodeVersionTwoParams = 10
+
+	// peer sharing disabled in the node-to-node version data
+	nodeToNodeNoPeerSharing = 0
 )
 
+// nodeToClientVersions are the node-to-client versions proposed and accepted,
+// in ascending order
+var nodeToClientVersions = []uint64{1, 32770, 32771}
+
+// nodeToNodeVersions are the node-to-node versions proposed and accepted, in
+// ascending order
+var nodeToNodeVersions = []uint64{7, 8, 9, 10, 11, 12, 13, 14}
+
 type handshakeResponse struct {
 	accepted      bool
-	versionNumber uint16
-	extraParams   uint32
+	versionNumber uint64
+	extraParams   cbor.DataItem
 	refuseReason  string
 }
 
-func handshakeRequest() []cbor.DataItem {
+// nodeToClientHandshakeRequest proposes the node-to-client versions for the network
+func nodeToClientHandshakeRequest(networkMagic uint64) []cbor.DataItem {
 
 	// msgProposeVersions = [0, versionTable]
 	// versionTable =
@@ -75,20 +97,50 @@ func handshakeRequest() []cbor.DataItem {
 	versionTable := cbor.NewMap()
 	arr.Add(versionTable)
 
-	versionTable.Add(cbor.NewPositiveInteger8(1), cbor.NewPositiveInteger(764824073))
-	versionTable.Add(cbor.NewPositiveInteger16(32770), cbor.NewPositiveInteger(764824073))
-	versionTable.Add(cbor.NewPositiveInteger16(32771), cbor.NewPositiveInteger(764824073))
+	for _, version := range nodeToClientVersions {
+		versionTable.Add(cbor.NewPositiveInteger(version), cbor.NewPositiveInteger(networkMagic))
+	}
+
+	return []cbor.DataItem{arr}
+}
+
+// nodeToNodeHandshakeRequest proposes the node-to-node versions for the
+// network in initiator only diffusion mode, so that the peer does not connect
+// back, and without peer sharing
+func nodeToNodeHandshakeRequest(networkMagic uint64) []cbor.DataItem {
+
+	arr := cbor.NewArray()
+	arr.Add(cbor.NewPositiveInteger8(handshakeMessagePropose))
+	versionTable := cbor.NewMap()
+	arr.Add(versionTable)
+
+	for _, version := range nodeToNodeVersions {
+		params := cbor.NewArray()
+		params.Add(cbor.NewPositiveInteger(networkMagic))
+		params.Add(cbor.NewPrimitiveTrue()) // initiatorOnlyDiffusionMode
+		if version > nodeToNodeVersionTwoParams {
+			params.Add(cbor.NewPositiveInteger8(nodeToNodeNoPeerSharing))
+			params.Add(cbor.NewPrimitiveFalse()) // query
+		}
+		versionTable.Add(cbor.NewPositiveInteger(version), params)
+	}
 
 	return []cbor.DataItem{arr}
 }
 
 func parseHandshakeResponse(sdu *multiplex.ServiceDataUnit) (*handshakeResponse, error) {
 
-	if sdu == nil || len(sdu.DataItems()) != 1 && sdu.DataItems()[0].MajorType() != cbor.MajorTypeArray {
+	if sdu == nil || len(sdu.DataItems()) != 1 {
 		log.Error("Handshake response is expecting an array response with 3 items")
 		return nil, errors.NewError(errors.ErrShellyUnexpectedCborItem)
 	}
 
+	return parseHandshakeMessage(sdu.DataItems()[0])
+}
+
+// parseHandshakeMessage parses the reply to the proposed versions
+func parseHandshakeMessage(item cbor.DataItem) (*handshakeResponse, error) {
+
 	var response *handshakeResponse
 
 	// msgAcceptVersion   = [1, versionNumber, extraParams]
@@ -102,15 +154,26 @@ func parseHandshakeResponse(sdu *multiplex.ServiceDataUnit) (*handshakeResponse,
 	// refuseReasonHandshakeDecodeError = [1, versionNumber, tstr]
 	// refuseReasonRefused              = [2, versionNumber, tstr]
 
-	arr := sdu.DataItems()[0].(*cbor.Array)
-	status := arr.Get(0).(*cbor.PositiveInteger8)
+	message := cbor.Path(item)
+	status, err := message.Index(0).Uint64()
+	if err != nil {
+		return nil, err
+	}
 
-	switch status.ValueAsUint8() {
+	switch status {
 	case handshakeMessageAccept:
+		versionNumber, err := message.Index(1).Uint64()
+		if err != nil {
+			return nil, err
+		}
+		extraParams, err := message.Index(2).Item()
+		if err != nil {
+			return nil, err
+		}
 		response = &handshakeResponse{
 			accepted:      true,
-			versionNumber: arr.Get(1).(*cbor.PositiveInteger16).ValueAsUint16(),
-			extraParams:   arr.Get(2).(*cbor.PositiveInteger32).ValueAsUint32(),
+			versionNumber: versionNumber,
+			extraParams:   extraParams,
 		}
 		break
 
@@ -120,14 +183,25 @@ func parseHandshakeResponse(sdu *multiplex.ServiceDataUnit) (*handshakeResponse,
 		// refuseReasonHandshakeDecodeError = [1, versionNumber, tstr]
 		// refuseReasonRefused              = [2, versionNumber, tstr]
 
-		refuseReasonArray := arr.Get(1).(*cbor.Array)
+		refuseReasonArray, err := message.Index(1).Array()
+		if err != nil {
+			return nil, err
+		}
+
+		refuseReason, err := message.Index(1).Index(0).Uint64()
+		if err != nil {
+			return nil, err
+		}
 
-		switch refuseReasonArray.Get(0).(*cbor.PositiveInteger8).ValueAsUint8() {
+		switch refuseReason {
 		case handshakeRefuseReasonVersionMismatch:
-			parameters := refuseReasonArray.Get(1).(*cbor.Array).ValuesAsString()
+			versionNumbers, err := message.Index(1).Index(1).Array()
+			if err != nil {
+				return nil, err
+			}
 			response = &handshakeResponse{
 				accepted:     false,
-				refuseReason: fmt.Sprintf("Version mistmatch [Parameters: %s]", parameters),
+				refuseReason: fmt.Sprintf("Version mistmatch [Parameters: %s]", versionNumbers.ValuesAsString()),
 			}
 			break
 		case handshakeRefuseReasonHandshakeDecodeError:
@@ -150,7 +224,82 @@ func parseHandshakeResponse(sdu *multiplex.ServiceDataUnit) (*handshakeResponse,
 			break
 		}
 		break
+
+	default:
+		return nil, errors.NewMessageErrorf(errors.ErrShellyUnexpectedCborItem, "Unexpected handshake message [%d]", status)
 	}
 
 	return response, nil
 }
+
+// handshakeReply returns the reply to the versions proposed in the request:
+// the highest version both sides support, with the params proposed for it if
+// they are for the network, or the refusal
+func handshakeReply(request cbor.DataItem, versions []uint64, networkMagic uint64) (cbor.DataItem, *handshakeResponse, error) {
+
+	message := cbor.Path(request)
+	status, err := message.Index(0).Uint64()
+	if err != nil {
+		return nil, nil, err
+	}
+	if status != handshakeMessagePropose {
+		return nil, nil, errors.NewMessageErrorf(errors.ErrShellyUnexpectedCborItem, "Unexpected handshake message [%d]", status)
+	}
+	versionTable, err := message.Index(1).Map()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		params, found := versionTable.GetUint(versions[i])
+		if !found {
+			continue
+		}
+
+		// refuseReasonRefused = [2, versionNumber, tstr]
+		magic, err := versionNetworkMagic(params)
+		if err != nil || magic != networkMagic {
+			refuseReason := fmt.Sprintf("Network magic mismatch, expecting [%d]", networkMagic)
+			reply := cbor.NewArrayWithItems([]cbor.DataItem{
+				cbor.NewPositiveInteger8(handshakeMessageRefuse),
+				cbor.NewArrayWithItems([]cbor.DataItem{
+					cbor.NewPositiveInteger8(handshakeRefuseReasonRefused),
+					cbor.NewPositiveInteger(versions[i]),
+					cbor.NewTextString(refuseReason),
+				}),
+			})
+			return reply, &handshakeResponse{versionNumber: versions[i], refuseReason: refuseReason}, nil
+		}
+
+		// msgAcceptVersion = [1, versionNumber, extraParams]
+		reply := cbor.NewArrayWithItems([]cbor.DataItem{
+			cbor.NewPositiveInteger8(handshakeMessageAccept),
+			cbor.NewPositiveInteger(versions[i]),
+			params,
+		})
+		return reply, &handshakeResponse{accepted: true, versionNumber: versions[i], extraParams: params}, nil
+	}
+
+	// refuseReasonVersionMismatch = [0, [ *versionNumber ] ]
+	supported := cbor.NewArray()
+	for _, version := range versions {
+		supported.Add(cbor.NewPositiveInteger(version))
+	}
+	reply := cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(handshakeMessageRefuse),
+		cbor.NewArrayWithItems([]cbor.DataItem{
+			cbor.NewPositiveInteger8(handshakeRefuseReasonVersionMismatch),
+			supported,
+		}),
+	})
+	return reply, &handshakeResponse{refuseReason: "Version mismatch"}, nil
+}
+
+// versionNetworkMagic returns the network magic of the version params, which
+// is the first item of the node-to-node version data
+func versionNetworkMagic(params cbor.DataItem) (uint64, error) {
+	if params.MajorType() == cbor.MajorTypeArray {
+		return cbor.Path(params).Index(0).Uint64()
+	}
+	return cbor.Path(params).Uint64()
+}
@@ -0,0 +1,62 @@
+package shelley
+
+import (
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/multiplex"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+//
+// keepAliveMessage
+//     = msgKeepAlive
+//     / msgKeepAliveResponse
+//     / msgDone
+//
+// msgKeepAlive         = [0, cookie]
+// msgKeepAliveResponse = [1, cookie]
+// msgDone              = [2]
+//
+// cookie = word16
+//
+////////////////////////////////////////////////////////////////////////////////
+
+// KeepAliveMessageType identify the message type for the keep alive protocol
+type KeepAliveMessageType uint
+
+const (
+	KeepAliveMessageKeepAliveType KeepAliveMessageType = 0
+	KeepAliveMessageResponseType  KeepAliveMessageType = 1
+	KeepAliveMessageDoneType      KeepAliveMessageType = 2
+)
+
+// KeepAliveHandler answers the keep alive messages of the peer, as the
+// ProtocolHandler of MiniProtocolIDKeepAlive
+func KeepAliveHandler(channel *multiplex.Channel) error {
+	for {
+		message, messageType, err := receiveMessage(channel)
+		if err != nil {
+			return err
+		}
+
+		switch KeepAliveMessageType(messageType) {
+		case KeepAliveMessageKeepAliveType:
+			cookie, err := message.Index(1).Uint64()
+			if err != nil {
+				return err
+			}
+			err = channel.Send(cbor.NewArrayWithItems([]cbor.DataItem{
+				cbor.NewPositiveInteger8(uint8(KeepAliveMessageResponseType)),
+				cbor.NewPositiveInteger(cookie),
+			}))
+			if err != nil {
+				return err
+			}
+
+		case KeepAliveMessageDoneType:
+			return nil
+
+		default:
+			return unexpectedMessage(multiplex.MiniProtocolIDKeepAlive, messageType)
+		}
+	}
+}
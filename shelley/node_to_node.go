@@ -0,0 +1,495 @@
+package shelley
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/gocardano/go-cardano-client/multiplex"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDialTimeout       = 10 * time.Second
+	defaultHandshakeTimeout  = 10 * time.Second
+	defaultKeepAliveInterval = 30 * time.Second
+	defaultKeepAliveTimeout  = 60 * time.Second
+)
+
+// nodeToNodeMiniProtocols are the mini protocols run by the node-to-node
+// client, for which the peer may send segments
+var nodeToNodeMiniProtocols = []multiplex.MiniProtocol{
+	multiplex.MiniProtocolIDMuxControl,
+	multiplex.MiniProtocolIDChainSyncHeaders,
+	multiplex.MiniProtocolIDBlockFetch,
+	multiplex.MiniProtocolIDKeepAlive,
+}
+
+// NodeToNodeOptions configures a node-to-node client
+type NodeToNodeOptions struct {
+	// NetworkMagic identifies the network of the peer.  Zero selects
+	// MainnetNetworkMagic.
+	NetworkMagic uint64
+
+	// HandshakeTimeout limits the time to negotiate the version.  Zero means
+	// no limit.
+	HandshakeTimeout time.Duration
+
+	// KeepAliveInterval is the time between the keep alive messages sent in
+	// the background, without which the peer closes an idle connection.  Zero
+	// disables them.
+	KeepAliveInterval time.Duration
+
+	// KeepAliveTimeout limits the time to wait for a keep alive response,
+	// after which the connection is closed.  Zero selects 60 seconds, and a
+	// negative timeout means no limit.
+	KeepAliveTimeout time.Duration
+}
+
+// NodeToNodeClient follows the chain of a relay with the node-to-node mini
+// protocols.  It negotiates the initiator only diffusion mode, so the relay
+// does not use the connection to run its own mini protocols.  The methods of
+// each mini protocol may be called concurrently with those of the others.
+type NodeToNodeClient struct {
+	mux     *multiplex.Mux
+	version uint64
+
+	chainSyncLock sync.Mutex
+	chainSync     *multiplex.Channel
+
+	blockFetchLock sync.Mutex
+	blockFetch     *multiplex.Channel
+
+	keepAliveLock    sync.Mutex
+	keepAlive        *multiplex.Channel
+	keepAliveTimeout time.Duration
+	cookie           uint16
+}
+
+// ChainSyncUpdate is the next change of the chain followed by RequestNext
+type ChainSyncUpdate struct {
+	// RollBackward is true when the chain rolls back to Point, and false
+	// when it rolls forward with Header
+	RollBackward bool
+
+	// Header is the block header as sent by the peer, which a Cardano node
+	// wraps with the era of the block
+	Header cbor.DataItem
+
+	// Point is the point the chain rolls back to
+	Point *ChainPoint
+
+	// Tip is the tip of the chain of the peer
+	Tip *ChainTip
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewNodeToNodeClient connects to the TCP port of a relay on the mainnet, at
+// the address in the host:port form, and sends keep alive messages in the
+// background
+func NewNodeToNodeClient(address string) (*NodeToNodeClient, error) {
+
+	bearer, err := multiplex.NewTCPBearerWithOptions(address, multiplex.BearerOptions{DialTimeout: defaultDialTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewNodeToNodeClientWithOptions(bearer, NodeToNodeOptions{
+		HandshakeTimeout:  defaultHandshakeTimeout,
+		KeepAliveInterval: defaultKeepAliveInterval,
+	})
+}
+
+// NewNodeToNodeClientWithOptions negotiates the node-to-node version over the
+// bearer, which the client owns, with the given options
+func NewNodeToNodeClientWithOptions(bearer multiplex.Bearer, options NodeToNodeOptions) (*NodeToNodeClient, error) {
+
+	networkMagic := options.NetworkMagic
+	if networkMagic == 0 {
+		networkMagic = MainnetNetworkMagic
+	}
+
+	keepAliveTimeout := options.KeepAliveTimeout
+	if keepAliveTimeout == 0 {
+		keepAliveTimeout = defaultKeepAliveTimeout
+	}
+
+	mux := multiplex.NewMuxWithOptions(bearer, multiplex.MuxOptions{MiniProtocols: nodeToNodeMiniProtocols})
+	c := &NodeToNodeClient{
+		mux:              mux,
+		chainSync:        mux.Channel(multiplex.MiniProtocolIDChainSyncHeaders, multiplex.MessageModeInitiator),
+		blockFetch:       mux.Channel(multiplex.MiniProtocolIDBlockFetch, multiplex.MessageModeInitiator),
+		keepAlive:        mux.Channel(multiplex.MiniProtocolIDKeepAlive, multiplex.MessageModeInitiator),
+		keepAliveTimeout: keepAliveTimeout,
+	}
+
+	if options.HandshakeTimeout > 0 {
+		bearer.SetReadDeadline(time.Now().Add(options.HandshakeTimeout))
+	}
+	if err := c.handshake(networkMagic); err != nil {
+		mux.Close()
+		return nil, err
+	}
+	bearer.SetReadDeadline(time.Time{})
+
+	if options.KeepAliveInterval > 0 {
+		go c.keepAliveLoop(options.KeepAliveInterval)
+	}
+
+	return c, nil
+}
+
+// Version returns the node-to-node version negotiated with the peer
+func (c *NodeToNodeClient) Version() uint64 {
+	return c.version
+}
+
+// Done returns a channel that is closed when the connection ends
+func (c *NodeToNodeClient) Done() <-chan struct{} {
+	return c.mux.Done()
+}
+
+// Close disconnects from the peer
+func (c *NodeToNodeClient) Close() error {
+	return c.mux.Close()
+}
+
+// handshake negotiates one of the node-to-node versions
+func (c *NodeToNodeClient) handshake(networkMagic uint64) error {
+
+	channel := c.mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeInitiator)
+	if err := channel.Send(nodeToNodeHandshakeRequest(networkMagic)...); err != nil {
+		return err
+	}
+	message, err := channel.Receive()
+	if err != nil {
+		log.WithError(err).Error("Error receiving handshake response from node")
+		return err
+	}
+
+	response, err := parseHandshakeMessage(message)
+	if err != nil {
+		log.WithError(err).Error("Error parsing handshake response from node")
+		return err
+	}
+
+	if !response.accepted {
+		log.WithField("refuseReason", response.refuseReason).Debug("Handshake failed")
+		return errors.NewMessageErrorf(errors.ErrShellyHandshakeFailed, "Handshake failed due to %s", response.refuseReason)
+	}
+
+	log.WithFields(log.Fields{
+		"versionNumber": response.versionNumber,
+		"extraParams":   cbor.Diagnostic(response.extraParams),
+	}).Debug("Handshake was successful")
+
+	c.version = response.versionNumber
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FindIntersect returns the most recent of the points that is on the chain of
+// the peer, from which RequestNext continues, or nil if none is
+func (c *NodeToNodeClient) FindIntersect(points ...ChainPoint) (*ChainPoint, *ChainTip, error) {
+
+	c.chainSyncLock.Lock()
+	defer c.chainSyncLock.Unlock()
+
+	items := make([]cbor.DataItem, len(points))
+	for i, point := range points {
+		items[i] = point.dataItem()
+	}
+	request := cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(ChainSyncMessageFindIntersectType)),
+		cbor.NewArrayWithItems(items),
+	})
+
+	message, messageType, err := exchangeMessage(c.chainSync, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch messageType {
+	case ChainSyncMessageIntersectFoundType:
+		point, err := parseChainPoint(message.Index(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		tip, err := parseChainTip(message.Index(2))
+		if err != nil {
+			return nil, nil, err
+		}
+		return point, tip, nil
+
+	case ChainSyncMessageIntersectNotFoundType:
+		tip, err := parseChainTip(message.Index(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, tip, nil
+	}
+
+	return nil, nil, unexpectedMessage(multiplex.MiniProtocolIDChainSyncHeaders, messageType)
+}
+
+// RequestNext returns the next change of the chain of the peer.  At the tip
+// of the chain, it waits for the peer to extend it.
+func (c *NodeToNodeClient) RequestNext() (*ChainSyncUpdate, error) {
+
+	c.chainSyncLock.Lock()
+	defer c.chainSyncLock.Unlock()
+
+	request := cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(ChainSyncMessageRequestNextType)),
+	})
+	message, messageType, err := exchangeMessage(c.chainSync, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageType == ChainSyncMessageAwaitReplyType {
+		log.Debug("Awaiting the next block at the tip of the chain")
+		if message, messageType, err = receiveMessage(c.chainSync); err != nil {
+			return nil, err
+		}
+	}
+
+	switch messageType {
+	case ChainSyncMessageRollForwardType:
+		header, err := message.Index(1).Item()
+		if err != nil {
+			return nil, err
+		}
+		tip, err := parseChainTip(message.Index(2))
+		if err != nil {
+			return nil, err
+		}
+		return &ChainSyncUpdate{Header: header, Tip: tip}, nil
+
+	case ChainSyncMessageRollBackwardType:
+		point, err := parseChainPoint(message.Index(1))
+		if err != nil {
+			return nil, err
+		}
+		tip, err := parseChainTip(message.Index(2))
+		if err != nil {
+			return nil, err
+		}
+		return &ChainSyncUpdate{RollBackward: true, Point: point, Tip: tip}, nil
+	}
+
+	return nil, unexpectedMessage(multiplex.MiniProtocolIDChainSyncHeaders, messageType)
+}
+
+// ChainSyncDone ends the chain sync mini protocol
+func (c *NodeToNodeClient) ChainSyncDone() error {
+
+	c.chainSyncLock.Lock()
+	defer c.chainSyncLock.Unlock()
+
+	return c.chainSync.Send(cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(ChainSyncMessageDoneType)),
+	}))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// FetchBlocks requests the blocks from one point to another, both included,
+// and calls fn with each block received.  fn is not called if the peer does
+// not have all the blocks of the range.  The blocks after an error returned
+// by fn are received and ignored, and the error is returned.
+func (c *NodeToNodeClient) FetchBlocks(from, to ChainPoint, fn func(block *cbor.EncodedCBOR) error) error {
+
+	c.blockFetchLock.Lock()
+	defer c.blockFetchLock.Unlock()
+
+	request := cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(BlockFetchMessageRequestRangeType)),
+		from.dataItem(),
+		to.dataItem(),
+	})
+	_, messageType, err := exchangeMessage(c.blockFetch, request)
+	if err != nil {
+		return err
+	}
+
+	switch BlockFetchMessageType(messageType) {
+	case BlockFetchMessageNoBlocksType:
+		log.Debug("No blocks for the requested range")
+		return nil
+	case BlockFetchMessageStartBatchType:
+	default:
+		return unexpectedMessage(multiplex.MiniProtocolIDBlockFetch, messageType)
+	}
+
+	var fnErr error
+	for {
+		message, messageType, err := receiveMessage(c.blockFetch)
+		if err != nil {
+			return err
+		}
+
+		switch BlockFetchMessageType(messageType) {
+		case BlockFetchMessageBlockType:
+			item, err := message.Index(1).Item()
+			if err != nil {
+				return err
+			}
+			block, ok := item.(*cbor.EncodedCBOR)
+			if !ok {
+				return errors.NewMessageErrorf(errors.ErrShellyUnexpectedCborItem,
+					"Expecting a block as encoded CBOR, got [%s]", cbor.Diagnostic(item))
+			}
+			if fnErr == nil {
+				fnErr = fn(block)
+			}
+
+		case BlockFetchMessageBatchDoneType:
+			return fnErr
+
+		default:
+			return unexpectedMessage(multiplex.MiniProtocolIDBlockFetch, messageType)
+		}
+	}
+}
+
+// BlockFetchDone ends the block fetch mini protocol
+func (c *NodeToNodeClient) BlockFetchDone() error {
+
+	c.blockFetchLock.Lock()
+	defer c.blockFetchLock.Unlock()
+
+	return c.blockFetch.Send(cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(BlockFetchMessageClientDoneType)),
+	}))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// KeepAlive exchanges a keep alive message with the peer, and returns the
+// round trip time.  It closes the connection if the response does not arrive
+// within the keep alive timeout.
+func (c *NodeToNodeClient) KeepAlive() (time.Duration, error) {
+
+	c.keepAliveLock.Lock()
+	defer c.keepAliveLock.Unlock()
+
+	c.cookie++
+	request := cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(uint8(KeepAliveMessageKeepAliveType)),
+		cbor.NewPositiveInteger(uint64(c.cookie)),
+	})
+
+	start := time.Now()
+	message, messageType, err := c.exchangeKeepAlive(request)
+	if err != nil {
+		return 0, err
+	}
+	if KeepAliveMessageType(messageType) != KeepAliveMessageResponseType {
+		return 0, unexpectedMessage(multiplex.MiniProtocolIDKeepAlive, messageType)
+	}
+
+	cookie, err := message.Index(1).Uint64()
+	if err != nil {
+		return 0, err
+	}
+	if cookie != uint64(c.cookie) {
+		return 0, errors.NewMessageErrorf(errors.ErrShellyUnexpectedCborItem,
+			"Keep alive response cookie [%d] does not match [%d]", cookie, c.cookie)
+	}
+
+	return time.Since(start), nil
+}
+
+// exchangeKeepAlive sends the keep alive request and returns the response,
+// closing the connection if it does not arrive within the keep alive timeout
+func (c *NodeToNodeClient) exchangeKeepAlive(request cbor.DataItem) (*cbor.Cursor, uint64, error) {
+
+	if c.keepAliveTimeout < 0 {
+		return exchangeMessage(c.keepAlive, request)
+	}
+
+	type response struct {
+		message     *cbor.Cursor
+		messageType uint64
+		err         error
+	}
+	received := make(chan response, 1)
+	go func() {
+		message, messageType, err := exchangeMessage(c.keepAlive, request)
+		received <- response{message: message, messageType: messageType, err: err}
+	}()
+
+	timer := time.NewTimer(c.keepAliveTimeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-received:
+		return r.message, r.messageType, r.err
+	case <-timer.C:
+		c.Close()
+		return nil, 0, errors.NewMessageErrorf(errors.ErrShellyKeepAliveTimeout,
+			"No keep alive response within [%s]", c.keepAliveTimeout)
+	}
+}
+
+// keepAliveLoop sends keep alive messages until the connection ends
+func (c *NodeToNodeClient) keepAliveLoop(interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rtt, err := c.KeepAlive()
+			if err != nil {
+				log.WithError(err).Warn("Error sending keep alive message, closing the connection")
+				c.Close()
+				return
+			}
+			log.WithField("rtt", rtt).Trace("Keep alive response received")
+		case <-c.mux.Done():
+			return
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// exchangeMessage sends the request on the channel and returns the response
+// with its message type
+func exchangeMessage(channel *multiplex.Channel, request cbor.DataItem) (*cbor.Cursor, uint64, error) {
+	if err := channel.Send(request); err != nil {
+		return nil, 0, err
+	}
+	return receiveMessage(channel)
+}
+
+// receiveMessage returns the next message received on the channel with its
+// message type
+func receiveMessage(channel *multiplex.Channel) (*cbor.Cursor, uint64, error) {
+
+	item, err := channel.Receive()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	message := cbor.Path(item)
+	messageType, err := message.Index(0).Uint64()
+	if err != nil {
+		return nil, 0, err
+	}
+	return message, messageType, nil
+}
+
+// unexpectedMessage returns the error for a message not expected in the
+// current state of the mini protocol
+func unexpectedMessage(miniProtocol multiplex.MiniProtocol, messageType uint64) error {
+	return errors.NewMessageErrorf(errors.ErrShellyUnexpectedCborItem,
+		"Unexpected [%s] message [%d]", miniProtocol, messageType)
+}
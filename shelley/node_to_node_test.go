@@ -0,0 +1,350 @@
+package shelley
+
+import (
+	e "errors"
+	"testing"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/gocardano/go-cardano-client/multiplex"
+	"github.com/stretchr/testify/assert"
+)
+
+const testNetworkMagic = 1097911063
+
+// newTestNodeToNodeClient returns a client connected to a fake node, which
+// accepts the handshake and then runs node on its side of the connection
+func newTestNodeToNodeClient(t *testing.T, node func(mux *multiplex.Mux)) *NodeToNodeClient {
+
+	bearer, peer := multiplex.NewPipeBearers()
+	mux := multiplex.NewMux(peer)
+
+	go func() {
+		channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeResponder)
+		request, err := channel.Receive()
+		if !assert.Nil(t, err) {
+			return
+		}
+		reply, _, err := handshakeReply(request, nodeToNodeVersions, testNetworkMagic)
+		if !assert.Nil(t, err) || !assert.Nil(t, channel.Send(reply)) {
+			return
+		}
+		node(mux)
+	}()
+
+	client, err := NewNodeToNodeClientWithOptions(bearer, NodeToNodeOptions{NetworkMagic: testNetworkMagic})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return client
+}
+
+// nodeExchange receives the next message of the client on the channel of the
+// fake node, checks its type and sends the reply
+func nodeExchange(t *testing.T, channel *multiplex.Channel, expectType uint64, reply ...cbor.DataItem) *cbor.Cursor {
+
+	message, messageType, err := receiveMessage(channel)
+	if !assert.Nil(t, err) {
+		return nil
+	}
+	assert.Equal(t, expectType, messageType)
+	for _, item := range reply {
+		assert.Nil(t, channel.Send(item))
+	}
+	return message
+}
+
+// testMessage returns a mini protocol message of the type with the items
+func testMessage(messageType uint64, items ...cbor.DataItem) cbor.DataItem {
+	return cbor.NewArrayWithItems(append([]cbor.DataItem{cbor.NewPositiveInteger(messageType)}, items...))
+}
+
+func TestNodeToNodeHandshake(t *testing.T) {
+
+	bearer, peer := multiplex.NewPipeBearers()
+	mux := multiplex.NewMux(peer)
+	defer mux.Close()
+
+	proposals := make(chan cbor.DataItem, 1)
+	go func() {
+		channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeResponder)
+		request, err := channel.Receive()
+		if !assert.Nil(t, err) {
+			return
+		}
+		proposals <- request
+		reply, _, _ := handshakeReply(request, []uint64{7, 8, 9, 10}, testNetworkMagic)
+		channel.Send(reply)
+	}()
+
+	client, err := NewNodeToNodeClientWithOptions(bearer, NodeToNodeOptions{NetworkMagic: testNetworkMagic})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer client.Close()
+	assert.Equal(t, uint64(10), client.Version())
+
+	// the version data has 2 params up to version 10, and 4 after
+	versionTable, err := cbor.Path(<-proposals).Index(1).Map()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, len(nodeToNodeVersions), versionTable.Length())
+	for _, version := range nodeToNodeVersions {
+		params := cbor.Path(versionTable).Key(version)
+		expectLength := 2
+		if version > 10 {
+			expectLength = 4
+		}
+		length, err := params.Len()
+		assert.Nil(t, err, "%d", version)
+		assert.Equal(t, expectLength, length, "%d", version)
+
+		magic, err := params.Index(0).Uint64()
+		assert.Nil(t, err, "%d", version)
+		assert.Equal(t, uint64(testNetworkMagic), magic, "%d", version)
+		initiatorOnly, err := params.Index(1).Item()
+		if assert.Nil(t, err, "%d", version) {
+			assert.Equal(t, true, initiatorOnly.Value(), "%d", version)
+		}
+	}
+}
+
+func TestNodeToNodeHandshakeRefused(t *testing.T) {
+
+	bearer, peer := multiplex.NewPipeBearers()
+	mux := multiplex.NewMux(peer)
+	defer mux.Close()
+
+	go func() {
+		channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeResponder)
+		request, err := channel.Receive()
+		if !assert.Nil(t, err) {
+			return
+		}
+		reply, _, _ := handshakeReply(request, nodeToNodeVersions, MainnetNetworkMagic)
+		channel.Send(reply)
+	}()
+
+	_, err := NewNodeToNodeClientWithOptions(bearer, NodeToNodeOptions{NetworkMagic: testNetworkMagic})
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrShellyHandshakeFailed)))
+}
+
+func TestNodeToNodeFindIntersect(t *testing.T) {
+
+	point := ChainPoint{Slot: 100, Hash: []byte{0x01, 0x02}}
+	tip := cbor.NewArrayWithItems([]cbor.DataItem{
+		ChainPoint{Slot: 200, Hash: []byte{0x03}}.dataItem(),
+		cbor.NewPositiveInteger(20),
+	})
+
+	client := newTestNodeToNodeClient(t, func(mux *multiplex.Mux) {
+		channel := mux.Channel(multiplex.MiniProtocolIDChainSyncHeaders, multiplex.MessageModeResponder)
+
+		message := nodeExchange(t, channel, uint64(ChainSyncMessageFindIntersectType),
+			testMessage(ChainSyncMessageIntersectFoundType, point.dataItem(), tip))
+		if length, err := message.Index(1).Len(); assert.Nil(t, err) {
+			assert.Equal(t, 2, length)
+		}
+
+		nodeExchange(t, channel, uint64(ChainSyncMessageFindIntersectType),
+			testMessage(ChainSyncMessageIntersectNotFoundType, tip))
+	})
+	defer client.Close()
+
+	found, chainTip, err := client.FindIntersect(point, ChainPoint{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, &point, found)
+		assert.Equal(t, uint64(200), chainTip.Point.Slot)
+		assert.Equal(t, uint64(20), chainTip.BlockNumber)
+	}
+
+	found, chainTip, err = client.FindIntersect(ChainPoint{Slot: 1, Hash: []byte{0xff}})
+	if assert.Nil(t, err) {
+		assert.Nil(t, found)
+		assert.Equal(t, uint64(20), chainTip.BlockNumber)
+	}
+}
+
+func TestNodeToNodeRequestNext(t *testing.T) {
+
+	header := cbor.NewArrayWithItems([]cbor.DataItem{cbor.NewPositiveInteger(6), cbor.NewTextString("header")})
+	rollback := ChainPoint{Slot: 100, Hash: []byte{0x01}}
+	tip := cbor.NewArrayWithItems([]cbor.DataItem{rollback.dataItem(), cbor.NewPositiveInteger(10)})
+
+	client := newTestNodeToNodeClient(t, func(mux *multiplex.Mux) {
+		channel := mux.Channel(multiplex.MiniProtocolIDChainSyncHeaders, multiplex.MessageModeResponder)
+
+		// at the tip, the roll forward follows the await reply
+		nodeExchange(t, channel, uint64(ChainSyncMessageRequestNextType),
+			testMessage(ChainSyncMessageAwaitReplyType),
+			testMessage(ChainSyncMessageRollForwardType, cbor.NewEncodedCBORFromItem(header), tip))
+
+		nodeExchange(t, channel, uint64(ChainSyncMessageRequestNextType),
+			testMessage(ChainSyncMessageRollBackwardType, rollback.dataItem(), tip))
+
+		nodeExchange(t, channel, uint64(ChainSyncMessageRequestNextType),
+			testMessage(ChainSyncMessageFindIntersectType, cbor.NewArray()))
+	})
+	defer client.Close()
+
+	update, err := client.RequestNext()
+	if assert.Nil(t, err) {
+		assert.False(t, update.RollBackward)
+		assert.Equal(t, uint64(10), update.Tip.BlockNumber)
+		if assert.NotNil(t, update.Header) {
+			item, err := update.Header.(*cbor.EncodedCBOR).Item()
+			assert.Nil(t, err)
+			assert.True(t, cbor.Equal(header, item))
+		}
+	}
+
+	update, err = client.RequestNext()
+	if assert.Nil(t, err) {
+		assert.True(t, update.RollBackward)
+		assert.Equal(t, &rollback, update.Point)
+	}
+
+	// a message out of the protocol state is an error
+	_, err = client.RequestNext()
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrShellyUnexpectedCborItem)))
+}
+
+func TestNodeToNodeFetchBlocks(t *testing.T) {
+
+	from := ChainPoint{Slot: 1, Hash: []byte{0x01}}
+	to := ChainPoint{Slot: 3, Hash: []byte{0x03}}
+	block := func(number uint64) cbor.DataItem {
+		return testMessage(uint64(BlockFetchMessageBlockType),
+			cbor.NewEncodedCBORFromItem(cbor.NewPositiveInteger(number)))
+	}
+	batch := []cbor.DataItem{
+		testMessage(uint64(BlockFetchMessageStartBatchType)),
+		block(1), block(2), block(3),
+		testMessage(uint64(BlockFetchMessageBatchDoneType)),
+	}
+
+	client := newTestNodeToNodeClient(t, func(mux *multiplex.Mux) {
+		channel := mux.Channel(multiplex.MiniProtocolIDBlockFetch, multiplex.MessageModeResponder)
+		requestRange := uint64(BlockFetchMessageRequestRangeType)
+
+		nodeExchange(t, channel, requestRange, testMessage(uint64(BlockFetchMessageNoBlocksType)))
+		message := nodeExchange(t, channel, requestRange, batch...)
+		if message != nil {
+			slot, err := message.Index(2).Index(0).Uint64()
+			assert.Nil(t, err)
+			assert.Equal(t, to.Slot, slot)
+		}
+		nodeExchange(t, channel, requestRange, batch...)
+		nodeExchange(t, channel, requestRange, testMessage(uint64(BlockFetchMessageNoBlocksType)))
+	})
+	defer client.Close()
+
+	blocks := []uint64{}
+	collect := func(block *cbor.EncodedCBOR) error {
+		item, err := block.Item()
+		if err != nil {
+			return err
+		}
+		number, err := cbor.Path(item).Uint64()
+		blocks = append(blocks, number)
+		return err
+	}
+
+	// no blocks for the range
+	assert.Nil(t, client.FetchBlocks(from, to, collect))
+	assert.Equal(t, []uint64{}, blocks)
+
+	// a batch of blocks
+	assert.Nil(t, client.FetchBlocks(from, to, collect))
+	assert.Equal(t, []uint64{1, 2, 3}, blocks)
+
+	// the blocks after an error of fn are received and ignored
+	calls := 0
+	stop := e.New("stop")
+	err := client.FetchBlocks(from, to, func(block *cbor.EncodedCBOR) error {
+		calls++
+		if calls == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 2, calls)
+
+	// the next request starts after the end of the batch
+	assert.Nil(t, client.FetchBlocks(from, to, collect))
+	assert.Equal(t, []uint64{1, 2, 3}, blocks)
+}
+
+func TestNodeToNodeKeepAlive(t *testing.T) {
+
+	client := newTestNodeToNodeClient(t, func(mux *multiplex.Mux) {
+		channel := mux.Channel(multiplex.MiniProtocolIDKeepAlive, multiplex.MessageModeResponder)
+
+		for i := 0; i < 2; i++ {
+			message, _, err := receiveMessage(channel)
+			if !assert.Nil(t, err) {
+				return
+			}
+			cookie, _ := message.Index(1).Uint64()
+			if i == 1 {
+				cookie++
+			}
+			channel.Send(testMessage(uint64(KeepAliveMessageResponseType), cbor.NewPositiveInteger(cookie)))
+		}
+	})
+	defer client.Close()
+
+	_, err := client.KeepAlive()
+	assert.Nil(t, err)
+
+	// the response must echo the cookie
+	_, err = client.KeepAlive()
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrShellyUnexpectedCborItem)))
+}
+
+func TestNodeToNodeKeepAliveTimeout(t *testing.T) {
+
+	bearer, peer := multiplex.NewPipeBearers()
+	mux := multiplex.NewMux(peer)
+	defer mux.Close()
+
+	go func() {
+		channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeResponder)
+		request, err := channel.Receive()
+		if !assert.Nil(t, err) {
+			return
+		}
+		reply, _, _ := handshakeReply(request, nodeToNodeVersions, testNetworkMagic)
+		channel.Send(reply)
+	}()
+
+	client, err := NewNodeToNodeClientWithOptions(bearer, NodeToNodeOptions{
+		NetworkMagic:     testNetworkMagic,
+		KeepAliveTimeout: 50 * time.Millisecond,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer client.Close()
+
+	// the node never answers, so the client gives up and closes the connection
+	_, err = client.KeepAlive()
+	assert.True(t, e.Is(err, errors.NewError(errors.ErrShellyKeepAliveTimeout)), "%v", err)
+	<-client.Done()
+}
+
+func TestNodeToNodeUnexpectedMiniProtocol(t *testing.T) {
+
+	client := newTestNodeToNodeClient(t, func(mux *multiplex.Mux) {
+		mux.Channel(multiplex.MiniProtocolIDLocalStateQuery, multiplex.MessageModeResponder).
+			Send(testMessage(0))
+	})
+	defer client.Close()
+
+	// the client only runs the node-to-node mini protocols
+	<-client.Done()
+	assert.True(t, e.Is(client.mux.Err(), errors.NewError(errors.ErrMuxMiniProtocolUnexpected)), "%v", client.mux.Err())
+}
@@ -0,0 +1,279 @@
+package shelley
+
+import (
+	e "errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/gocardano/go-cardano-client/multiplex"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ProtocolHandler runs the responder side of a mini protocol on the channel
+// of one connection.  It returns nil when the mini protocol ends, and an
+// error, which closes the connection, when the peer violates it.  It returns
+// once the channel fails, after the connection is closed.
+type ProtocolHandler func(channel *multiplex.Channel) error
+
+// ServerOptions configures a server
+type ServerOptions struct {
+	// NetworkMagic identifies the network of the server.  Zero selects
+	// MainnetNetworkMagic.
+	NetworkMagic uint64
+
+	// NodeToNode answers the handshakes with the node-to-node versions
+	// instead of the node-to-client versions
+	NodeToNode bool
+
+	// HandshakeTimeout limits the time for a peer to propose versions.  Zero
+	// means no limit.
+	HandshakeTimeout time.Duration
+}
+
+// Server answers the handshakes of the peers connecting to it, and runs the
+// handlers registered for the mini protocols they initiate.  A segment of a
+// mini protocol without a handler violates the protocol, and closes the
+// connection.
+type Server struct {
+	options  ServerOptions
+	versions []uint64
+
+	lock      sync.Mutex
+	handlers  map[multiplex.MiniProtocol]ProtocolHandler
+	listeners map[*multiplex.Listener]struct{}
+	muxes     map[*multiplex.Mux]struct{}
+	closed    bool
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+// NewServer returns a server answering node-to-client handshakes on the mainnet
+func NewServer() *Server {
+	return NewServerWithOptions(ServerOptions{})
+}
+
+// NewServerWithOptions returns a server with the given options
+func NewServerWithOptions(options ServerOptions) *Server {
+
+	if options.NetworkMagic == 0 {
+		options.NetworkMagic = MainnetNetworkMagic
+	}
+
+	versions := nodeToClientVersions
+	if options.NodeToNode {
+		versions = nodeToNodeVersions
+	}
+
+	return &Server{
+		options:   options,
+		versions:  versions,
+		handlers:  map[multiplex.MiniProtocol]ProtocolHandler{},
+		listeners: map[*multiplex.Listener]struct{}{},
+		muxes:     map[*multiplex.Mux]struct{}{},
+	}
+}
+
+// Handle registers the handler of the mini protocol, for the connections
+// accepted afterwards
+func (s *Server) Handle(miniProtocol multiplex.MiniProtocol, handler ProtocolHandler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[miniProtocol] = handler
+}
+
+// Serve accepts the connections of the listener, which the server owns, and
+// serves each of them in its own goroutine until the listener fails or the
+// server is closed.  It returns nil after Close, and the error of the
+// listener otherwise.
+func (s *Server) Serve(listener *multiplex.Listener) error {
+
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		listener.Close()
+		return nil
+	}
+	s.listeners[listener] = struct{}{}
+	s.lock.Unlock()
+
+	defer func() {
+		s.lock.Lock()
+		delete(s.listeners, listener)
+		s.lock.Unlock()
+	}()
+
+	for {
+		bearer, err := listener.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+			log.WithError(err).Error("Error accepting connection")
+			return err
+		}
+		log.WithField("listener", listener.Addr().String()).Debug("Accepted connection")
+
+		go func() {
+			if err := s.ServeBearer(bearer); err != nil {
+				log.WithError(err).Debug("Connection ended with error")
+			}
+		}()
+	}
+}
+
+// ServeBearer answers the handshake of the peer on the bearer, which the
+// server owns, and runs the handlers until the connection ends.  It returns
+// the error of the handshake, of the handler that closed the connection, or
+// of the peer violating the protocol.
+func (s *Server) ServeBearer(bearer multiplex.Bearer) error {
+
+	s.lock.Lock()
+	handlers := make(map[multiplex.MiniProtocol]ProtocolHandler, len(s.handlers))
+	miniProtocols := []multiplex.MiniProtocol{multiplex.MiniProtocolIDMuxControl}
+	for miniProtocol, handler := range s.handlers {
+		handlers[miniProtocol] = handler
+		miniProtocols = append(miniProtocols, miniProtocol)
+	}
+	s.lock.Unlock()
+
+	mux := multiplex.NewMuxWithOptions(bearer, multiplex.MuxOptions{MiniProtocols: miniProtocols})
+	if !s.track(mux) {
+		mux.Close()
+		return errors.NewMessageErrorf(errors.ErrMuxClosed, "Server closed")
+	}
+	defer s.untrack(mux)
+
+	if s.options.HandshakeTimeout > 0 {
+		bearer.SetReadDeadline(time.Now().Add(s.options.HandshakeTimeout))
+	}
+	if err := s.handshake(mux); err != nil {
+		mux.Close()
+		return err
+	}
+	bearer.SetReadDeadline(time.Time{})
+
+	var wg sync.WaitGroup
+	var handlerErr error
+	var errLock sync.Mutex
+	for miniProtocol, handler := range handlers {
+		wg.Add(1)
+		go func(miniProtocol multiplex.MiniProtocol, handler ProtocolHandler) {
+			defer wg.Done()
+			err := handler(mux.Channel(miniProtocol, multiplex.MessageModeResponder))
+			if err == nil {
+				log.WithField("miniProtocol", miniProtocol.String()).Debug("Mini protocol ended")
+				return
+			}
+
+			select {
+			case <-mux.Done():
+				// the channel failed with the connection
+			default:
+				log.WithError(err).WithField("miniProtocol", miniProtocol.String()).Error("Mini protocol failed, closing the connection")
+				errLock.Lock()
+				handlerErr = err
+				errLock.Unlock()
+				mux.Close()
+			}
+		}(miniProtocol, handler)
+	}
+
+	<-mux.Done()
+	wg.Wait()
+	if handlerErr != nil {
+		return handlerErr
+	}
+	if err := mux.Err(); err != io.EOF && !e.Is(err, errors.NewError(errors.ErrMuxClosed)) {
+		return err
+	}
+	return nil
+}
+
+// Close closes the listeners and the connections being served
+func (s *Server) Close() error {
+
+	s.lock.Lock()
+	s.closed = true
+	listeners := make([]*multiplex.Listener, 0, len(s.listeners))
+	for listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	muxes := make([]*multiplex.Mux, 0, len(s.muxes))
+	for mux := range s.muxes {
+		muxes = append(muxes, mux)
+	}
+	s.lock.Unlock()
+
+	var err error
+	for _, listener := range listeners {
+		if closeErr := listener.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	for _, mux := range muxes {
+		mux.Close()
+	}
+	return err
+}
+
+// handshake answers the versions proposed by the peer
+func (s *Server) handshake(mux *multiplex.Mux) error {
+
+	channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeResponder)
+	request, err := channel.Receive()
+	if err != nil {
+		log.WithError(err).Error("Error receiving handshake request from peer")
+		return err
+	}
+
+	reply, response, err := handshakeReply(request, s.versions, s.options.NetworkMagic)
+	if err != nil {
+		log.WithError(err).Error("Error parsing handshake request from peer")
+		return err
+	}
+	if err := channel.Send(reply); err != nil {
+		return err
+	}
+
+	if !response.accepted {
+		log.WithField("refuseReason", response.refuseReason).Debug("Handshake refused")
+		return errors.NewMessageErrorf(errors.ErrShellyHandshakeFailed, "Handshake refused due to %s", response.refuseReason)
+	}
+
+	log.WithFields(log.Fields{
+		"versionNumber": response.versionNumber,
+		"extraParams":   cbor.Diagnostic(response.extraParams),
+	}).Debug("Handshake was accepted")
+
+	return nil
+}
+
+// track adds the multiplexer of a connection being served, unless the server
+// is closed
+func (s *Server) track(mux *multiplex.Mux) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return false
+	}
+	s.muxes[mux] = struct{}{}
+	return true
+}
+
+// untrack removes the multiplexer of a connection that ended
+func (s *Server) untrack(mux *multiplex.Mux) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.muxes, mux)
+}
+
+// isClosed returns true once Close is called
+func (s *Server) isClosed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.closed
+}
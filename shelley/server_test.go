@@ -0,0 +1,199 @@
+package shelley
+
+import (
+	e "errors"
+	"testing"
+	"time"
+
+	"github.com/gocardano/go-cardano-client/cbor"
+	"github.com/gocardano/go-cardano-client/errors"
+	"github.com/gocardano/go-cardano-client/multiplex"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveTestBearer serves the server end of a pipe in the background, and
+// returns the multiplexer of the peer end and the result of ServeBearer
+func serveTestBearer(server *Server) (*multiplex.Mux, <-chan error) {
+
+	bearer, peer := multiplex.NewPipeBearers()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeBearer(bearer)
+	}()
+	return multiplex.NewMux(peer), served
+}
+
+// proposeVersions sends the versions with their params to the server, and
+// returns its reply
+func proposeVersions(t *testing.T, mux *multiplex.Mux, versions map[uint64]cbor.DataItem) *cbor.Cursor {
+
+	versionTable := cbor.NewMap()
+	for version, params := range versions {
+		versionTable.Add(cbor.NewPositiveInteger(version), params)
+	}
+	channel := mux.Channel(multiplex.MiniProtocolIDMuxControl, multiplex.MessageModeInitiator)
+	message, _, err := exchangeMessage(channel, cbor.NewArrayWithItems([]cbor.DataItem{
+		cbor.NewPositiveInteger8(handshakeMessagePropose),
+		versionTable,
+	}))
+	if !assert.Nil(t, err) {
+		return cbor.Path(nil)
+	}
+	return message
+}
+
+// waitServed returns the result of ServeBearer, or fails after a while
+func waitServed(t *testing.T, served <-chan error) error {
+	select {
+	case err := <-served:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeBearer did not return")
+		return nil
+	}
+}
+
+func TestServerHandshakeAccept(t *testing.T) {
+
+	server := NewServerWithOptions(ServerOptions{NetworkMagic: testNetworkMagic})
+	mux, served := serveTestBearer(server)
+	defer mux.Close()
+
+	// the highest version both sides support is accepted with its params
+	magic := cbor.NewPositiveInteger(testNetworkMagic)
+	reply := proposeVersions(t, mux, map[uint64]cbor.DataItem{1: magic, 32770: magic, 40000: magic})
+
+	status, err := reply.Index(0).Uint64()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(handshakeMessageAccept), status)
+	version, err := reply.Index(1).Uint64()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(32770), version)
+	params, err := reply.Index(2).Uint64()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(testNetworkMagic), params)
+
+	// the connection ends when the peer closes it
+	mux.Close()
+	assert.Nil(t, waitServed(t, served))
+}
+
+func TestServerHandshakeRefuse(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		versions     map[uint64]cbor.DataItem
+		expectReason uint64
+	}{
+		{
+			name:         "magic mismatch",
+			versions:     map[uint64]cbor.DataItem{32770: cbor.NewPositiveInteger(MainnetNetworkMagic)},
+			expectReason: handshakeRefuseReasonRefused,
+		},
+		{
+			name:         "version mismatch",
+			versions:     map[uint64]cbor.DataItem{2: cbor.NewPositiveInteger(testNetworkMagic)},
+			expectReason: handshakeRefuseReasonVersionMismatch,
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		server := NewServerWithOptions(ServerOptions{NetworkMagic: testNetworkMagic})
+		mux, served := serveTestBearer(server)
+
+		reply := proposeVersions(t, mux, testCase.versions)
+		status, err := reply.Index(0).Uint64()
+		assert.Nil(t, err, testCase.name)
+		assert.Equal(t, uint64(handshakeMessageRefuse), status, testCase.name)
+		reason, err := reply.Index(1).Index(0).Uint64()
+		assert.Nil(t, err, testCase.name)
+		assert.Equal(t, testCase.expectReason, reason, testCase.name)
+
+		// the server closes the connection after the refusal
+		err = waitServed(t, served)
+		assert.True(t, e.Is(err, errors.NewError(errors.ErrShellyHandshakeFailed)), testCase.name)
+		<-mux.Done()
+	}
+}
+
+func TestServerHandshakeTimeout(t *testing.T) {
+
+	server := NewServerWithOptions(ServerOptions{HandshakeTimeout: 50 * time.Millisecond})
+	mux, served := serveTestBearer(server)
+	defer mux.Close()
+
+	// the peer proposes no version
+	assert.NotNil(t, waitServed(t, served))
+	<-mux.Done()
+}
+
+func TestServerProtocolViolation(t *testing.T) {
+
+	testCases := []struct {
+		name         string
+		miniProtocol multiplex.MiniProtocol
+		message      cbor.DataItem
+		expectErr    int
+	}{
+		{
+			name:         "handler error",
+			miniProtocol: multiplex.MiniProtocolIDKeepAlive,
+			message:      testMessage(uint64(KeepAliveMessageResponseType), cbor.NewPositiveInteger(1)),
+			expectErr:    errors.ErrShellyUnexpectedCborItem,
+		},
+		{
+			name:         "mini protocol without handler",
+			miniProtocol: multiplex.MiniProtocolIDChainSyncHeaders,
+			message:      testMessage(uint64(ChainSyncMessageRequestNextType)),
+			expectErr:    errors.ErrMuxMiniProtocolUnexpected,
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		server := NewServerWithOptions(ServerOptions{NetworkMagic: testNetworkMagic})
+		server.Handle(multiplex.MiniProtocolIDKeepAlive, KeepAliveHandler)
+		mux, served := serveTestBearer(server)
+
+		magic := cbor.NewPositiveInteger(testNetworkMagic)
+		proposeVersions(t, mux, map[uint64]cbor.DataItem{32770: magic})
+		mux.Channel(testCase.miniProtocol, multiplex.MessageModeInitiator).Send(testCase.message)
+
+		err := waitServed(t, served)
+		assert.True(t, e.Is(err, errors.NewError(testCase.expectErr)), "%s: %v", testCase.name, err)
+		<-mux.Done()
+	}
+}
+
+func TestServerClose(t *testing.T) {
+
+	server := NewServerWithOptions(ServerOptions{NetworkMagic: testNetworkMagic, NodeToNode: true})
+	server.Handle(multiplex.MiniProtocolIDKeepAlive, KeepAliveHandler)
+
+	bearer, peer := multiplex.NewPipeBearers()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ServeBearer(bearer)
+	}()
+	client, err := NewNodeToNodeClientWithOptions(peer, NodeToNodeOptions{NetworkMagic: testNetworkMagic})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer client.Close()
+
+	// the node-to-node client runs against the server
+	assert.Equal(t, nodeToNodeVersions[len(nodeToNodeVersions)-1], client.Version())
+	_, err = client.KeepAlive()
+	assert.Nil(t, err)
+
+	// Close ends the connections being served, and the next ones
+	assert.Nil(t, server.Close())
+	assert.Nil(t, waitServed(t, served))
+	<-client.Done()
+
+	_, err = client.KeepAlive()
+	assert.NotNil(t, err)
+	bearer, _ = multiplex.NewPipeBearers()
+	assert.True(t, e.Is(server.ServeBearer(bearer), errors.NewError(errors.ErrMuxClosed)))
+}
@@ -3,44 +3,35 @@ package shelley
 import (
 	e "errors"
 	"io"
-	"net"
 	"time"
 
 	"github.com/gocardano/go-cardano-client/errors"
 	"github.com/gocardano/go-cardano-client/multiplex"
-	"github.com/gocardano/go-cardano-client/utils"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const (
-	networkUnix = "unix"
-)
-
 // UnixSocket wraps a unix socket connection
+//
+// Deprecated: use multiplex.NewUnixBearer, which reads and writes segments
 type UnixSocket struct {
-	filename       string
-	connection     net.Conn
+	bearer         multiplex.Bearer
 	readTimeoutMs  int
 	writeTimeoutMs int
 }
 
 // NewUnixSocket returns a new instance of socket
+//
+// Deprecated: use multiplex.NewUnixBearer
 func NewUnixSocket(filename string, readTimeoutMs, writeTimeoutMs int) (*UnixSocket, error) {
 
-	if !utils.FileExists(filename) {
-		return nil, errors.NewMessageErrorf(errors.ErrSocketNotExists,
-			"Socket [%s] not found", filename)
-	}
-
-	connection, err := net.Dial(networkUnix, filename)
+	bearer, err := multiplex.NewUnixBearer(filename)
 	if err != nil {
 		return nil, err
 	}
 
 	s := &UnixSocket{
-		connection:     connection,
-		filename:       filename,
+		bearer:         bearer,
 		readTimeoutMs:  readTimeoutMs,
 		writeTimeoutMs: writeTimeoutMs,
 	}
@@ -49,94 +40,90 @@ func NewUnixSocket(filename string, readTimeoutMs, writeTimeoutMs int) (*UnixSoc
 
 // Close the socket connection
 func (s *UnixSocket) Close() error {
-	return s.connection.Close()
+	return s.bearer.Close()
 }
 
-// Write the payload to the socket and return the result
+// Write the payload, made of multiplexed segments, to the socket and return the result
 func (s *UnixSocket) Write(payload []byte) (*multiplex.ServiceDataUnit, error) {
 
-	////////////////////////////////////////////////////////////
-	// Step 1: Write to socket
-	////////////////////////////////////////////////////////////
-	s.connection.SetWriteDeadline(time.Now().Add(time.Duration(s.writeTimeoutMs) * time.Millisecond))
-	log.Debugf("Attempting to write %d bytes to socket", len(payload))
-	written, err := s.connection.Write(payload)
+	requests, err := multiplex.ParseServiceDataUnits(payload)
 	if err != nil {
-		log.WithError(err).Error("Error writing to socket")
-		return nil, errors.NewMessageErrorf(errors.ErrSocketWritingToSocket, "Error writing to socket [%s]", s.filename)
+		return nil, err
+	}
+	for _, request := range requests {
+		if err := writeServiceDataUnit(s.bearer, request, s.writeTimeoutMs); err != nil {
+			return nil, err
+		}
+	}
+	return readServiceDataUnit(s.bearer, s.readTimeoutMs)
+}
+
+// roundTrip writes the request to the bearer and returns the response
+func roundTrip(bearer multiplex.Bearer, request *multiplex.ServiceDataUnit,
+	readTimeoutMs, writeTimeoutMs int) (*multiplex.ServiceDataUnit, error) {
+
+	if err := writeServiceDataUnit(bearer, request, writeTimeoutMs); err != nil {
+		return nil, err
+	}
+	return readServiceDataUnit(bearer, readTimeoutMs)
+}
+
+// writeServiceDataUnit writes the segments of the request to the bearer
+func writeServiceDataUnit(bearer multiplex.Bearer, request *multiplex.ServiceDataUnit, writeTimeoutMs int) error {
+
+	bearer.SetWriteDeadline(time.Now().Add(time.Duration(writeTimeoutMs) * time.Millisecond))
+	written := 0
+	for _, message := range request.Messages(bearer.SDUSize()) {
+		if err := bearer.WriteSegment(message); err != nil {
+			log.WithError(err).Error("Error writing to bearer")
+			return errors.NewMessageErrorf(errors.ErrSocketWritingToSocket, "Error writing to bearer: %s", err)
+		}
+		written += multiplex.HeaderSize + len(message.Data())
 	}
-	log.Debugf("Successfully written [%d] bytes to socket", written)
+	log.Debugf("Successfully written [%d] bytes to bearer", written)
+	return nil
+}
+
+// readServiceDataUnit reads from the bearer till the response message is complete
+func readServiceDataUnit(bearer multiplex.Bearer, readTimeoutMs int) (*multiplex.ServiceDataUnit, error) {
 
-	s.connection.SetReadDeadline(time.Now().Add(time.Duration(s.readTimeoutMs) * time.Millisecond))
-	////////////////////////////////////////////////////////////
-	// Step 2: Read till EOF
-	////////////////////////////////////////////////////////////
-	response := []byte{}
+	bearer.SetReadDeadline(time.Now().Add(time.Duration(readTimeoutMs) * time.Millisecond))
+	reassembler := multiplex.NewReassembler()
+	totalReadBytes := 0
 	for {
 
-		////////////////////////////////////////////////////////////
-		// Step 2a: Read 8 bytes header to determine payload length
-		////////////////////////////////////////////////////////////
-		bytesHeader := make([]byte, multiplex.HeaderSize)
-		readBytes, err := s.connection.Read(bytesHeader)
+		header, payload, err := bearer.ReadSegment()
 		if err != nil {
 			if e.Is(err, io.EOF) {
 				// nothing to read, no-op
 				log.Trace("EOF received on reading for response, nothing to read")
 				return nil, nil
 			}
-			log.WithError(err).Error("Error reading header of 8 bytes")
-			return nil, err
-		} else if readBytes != multiplex.HeaderSize {
-			log.Errorf("Expecting to have read 8 bytes for header, but only read [%d] bytes", readBytes)
-			return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
+			if e.Is(err, io.ErrUnexpectedEOF) {
+				log.Error("Connection closed in the middle of a segment")
+				return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
+			}
+			log.WithError(err).Error("Error reading from bearer")
+			return nil, errors.NewMessageErrorf(errors.ErrSocketReadingFromSocket, "Error reading from bearer: %s", err)
 		}
+		totalReadBytes += multiplex.HeaderSize + len(payload)
 
-		header, err := multiplex.ParseHeader(bytesHeader)
-		if err != nil {
-			log.WithError(err).Error("Parsed header is invalid")
-			return nil, errors.NewError(errors.ErrShelleyPayloadInvalid)
-		}
-		response = append(response, header.Bytes()...)
+		log.WithFields(log.Fields{
+			"expectedPayloadLength": header.PayloadLength(),
+			"totalReadBytes":        totalReadBytes,
+		}).Trace("Received CBOR data")
 
 		////////////////////////////////////////////////////////////
-		// Step 2b: Reading until entire payload has been read
+		// Stop once the segments decode completely
 		////////////////////////////////////////////////////////////
-
-		totalReadBytes := 0
-		for totalReadBytes < header.PayloadLengthAsInt32() {
-			buf := make([]byte, header.PayloadLengthAsInt32()-totalReadBytes)
-			readBytes, err = s.connection.Read(buf)
-			if err != nil {
-				if err != io.EOF {
-					log.WithError(err).Error("Error reading from socket")
-				}
-				break
-			}
-
-			log.WithFields(log.Fields{
-				"expectedPayloadLength": header.PayloadLength(),
-				"readBytes":             readBytes,
-				"totalReadBytes":        totalReadBytes,
-			}).Trace("Received CBOR data")
-
-			response = append(response, buf[:readBytes]...)
-			totalReadBytes += readBytes
+		sdu, err := reassembler.Add(header, payload)
+		if err != nil {
+			return nil, err
 		}
-
-		if int(header.PayloadLength()) != multiplex.MaxSDUSize {
-			log.WithField("headerPayloadLength", header.PayloadLength()).
-				Trace("Breaking out of loop since read payload is not MaxSDUSize")
-			break
+		if sdu != nil {
+			log.WithField("responseLength", totalReadBytes).Debug("Total read response bytes from bearer")
+			return sdu, nil
 		}
+		log.Trace("Response continues in the next segment")
 	}
-
-	log.WithField("responseLength", len(response)).Debug("Total read response bytes from socket")
-
-	sdus, err := multiplex.ParseServiceDataUnits(response)
-	if err != nil {
-		return nil, err
-	}
-
-	return sdus[0], nil
 }